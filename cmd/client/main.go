@@ -2,15 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/andranikuz/gophkeeper/internal/bbolt"
 	"github.com/andranikuz/gophkeeper/internal/client"
+	"github.com/andranikuz/gophkeeper/internal/profile"
 	"github.com/andranikuz/gophkeeper/internal/session"
+	"github.com/andranikuz/gophkeeper/internal/tlsutil"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
 )
 
 // Version содержит номер версии. Задаётся через ldflags при сборке.
@@ -22,33 +29,90 @@ var BuildDate = "unknown"
 func printUsage() {
 	fmt.Println(getVersionInfo())
 	fmt.Println("Usage:")
-	fmt.Println("  client -server=<server_url> -grpc-server=<grpc-server_url> -db=<local_db_path> <command> [options]")
+	fmt.Println("  client -server=<server_url> -grpc-server=<grpc-server_url> -db=<local_db_path> -master=<master_password> <command> [options]")
+	fmt.Println("  client -profile=<name> [-config=<profiles_file>] <command> [options]")
+	fmt.Println("  Master password may also be set via the GOPHKEEPER_MASTER_PASSWORD environment variable.")
+	fmt.Println("  With an https:// server URL TLS is used for both servers: -tls-ca=<ca_bundle> and -tls-pin=<sha256_fingerprint>.")
 	fmt.Println("Commands:")
 	fmt.Println("  register             -username=<username> -password=<password>")
-	fmt.Println("  login                -username=<username> -password=<password>")
+	fmt.Println("  login                -username=<username> -password=<password> [-otp=<code>]")
+	fmt.Println("  logout               [-all]")
+	fmt.Println("  change-password      [-old=<password> -new=<password>] [-new-master=<master_password>]")
+	fmt.Println("  delete-account       -password=<password>")
+	fmt.Println("  export               -out=<archive_path>")
 	fmt.Println("  get")
 	fmt.Println("  save-credential      -login=<login> -password=<password> -meta=<meta>")
 	fmt.Println("  save-text            -text=<text>  -meta=<meta>")
 	fmt.Println("  save-card            -number=<card_number> -exp=<expiration_date> -cvv=<cvv> -holder=<card_holder_name> -meta=<meta>")
 	fmt.Println("  save-file            -file=<file_path>  -meta=<meta>")
+	fmt.Println("  get-file             -id=<item_id> -out=<file_path>")
 	fmt.Println("  sync")
+	fmt.Println("  delete               -id=<item_id>")
+	fmt.Println("  conflicts")
+	fmt.Println("  resolve              -id=<item_id> -keep=local|remote|both")
+	fmt.Println("  2fa-enroll")
+	fmt.Println("  2fa-verify           -code=<otp>")
+	fmt.Println("  profiles")
 }
 
 func main() {
 	serverURL := flag.String("server", "http://127.0.0.1:8080", "Server URL")
 	grpcServerURL := flag.String("grpc-server", "127.0.0.1:50051", "grpc server URL")
 	dbPath := flag.String("db", "data/client.db", "Path to local BoltDB file")
+	masterPassword := flag.String("master", os.Getenv("GOPHKEEPER_MASTER_PASSWORD"), "Master password for vault encryption")
+	tlsCA := flag.String("tls-ca", "", "PEM bundle of CA certificates trusted instead of the system ones")
+	tlsPin := flag.String("tls-pin", "", "SHA-256 fingerprint of the pinned server certificate")
+	sessionPath := flag.String("session", session.DefaultFile, "Path to the session file")
+	profileName := flag.String("profile", "", "Name of the profile from the profiles file")
+	profilesPath := flag.String("config", profile.DefaultPath(), "Path to the profiles file")
 	flag.Parse()
 	if flag.NArg() < 1 {
 		printUsage()
 		os.Exit(1)
 	}
 	command := flag.Arg(0)
+
+	// Настройки профиля применяются к флагам, не заданным явно в командной строке.
+	profiles, err := profile.Load(*profilesPath)
+	if err != nil {
+		fmt.Println("Profile error:", err)
+		os.Exit(1)
+	}
+	if command == "profiles" {
+		listProfiles(profiles)
+		return
+	}
+	selected, name, err := profiles.Get(*profileName)
+	if err != nil {
+		fmt.Println("Profile error:", err)
+		os.Exit(1)
+	}
+	if name != "" {
+		explicit := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+		for flagName, value := range map[string]string{
+			"server":      selected.Server,
+			"grpc-server": selected.GrpcServer,
+			"tls-ca":      selected.TLSCA,
+			"tls-pin":     selected.TLSPin,
+			"db":          selected.DB,
+			"session":     selected.Session,
+		} {
+			if value != "" && !explicit[flagName] {
+				flag.Set(flagName, value)
+			}
+		}
+	}
+
 	// Создаем базовый контекст с таймаутом.
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
 	// Открываем локальное хранилище BoltDB.
+	if err := os.MkdirAll(filepath.Dir(*dbPath), 0700); err != nil {
+		fmt.Println("Error creating local database directory:", err)
+		os.Exit(1)
+	}
 	localDB, err := bbolt.OpenLocalStorage(*dbPath)
 	if err != nil {
 		fmt.Println("Error opening local database:", err)
@@ -56,7 +120,20 @@ func main() {
 	}
 	defer localDB.Close()
 
-	cli := client.NewClient(*serverURL, *grpcServerURL, session.NewSession(), localDB)
+	// TLS включается адресом сервера со схемой https: тогда и gRPC-соединение идёт по TLS.
+	var tlsConfig *tls.Config
+	if strings.HasPrefix(*serverURL, "https://") {
+		tlsConfig, err = tlsutil.ClientConfig(*tlsCA, *tlsPin)
+		if err != nil {
+			fmt.Println("TLS configuration error:", err)
+			os.Exit(1)
+		}
+	} else if *tlsCA != "" || *tlsPin != "" {
+		fmt.Println("-tls-ca and -tls-pin require an https:// server URL")
+		os.Exit(1)
+	}
+
+	cli := client.NewClient(*serverURL, *grpcServerURL, session.NewSessionFile(*sessionPath), localDB, tlsConfig)
 
 	switch command {
 	case "register":
@@ -71,6 +148,15 @@ func main() {
 		}
 	}
 
+	// Команды, работающие с содержимым записей, требуют мастер-пароль.
+	switch command {
+	case "get", "get-file", "save-credential", "save-text", "save-card", "save-file", "sync", "conflicts":
+		if err := cli.Unlock(ctx, *masterPassword); err != nil {
+			fmt.Println("Unlock error:", err)
+			os.Exit(1)
+		}
+	}
+
 	switch command {
 	case "get":
 		getItems(ctx, cli, flag.Args()[1:])
@@ -82,10 +168,28 @@ func main() {
 		saveCard(ctx, cli, flag.Args()[1:])
 	case "save-file":
 		saveFile(ctx, cli, flag.Args()[1:])
+	case "get-file":
+		getFile(ctx, cli, flag.Args()[1:])
 	case "sync":
 		sync(ctx, cli, flag.Args()[1:])
 	case "delete":
 		delete(ctx, cli, flag.Args()[1:])
+	case "conflicts":
+		getConflicts(ctx, cli, flag.Args()[1:])
+	case "resolve":
+		resolve(ctx, cli, flag.Args()[1:])
+	case "logout":
+		logout(ctx, cli, flag.Args()[1:])
+	case "change-password":
+		changePassword(ctx, cli, *masterPassword, flag.Args()[1:])
+	case "delete-account":
+		deleteAccount(ctx, cli, flag.Args()[1:])
+	case "export":
+		exportAccount(ctx, cli, flag.Args()[1:])
+	case "2fa-enroll":
+		enrollTwoFactor(ctx, cli, flag.Args()[1:])
+	case "2fa-verify":
+		verifyTwoFactor(ctx, cli, flag.Args()[1:])
 	default:
 		if command != "register" && command != "login" {
 			fmt.Println("Unknown command:", command)
@@ -122,6 +226,7 @@ func login(ctx context.Context, cli *client.Client, args []string) {
 	cmd := flag.NewFlagSet("login", flag.ExitOnError)
 	username := cmd.String("username", "", "Username")
 	password := cmd.String("password", "", "Password")
+	otp := cmd.String("otp", "", "One-time password or recovery code")
 	if err := cmd.Parse(args); err != nil {
 		fmt.Println("Failed to parse arguments")
 		os.Exit(1)
@@ -133,8 +238,13 @@ func login(ctx context.Context, cli *client.Client, args []string) {
 	dto := client.LoginDTO{
 		Username: *username,
 		Password: *password,
+		OTP:      *otp,
 	}
 	err := cli.Login(ctx, dto)
+	if errors.Is(err, client.ErrTwoFactorRequired) {
+		fmt.Println("Two-factor authentication required: repeat login with -otp=<code from authenticator app or recovery code>")
+		os.Exit(1)
+	}
 	if err != nil {
 		fmt.Println("Login error:", err)
 		os.Exit(1)
@@ -142,6 +252,123 @@ func login(ctx context.Context, cli *client.Client, args []string) {
 	fmt.Println("Login successful. Session saved.")
 }
 
+func logout(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("logout", flag.ExitOnError)
+	all := cmd.Bool("all", false, "Log out on all devices")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if err := cli.Logout(ctx, *all); err != nil {
+		fmt.Println("Logout error:", err)
+		os.Exit(1)
+	}
+	if *all {
+		fmt.Println("Logged out on all devices.")
+		return
+	}
+	fmt.Println("Logged out. Session removed.")
+}
+
+// changePassword меняет пароль учётной записи и/или мастер-пароль. Для смены мастер-пароля
+// хранилище разблокируется текущим мастер-паролем и перешифровывается новым.
+func changePassword(ctx context.Context, cli *client.Client, masterPassword string, args []string) {
+	cmd := flag.NewFlagSet("change-password", flag.ExitOnError)
+	oldPassword := cmd.String("old", "", "Current account password")
+	newPassword := cmd.String("new", "", "New account password")
+	newMaster := cmd.String("new-master", "", "New master password")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if *newPassword == "" && *newMaster == "" {
+		fmt.Println("new account password or new master password must be provided")
+		os.Exit(1)
+	}
+	if *newPassword != "" {
+		if err := cli.ChangePassword(ctx, *oldPassword, *newPassword); err != nil {
+			fmt.Println("Password change error:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Account password changed. Other devices must log in again.")
+	}
+	if *newMaster != "" {
+		if err := cli.Unlock(ctx, masterPassword); err != nil {
+			fmt.Println("Unlock error:", err)
+			os.Exit(1)
+		}
+		if err := cli.ChangeMasterPassword(ctx, *newMaster); err != nil {
+			fmt.Println("Master password change error:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Vault re-encrypted with the new master password. Use it on all devices from now on.")
+	}
+}
+
+func deleteAccount(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("delete-account", flag.ExitOnError)
+	password := cmd.String("password", "", "Account password")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if err := cli.DeleteAccount(ctx, *password); err != nil {
+		fmt.Println("Account deletion error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Account and all data on the server deleted. Local copy is kept in the data directory.")
+}
+
+func exportAccount(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("export", flag.ExitOnError)
+	out := cmd.String("out", "gophkeeper-export.tar.gz", "Path to save the archive")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	n, err := cli.ExportAccount(ctx, *out)
+	if err != nil {
+		fmt.Println("Export error:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d bytes to %s\n", n, *out)
+}
+
+func enrollTwoFactor(ctx context.Context, cli *client.Client, args []string) {
+	enrollment, err := cli.EnrollTwoFactor(ctx)
+	if err != nil {
+		fmt.Println("2FA enrollment error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Add the account to your authenticator app using the URI or the secret:")
+	fmt.Println("  URI:   ", enrollment.URI)
+	fmt.Println("  Secret:", enrollment.Secret)
+	fmt.Println("Then confirm with: 2fa-verify -code=<code from the app>")
+}
+
+func verifyTwoFactor(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("2fa-verify", flag.ExitOnError)
+	code := cmd.String("code", "", "Code from authenticator app")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if *code == "" {
+		fmt.Println("code must be provided")
+		os.Exit(1)
+	}
+	recoveryCodes, err := cli.VerifyTwoFactor(ctx, *code)
+	if err != nil {
+		fmt.Println("2FA verification error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Two-factor authentication enabled. Store these recovery codes in a safe place,")
+	fmt.Println("each of them can be used once instead of a code from the app:")
+	for _, recoveryCode := range recoveryCodes {
+		fmt.Println("  " + recoveryCode)
+	}
+}
+
 func saveText(ctx context.Context, cli *client.Client, args []string) {
 	cmd := flag.NewFlagSet("save-text", flag.ExitOnError)
 	text := cmd.String("text", "", "Text content")
@@ -242,6 +469,26 @@ func saveFile(ctx context.Context, cli *client.Client, args []string) {
 	fmt.Println("File data saved successfully")
 }
 
+func getFile(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("get-file", flag.ExitOnError)
+	id := cmd.String("id", "", "item id")
+	out := cmd.String("out", "", "Path to save decrypted file")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if *id == "" {
+		fmt.Println("id must be provided")
+		os.Exit(1)
+	}
+	path, err := cli.GetFile(ctx, *id, *out)
+	if err != nil {
+		fmt.Println("Get file error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("File saved to", path)
+}
+
 func getItems(ctx context.Context, cli *client.Client, args []string) {
 	data, err := cli.GetItems(ctx)
 	if err != nil {
@@ -291,6 +538,64 @@ func delete(ctx context.Context, cli *client.Client, args []string) {
 	fmt.Println("Item deleted")
 }
 
+func getConflicts(ctx context.Context, cli *client.Client, args []string) {
+	conflicts, err := cli.GetConflicts(ctx)
+	if err != nil {
+		fmt.Println("Get conflicts error:", err)
+		os.Exit(1)
+	}
+
+	if len(conflicts) == 0 {
+		fmt.Println("No conflicts found.")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tType\tVersion\tUpdated At\tContent\tMeta")
+	for _, conflict := range conflicts {
+		for _, version := range []struct {
+			name string
+			item entity.DataItem
+		}{{"local", conflict.Local}, {"remote", conflict.Remote}} {
+			content := version.item.Content
+			if version.item.Deleted {
+				content = "<deleted>"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				version.item.ID,
+				version.item.Type.String(),
+				version.name,
+				version.item.UpdatedAt.Format(time.RFC3339),
+				content,
+				version.item.Meta,
+			)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Println("Failed to print conflicts")
+		os.Exit(1)
+	}
+}
+
+func resolve(ctx context.Context, cli *client.Client, args []string) {
+	cmd := flag.NewFlagSet("resolve", flag.ExitOnError)
+	id := cmd.String("id", "", "item id")
+	keep := cmd.String("keep", "", "Version to keep: local, remote or both")
+	if err := cmd.Parse(args); err != nil {
+		fmt.Println("Failed to parse arguments")
+		os.Exit(1)
+	}
+	if *id == "" || *keep == "" {
+		fmt.Println("id and keep must be provided")
+		os.Exit(1)
+	}
+	if err := cli.ResolveConflict(ctx, *id, *keep); err != nil {
+		fmt.Println("Resolve error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("Conflict resolved")
+}
+
 func sync(ctx context.Context, cli *client.Client, args []string) {
 	if err := cli.SyncGRPC(ctx); err != nil {
 		fmt.Println("Sync error:", err)
@@ -299,6 +604,28 @@ func sync(ctx context.Context, cli *client.Client, args []string) {
 	fmt.Println("Synchronization completed")
 }
 
+// listProfiles выводит профили из файла профилей, отмечая профиль по умолчанию.
+func listProfiles(profiles *profile.File) {
+	names := profiles.Names()
+	if len(names) == 0 {
+		fmt.Println("No profiles found")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Name	Server	gRPC Server	DB")
+	for _, name := range names {
+		p, _, _ := profiles.Get(name)
+		if name == profiles.Default {
+			name += " (default)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, p.Server, p.GrpcServer, p.DB)
+	}
+	if err := w.Flush(); err != nil {
+		fmt.Println("Failed to print profiles")
+		os.Exit(1)
+	}
+}
+
 func getVersionInfo() string {
 	return "Version: " + Version + ", Build Date: " + BuildDate
 }
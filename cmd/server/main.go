@@ -2,22 +2,52 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/andranikuz/gophkeeper/internal/config"
 	"github.com/andranikuz/gophkeeper/internal/server"
 )
 
 func main() {
+	// Инициализируем конфиг.
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Ошибка конфигурации: %v", err)
+	}
+	if cfg.PrintConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	// Команда migrate управляет схемой базы данных без запуска сервера.
+	if flag.Arg(0) == "migrate" {
+		if err := server.Migrate(cfg, flag.Arg(1), os.Stdout); err != nil {
+			log.Fatalf("Ошибка миграции: %v", err)
+		}
+		return
+	}
+
+	// Секрет по умолчанию и прочие небезопасные настройки проверяются только перед запуском сервера.
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Ошибка конфигурации: %v", err)
+	}
+	// SIGINT и SIGTERM отменяют контекст сервера и запускают его остановку.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	// Инициализируем сервер.
-	s, err := server.NewServer(context.Background())
+	s, err := server.NewServer(ctx, cfg)
 	if err != nil {
 		log.Fatalf("Ошибка инициализации сервера: %v", err)
 	}
 	fmt.Println("Запуск сервера:")
-	// Запускаем HTTP-сервер.
+	// Запускаем HTTP- и gRPC-серверы; Run возвращает управление после остановки.
 	if err := s.Run(); err != nil {
-		log.Fatalf("Ошибка запуска сервера: %v", err)
+		log.Fatalf("Ошибка работы сервера: %v", err)
 	}
-	log.Printf("Сервер запущен")
+	log.Printf("Сервер остановлен")
 }
@@ -0,0 +1,175 @@
+// Package account удаляет и выгружает учётные записи пользователей вместе со всеми их данными.
+package account
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// Service удаляет и выгружает учётные записи.
+type Service struct {
+	accounts  repository.AccountRepository
+	users     repository.UserRepository
+	items     repository.DataItemRepository
+	blobs     repository.BlobRepository
+	store     *blobstore.Store
+	uploadDir string // Директория файлов, загруженных до появления хранилища блобов
+}
+
+// NewService создаёт сервис учётных записей.
+func NewService(
+	accounts repository.AccountRepository,
+	users repository.UserRepository,
+	items repository.DataItemRepository,
+	blobs repository.BlobRepository,
+	store *blobstore.Store,
+	uploadDir string,
+) *Service {
+	return &Service{
+		accounts:  accounts,
+		users:     users,
+		items:     items,
+		blobs:     blobs,
+		store:     store,
+		uploadDir: uploadDir,
+	}
+}
+
+// DeleteAccount удаляет пользователя и все его данные одной транзакцией базы, после чего удаляет
+// файлы пользователя из uploadDir. Чанки файлов в хранилище блобов остаются до сборки мусора:
+// пока не истёк льготный период, на них может сослаться загрузка другого пользователя.
+func (s *Service) DeleteAccount(userID string) error {
+	if err := s.accounts.DeleteAccount(userID); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(filepath.Join(s.uploadDir, userID)); err != nil {
+		logger.ErrorLogger.Printf("Failed to remove legacy files of deleted account: %v", err)
+	}
+	logger.InfoLogger.Printf("Account %s deleted", userID)
+	return nil
+}
+
+// exportedAccount — сведения об учётной записи в архиве. Хэш пароля в выгрузку не попадает.
+type exportedAccount struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ExportAccount записывает в w архив tar.gz со сведениями об учётной записи (account.json),
+// всеми записями пользователя, включая надгробия (items.json), и файлами записей (files/<id>).
+// Содержимое записей и файлов выгружается в том виде, в каком хранится, то есть зашифрованным
+// ключом пользователя. Архив пишется потоково, файлы не загружаются в память целиком.
+func (s *Service) ExportAccount(ctx context.Context, userID string, w io.Writer) error {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	items, err := s.items.GetUserItems(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user items: %w", err)
+	}
+	if items == nil {
+		items = []entity.DataItem{}
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeJSON(tw, "account.json", exportedAccount{ID: user.ID, Username: user.Username, CreatedAt: user.CreatedAt}); err != nil {
+		return err
+	}
+	if err := writeJSON(tw, "items.json", items); err != nil {
+		return err
+	}
+	for _, item := range items {
+		if item.Type != entity.DataTypeBinary || item.Deleted {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.writeFile(tw, userID, item.ID); err != nil {
+			return fmt.Errorf("failed to export file %s: %w", item.ID, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeFile добавляет в архив файл записи. Файл, который клиент ещё не загрузил, пропускается.
+func (s *Service) writeFile(tw *tar.Writer, userID, fileID string) error {
+	data, size, err := s.openFile(userID, fileID)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.InfoLogger.Printf("Export: file %s is not uploaded yet, skipped", fileID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    "files/" + fileID,
+		Mode:    0600,
+		Size:    size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, data)
+	return err
+}
+
+// openFile открывает файл по манифесту в хранилище блобов или, для файлов, загруженных
+// до его появления, из uploadDir.
+func (s *Service) openFile(userID, fileID string) (io.ReadCloser, int64, error) {
+	manifest, err := s.blobs.GetManifest(userID, fileID)
+	if err == nil {
+		return s.store.NewReader(manifest.Chunks), manifest.Size, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, 0, err
+	}
+	file, err := os.Open(filepath.Join(s.uploadDir, userID, fileID))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// writeJSON добавляет в архив файл name с v в формате JSON.
+func writeJSON(tw *tar.Writer, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
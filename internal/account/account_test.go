@@ -0,0 +1,176 @@
+package account
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// fakeAccountRepository реализует repository.AccountRepository и запоминает удалённых пользователей.
+type fakeAccountRepository struct {
+	deleted []string
+}
+
+func (f *fakeAccountRepository) DeleteAccount(userID string) error {
+	f.deleted = append(f.deleted, userID)
+	return nil
+}
+
+// fakeUserRepository реализует repository.UserRepository для одного пользователя.
+type fakeUserRepository struct {
+	user entity.User
+}
+
+func (f *fakeUserRepository) SaveUser(user entity.User) error { return nil }
+func (f *fakeUserRepository) GetUserByUsername(username string) (*entity.User, error) {
+	return nil, repository.ErrNotFound
+}
+func (f *fakeUserRepository) GetUserByID(id string) (*entity.User, error) {
+	if id != f.user.ID {
+		return nil, repository.ErrNotFound
+	}
+	user := f.user
+	return &user, nil
+}
+func (f *fakeUserRepository) UpdatePassword(userID, passwordHash string) error { return nil }
+
+// fakeItemRepository реализует repository.DataItemRepository.
+type fakeItemRepository struct {
+	items []entity.DataItem
+}
+
+func (f *fakeItemRepository) ApplyChanges(userID string, changes []entity.DataItem) ([]entity.DataItem, []string, error) {
+	return changes, nil, nil
+}
+func (f *fakeItemRepository) GetUserItems(userID string) ([]entity.DataItem, error) {
+	return f.items, nil
+}
+func (f *fakeItemRepository) PurgeDeletedItems(before time.Time) (int64, error) { return 0, nil }
+
+// fakeBlobRepository реализует repository.BlobRepository; используются только манифесты.
+type fakeBlobRepository struct {
+	manifests map[string]entity.FileManifest
+}
+
+func (f *fakeBlobRepository) AddBlob(hash string, size int64) error { return nil }
+func (f *fakeBlobRepository) MissingBlobs(hashes []string) ([]string, error) {
+	return nil, nil
+}
+func (f *fakeBlobRepository) GetManifest(userID, fileID string) (*entity.FileManifest, error) {
+	manifest, ok := f.manifests[fileID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &manifest, nil
+}
+func (f *fakeBlobRepository) SaveManifest(manifest entity.FileManifest) error { return nil }
+func (f *fakeBlobRepository) DeleteManifest(userID, fileID string) error      { return nil }
+func (f *fakeBlobRepository) PurgeUnreferencedBlobs(before time.Time, remove func(hash string) error) ([]string, error) {
+	return nil, nil
+}
+
+// putBlob сохраняет чанк в хранилище и возвращает его хэш.
+func putBlob(t *testing.T, store *blobstore.Store, data []byte) string {
+	t.Helper()
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	require.NoError(t, store.Put(hash, data))
+	return hash
+}
+
+func newTestStore(t *testing.T) *blobstore.Store {
+	t.Helper()
+	storage, err := blobstore.NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+	return blobstore.New(storage)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	store := newTestStore(t)
+	chunk := putBlob(t, store, []byte("chunk"))
+	uploadDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadDir, "user1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "user1", "legacy"), []byte("data"), 0644))
+
+	accounts := &fakeAccountRepository{}
+	svc := NewService(accounts, &fakeUserRepository{}, &fakeItemRepository{}, &fakeBlobRepository{}, store, uploadDir)
+	require.NoError(t, svc.DeleteAccount("user1"))
+
+	assert.Equal(t, []string{"user1"}, accounts.deleted)
+	// Чанки удаляет сборщик мусора, а не удаление учётной записи.
+	data, err := store.Open(chunk)
+	require.NoError(t, err)
+	data.Close()
+	_, err = os.Stat(filepath.Join(uploadDir, "user1"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestExportAccount(t *testing.T) {
+	store := newTestStore(t)
+	first := putBlob(t, store, []byte("encrypted "))
+	second := putBlob(t, store, []byte("file"))
+	uploadDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(uploadDir, "user1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(uploadDir, "user1", "legacy"), []byte("legacy file"), 0644))
+
+	items := []entity.DataItem{
+		{ID: "text", Type: entity.DataTypeText, Content: "sealed", UserID: "user1", Revision: 1},
+		{ID: "file", Type: entity.DataTypeBinary, Content: "sealed name", UserID: "user1", Revision: 2},
+		{ID: "legacy", Type: entity.DataTypeBinary, UserID: "user1", Revision: 3},
+		{ID: "not-uploaded", Type: entity.DataTypeBinary, UserID: "user1", Revision: 4},
+		{ID: "removed", Type: entity.DataTypeBinary, UserID: "user1", Deleted: true, Revision: 5},
+	}
+	blobs := &fakeBlobRepository{manifests: map[string]entity.FileManifest{
+		"file": {UserID: "user1", FileID: "file", Size: 14, Chunks: []string{first, second}},
+	}}
+	users := &fakeUserRepository{user: entity.User{ID: "user1", Username: "alice", Password: "hash"}}
+	svc := NewService(&fakeAccountRepository{}, users, &fakeItemRepository{items: items}, blobs, store, uploadDir)
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.ExportAccount(context.Background(), "user1", &buf))
+
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	entries := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		entries[header.Name] = data
+	}
+
+	assert.Len(t, entries, 4)
+	var account map[string]any
+	require.NoError(t, json.Unmarshal(entries["account.json"], &account))
+	assert.Equal(t, "alice", account["username"])
+	assert.NotContains(t, account, "password")
+	var exported []entity.DataItem
+	require.NoError(t, json.Unmarshal(entries["items.json"], &exported))
+	assert.Len(t, exported, len(items))
+	assert.Equal(t, "encrypted file", string(entries["files/file"]))
+	assert.Equal(t, "legacy file", string(entries["files/legacy"]))
+
+	_, err = os.Stat(filepath.Join(uploadDir, "user1", "legacy"))
+	assert.NoError(t, err, "Выгрузка не должна изменять данные")
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"time"
 
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 	"github.com/andranikuz/gophkeeper/pkg/services"
+	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -19,6 +21,13 @@ const (
 type Authenticator struct {
 	tokenSecret     string
 	tokenExpiration time.Duration
+
+	// Токены обновления; без хранилища они не выдаются.
+	refreshTokens     repository.RefreshTokenRepository
+	refreshExpiration time.Duration
+
+	// Список отозванных до истечения срока токенов; без него отзыв не поддерживается.
+	revokedTokens repository.RevokedTokenRepository
 }
 
 // NewAuthenticator создаёт новый экземпляр Authenticator.
@@ -47,12 +56,18 @@ func (a *Authenticator) CheckPasswordHash(password, hash string) bool {
 
 // GenerateToken генерирует JWT-токен для пользователя с указанным идентификатором и именем.
 // Токен подписывается секретным ключом и имеет ограниченный срок действия.
+// Уникальный идентификатор токена (jti) позволяет отозвать его до истечения срока.
 func (a *Authenticator) GenerateToken(userID string, username string) (string, error) {
+	jti, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
 	expirationTime := time.Now().Add(a.tokenExpiration)
 	claims := &services.Claims{
 		UserID:   userID,
 		Username: username,
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti.String(),
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  time.Now().Unix(),
 			Issuer:    "gophkeeper",
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// refreshTokenBytes — длина случайной части токена обновления.
+const refreshTokenBytes = 32
+
+// WithRefreshTokens включает выдачу токенов обновления, хранящихся в repo и действующих ttl.
+func (a *Authenticator) WithRefreshTokens(repo repository.RefreshTokenRepository, ttl time.Duration) *Authenticator {
+	a.refreshTokens = repo
+	a.refreshExpiration = ttl
+	return a
+}
+
+// IssueRefreshToken выпускает токен обновления, начинающий новое семейство.
+// Если хранилище токенов не настроено, возвращается пустая строка.
+func (a *Authenticator) IssueRefreshToken(userID, username string) (string, error) {
+	if a.refreshTokens == nil {
+		return "", nil
+	}
+	familyID, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	token, stored, err := a.newRefreshToken(userID, username, familyID.String())
+	if err != nil {
+		return "", err
+	}
+	if err := a.refreshTokens.SaveRefreshToken(stored); err != nil {
+		return "", fmt.Errorf("failed to save refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// RefreshTokens обменивает токен обновления на новый access-токен и новый токен обновления.
+// Предъявленный токен при этом отзывается. Повторное предъявление уже отозванного токена
+// считается признаком кражи: всё семейство отзывается, и пользователю придётся войти заново.
+func (a *Authenticator) RefreshTokens(refreshToken string) (*services.TokenPair, error) {
+	if a.refreshTokens == nil || refreshToken == "" {
+		return nil, services.ErrInvalidRefreshToken
+	}
+	hash := hashRefreshToken(refreshToken)
+	stored, err := a.refreshTokens.GetRefreshToken(hash)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, services.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Revoked {
+		return nil, a.revokeFamily(stored.FamilyID)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, services.ErrInvalidRefreshToken
+	}
+
+	next, nextStored, err := a.newRefreshToken(stored.UserID, stored.Username, stored.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+	err = a.refreshTokens.RotateRefreshToken(hash, nextStored)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Токен успели использовать между чтением и ротацией.
+		return nil, a.revokeFamily(stored.FamilyID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	access, err := a.GenerateToken(stored.UserID, stored.Username)
+	if err != nil {
+		return nil, err
+	}
+	return &services.TokenPair{UserID: stored.UserID, AccessToken: access, RefreshToken: next}, nil
+}
+
+// revokeFamily отзывает семейство токенов после повторного использования токена
+// и возвращает services.ErrInvalidRefreshToken.
+func (a *Authenticator) revokeFamily(familyID string) error {
+	if err := a.refreshTokens.RevokeRefreshTokenFamily(familyID); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return services.ErrInvalidRefreshToken
+}
+
+// newRefreshToken генерирует токен обновления и его запись для хранилища.
+func (a *Authenticator) newRefreshToken(userID, username, familyID string) (string, entity.RefreshToken, error) {
+	buf := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", entity.RefreshToken{}, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	now := time.Now().UTC()
+	return token, entity.RefreshToken{
+		TokenHash: hashRefreshToken(token),
+		UserID:    userID,
+		Username:  username,
+		FamilyID:  familyID,
+		ExpiresAt: now.Add(a.refreshExpiration),
+		CreatedAt: now,
+	}, nil
+}
+
+// hashRefreshToken возвращает SHA-256 токена в hex: в базе токены хранятся только в таком виде.
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
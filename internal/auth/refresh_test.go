@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// fakeRefreshTokenRepo хранит токены обновления в памяти.
+type fakeRefreshTokenRepo struct {
+	tokens map[string]entity.RefreshToken
+}
+
+func newFakeRefreshTokenRepo() *fakeRefreshTokenRepo {
+	return &fakeRefreshTokenRepo{tokens: make(map[string]entity.RefreshToken)}
+}
+
+func (f *fakeRefreshTokenRepo) SaveRefreshToken(token entity.RefreshToken) error {
+	f.tokens[token.TokenHash] = token
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) GetRefreshToken(tokenHash string) (*entity.RefreshToken, error) {
+	token, ok := f.tokens[tokenHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &token, nil
+}
+
+func (f *fakeRefreshTokenRepo) RotateRefreshToken(oldHash string, next entity.RefreshToken) error {
+	old, ok := f.tokens[oldHash]
+	if !ok || old.Revoked {
+		return repository.ErrNotFound
+	}
+	old.Revoked = true
+	f.tokens[oldHash] = old
+	f.tokens[next.TokenHash] = next
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) RevokeRefreshTokenFamily(familyID string) error {
+	for hash, token := range f.tokens {
+		if token.FamilyID == familyID {
+			token.Revoked = true
+			f.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) RevokeUserRefreshTokens(userID string) error {
+	for hash, token := range f.tokens {
+		if token.UserID == userID {
+			token.Revoked = true
+			f.tokens[hash] = token
+		}
+	}
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) PurgeExpiredRefreshTokens(before time.Time) (int64, error) {
+	var n int64
+	for hash, token := range f.tokens {
+		if token.ExpiresAt.Before(before) {
+			delete(f.tokens, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func TestRefreshTokens_Rotation(t *testing.T) {
+	repo := newFakeRefreshTokenRepo()
+	a := NewAuthenticator("secret", 60).WithRefreshTokens(repo, time.Hour)
+
+	first, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+	// В хранилище попадает только хэш токена.
+	_, err = repo.GetRefreshToken(first)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	tokens, err := a.RefreshTokens(first)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", tokens.UserID)
+	assert.NotEqual(t, first, tokens.RefreshToken)
+	claims, err := a.ValidateToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "john_doe", claims.Username)
+
+	// Новый токен продолжает цепочку.
+	next, err := a.RefreshTokens(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, next.RefreshToken)
+}
+
+func TestRefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	repo := newFakeRefreshTokenRepo()
+	a := NewAuthenticator("secret", 60).WithRefreshTokens(repo, time.Hour)
+
+	first, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	other, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	tokens, err := a.RefreshTokens(first)
+	require.NoError(t, err)
+
+	// Повторное предъявление использованного токена отзывает всю цепочку.
+	_, err = a.RefreshTokens(first)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	_, err = a.RefreshTokens(tokens.RefreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// Сессии других устройств не затрагиваются.
+	_, err = a.RefreshTokens(other)
+	assert.NoError(t, err)
+}
+
+func TestRefreshTokens_Invalid(t *testing.T) {
+	repo := newFakeRefreshTokenRepo()
+	a := NewAuthenticator("secret", 60).WithRefreshTokens(repo, -time.Hour)
+
+	_, err := a.RefreshTokens("unknown")
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	expired, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	_, err = a.RefreshTokens(expired)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+	n, err := repo.PurgeExpiredRefreshTokens(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	// Без хранилища токены обновления не выдаются.
+	plain := NewAuthenticator("secret", 60)
+	token, err := plain.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+	_, err = plain.RefreshTokens("anything")
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// WithDenylist включает отзыв JWT-токенов до истечения срока с хранением списка отозванных в repo.
+func (a *Authenticator) WithDenylist(repo repository.RevokedTokenRepository) *Authenticator {
+	a.revokedTokens = repo
+	return a
+}
+
+// RevokeToken отзывает токен с указанными claims. Токены без jti, выпущенные до появления отзыва,
+// отозвать по отдельности нельзя: они действуют до истечения срока или до выхода на всех устройствах.
+func (a *Authenticator) RevokeToken(claims *services.Claims) error {
+	if a.revokedTokens == nil {
+		return errors.New("token revocation is not configured")
+	}
+	if claims.Id == "" {
+		return nil
+	}
+	return a.revokedTokens.RevokeToken(claims.Id, claims.UserID, time.Unix(claims.ExpiresAt, 0))
+}
+
+// RevokeAllTokens завершает все сессии пользователя: отзывает выпущенные ему JWT-токены
+// и токены обновления.
+func (a *Authenticator) RevokeAllTokens(userID string) error {
+	if a.revokedTokens == nil {
+		return errors.New("token revocation is not configured")
+	}
+	// iat хранится с точностью до секунды, поэтому граница отзыва округляется так же:
+	// токен, выпущенный сразу после выхода в ту же секунду, остаётся действительным.
+	if err := a.revokedTokens.RevokeUserTokens(userID, time.Now().Truncate(time.Second)); err != nil {
+		return fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+	if a.refreshTokens != nil {
+		if err := a.refreshTokens.RevokeUserRefreshTokens(userID); err != nil {
+			return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+// RevokeRefreshToken отзывает токен обновления вместе с его семейством. Неизвестный токен игнорируется.
+func (a *Authenticator) RevokeRefreshToken(refreshToken string) error {
+	if a.refreshTokens == nil || refreshToken == "" {
+		return nil
+	}
+	stored, err := a.refreshTokens.GetRefreshToken(hashRefreshToken(refreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return a.refreshTokens.RevokeRefreshTokenFamily(stored.FamilyID)
+}
+
+// IsTokenRevoked сообщает, отозван ли действительный по подписи и сроку токен.
+func (a *Authenticator) IsTokenRevoked(claims *services.Claims) (bool, error) {
+	if a.revokedTokens == nil {
+		return false, nil
+	}
+	return a.revokedTokens.IsTokenRevoked(claims.Id, claims.UserID, time.Unix(claims.IssuedAt, 0))
+}
@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// fakeRevokedTokenRepo хранит список отозванных токенов в памяти.
+type fakeRevokedTokenRepo struct {
+	tokens        map[string]time.Time
+	revokedBefore map[string]time.Time
+}
+
+func newFakeRevokedTokenRepo() *fakeRevokedTokenRepo {
+	return &fakeRevokedTokenRepo{tokens: make(map[string]time.Time), revokedBefore: make(map[string]time.Time)}
+}
+
+func (f *fakeRevokedTokenRepo) RevokeToken(jti, userID string, expiresAt time.Time) error {
+	f.tokens[jti] = expiresAt
+	return nil
+}
+
+func (f *fakeRevokedTokenRepo) RevokeUserTokens(userID string, before time.Time) error {
+	f.revokedBefore[userID] = before
+	return nil
+}
+
+func (f *fakeRevokedTokenRepo) IsTokenRevoked(jti, userID string, issuedAt time.Time) (bool, error) {
+	if _, ok := f.tokens[jti]; ok {
+		return true, nil
+	}
+	before, ok := f.revokedBefore[userID]
+	return ok && issuedAt.Before(before), nil
+}
+
+func (f *fakeRevokedTokenRepo) PurgeExpiredRevokedTokens(before time.Time) (int64, error) {
+	var n int64
+	for jti, expiresAt := range f.tokens {
+		if expiresAt.Before(before) {
+			delete(f.tokens, jti)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// validate выпускает и проверяет токен, возвращая его claims.
+func validate(t *testing.T, a *Authenticator, userID string) *services.Claims {
+	t.Helper()
+	token, err := a.GenerateToken(userID, "john_doe")
+	require.NoError(t, err)
+	claims, err := a.ValidateToken(token)
+	require.NoError(t, err)
+	return claims
+}
+
+func TestRevokeToken(t *testing.T) {
+	a := NewAuthenticator("secret", 60).WithDenylist(newFakeRevokedTokenRepo())
+
+	first := validate(t, a, "user123")
+	second := validate(t, a, "user123")
+	// У каждого токена свой идентификатор.
+	require.NotEmpty(t, first.Id)
+	assert.NotEqual(t, first.Id, second.Id)
+
+	require.NoError(t, a.RevokeToken(first))
+	revoked, err := a.IsTokenRevoked(first)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = a.IsTokenRevoked(second)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Без списка отозванных токены не отзываются.
+	plain := NewAuthenticator("secret", 60)
+	assert.Error(t, plain.RevokeToken(first))
+	revoked, err = plain.IsTokenRevoked(first)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevokeAllTokens(t *testing.T) {
+	refreshRepo := newFakeRefreshTokenRepo()
+	a := NewAuthenticator("secret", 60).
+		WithRefreshTokens(refreshRepo, time.Hour).
+		WithDenylist(newFakeRevokedTokenRepo())
+
+	refreshToken, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	claims := validate(t, a, "user123")
+	other := validate(t, a, "user456")
+	// Токен выпущен в прошлую секунду: граница отзыва округляется до секунды.
+	claims.IssuedAt--
+
+	require.NoError(t, a.RevokeAllTokens("user123"))
+	revoked, err := a.IsTokenRevoked(claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = a.RefreshTokens(refreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// Сессии других пользователей не затрагиваются.
+	revoked, err = a.IsTokenRevoked(other)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	a := NewAuthenticator("secret", 60).WithRefreshTokens(newFakeRefreshTokenRepo(), time.Hour)
+
+	refreshToken, err := a.IssueRefreshToken("user123", "john_doe")
+	require.NoError(t, err)
+	require.NoError(t, a.RevokeRefreshToken(refreshToken))
+	_, err = a.RefreshTokens(refreshToken)
+	assert.ErrorIs(t, err, services.ErrInvalidRefreshToken)
+
+	// Неизвестный токен игнорируется.
+	assert.NoError(t, a.RevokeRefreshToken("unknown"))
+}
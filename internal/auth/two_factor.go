@@ -0,0 +1,194 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+const (
+	// totpPeriod — длительность временного шага TOTP.
+	totpPeriod = 30 * time.Second
+	// totpSkew — сколько соседних шагов принимается с учётом расхождения часов.
+	totpSkew = 1
+	// recoveryCodeCount — число выдаваемых кодов восстановления.
+	recoveryCodeCount = 10
+	// recoveryCodeBytes — длина случайной части кода восстановления. 80 бит не позволяют
+	// подобрать код по утёкшему хэшу, поэтому медленное хэширование не нужно.
+	recoveryCodeBytes = 10
+)
+
+// totpOpts — параметры TOTP, совместимые с распространёнными приложениями-аутентификаторами.
+var totpOpts = totp.ValidateOpts{
+	Period:    uint(totpPeriod / time.Second),
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// TwoFactor реализует двухфакторную аутентификацию по TOTP (RFC 6238) с кодами восстановления.
+type TwoFactor struct {
+	repo   repository.TwoFactorRepository
+	issuer string
+	now    func() time.Time
+}
+
+// NewTwoFactor создаёт сервис двухфакторной аутентификации. issuer отображается в приложении-аутентификаторе.
+func NewTwoFactor(repo repository.TwoFactorRepository, issuer string) *TwoFactor {
+	return &TwoFactor{repo: repo, issuer: issuer, now: time.Now}
+}
+
+// Enroll генерирует новый секрет. До подтверждения кодом двухфакторная аутентификация не действует,
+// а повторный вызов заменяет секрет. Включённую двухфакторную аутентификацию подключить заново нельзя.
+func (t *TwoFactor) Enroll(userID, username string) (*services.TOTPEnrollment, error) {
+	current, err := t.repo.GetTwoFactor(userID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	if current != nil && current.Enabled {
+		return nil, services.ErrTwoFactorEnabled
+	}
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      t.issuer,
+		AccountName: username,
+		Period:      totpOpts.Period,
+		Digits:      totpOpts.Digits,
+		Algorithm:   totpOpts.Algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = t.repo.SaveTwoFactor(entity.TwoFactor{UserID: userID, Secret: key.Secret(), CreatedAt: t.now().UTC()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+	return &services.TOTPEnrollment{Secret: key.Secret(), URI: key.URL()}, nil
+}
+
+// Confirm проверяет код из приложения и включает двухфакторную аутентификацию.
+// Коды восстановления возвращаются только здесь: сервер хранит лишь их хэши.
+func (t *TwoFactor) Confirm(userID, code string) ([]string, error) {
+	tf, err := t.repo.GetTwoFactor(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, services.ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, services.ErrTwoFactorEnabled
+	}
+	if err := t.verifyTOTP(tf, code); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := t.repo.EnableTwoFactor(userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	return codes, nil
+}
+
+// Enabled сообщает, включена ли у пользователя двухфакторная аутентификация.
+func (t *TwoFactor) Enabled(userID string) (bool, error) {
+	tf, err := t.repo.GetTwoFactor(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// Verify принимает код TOTP или код восстановления. Каждый код принимается только один раз.
+func (t *TwoFactor) Verify(userID, code string) error {
+	tf, err := t.repo.GetTwoFactor(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return services.ErrTwoFactorNotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if !tf.Enabled {
+		return services.ErrTwoFactorNotEnrolled
+	}
+	code = strings.TrimSpace(code)
+	if len(code) == int(totpOpts.Digits) {
+		return t.verifyTOTP(tf, code)
+	}
+	ok, err := t.repo.UseRecoveryCode(userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return services.ErrInvalidOTP
+	}
+	return nil
+}
+
+// verifyTOTP проверяет код TOTP в пределах totpSkew шагов от текущего времени и отмечает его шаг
+// использованным, чтобы перехваченный код нельзя было предъявить повторно.
+func (t *TwoFactor) verifyTOTP(tf *entity.TwoFactor, code string) error {
+	now := t.now()
+	current := now.Unix() / int64(totpOpts.Period)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totp.GenerateCodeCustom(tf.Secret, time.Unix(step*int64(totpOpts.Period), 0), totpOpts)
+		if err != nil {
+			return err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+		ok, err := t.repo.UseTOTPStep(tf.UserID, step)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return services.ErrInvalidOTP
+		}
+		return nil
+	}
+	return services.ErrInvalidOTP
+}
+
+// newRecoveryCode генерирует код восстановления вида xxxx-xxxx-xxxx-xxxx.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(buf))
+	groups := make([]string, 0, len(code)/4)
+	for i := 0; i < len(code); i += 4 {
+		groups = append(groups, code[i:i+4])
+	}
+	return strings.Join(groups, "-"), nil
+}
+
+// hashRecoveryCode нормализует код восстановления и возвращает его SHA-256 в hex.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// fakeTwoFactorRepo хранит настройки двухфакторной аутентификации в памяти.
+type fakeTwoFactorRepo struct {
+	settings      map[string]entity.TwoFactor
+	recoveryCodes map[string]map[string]bool
+}
+
+func newFakeTwoFactorRepo() *fakeTwoFactorRepo {
+	return &fakeTwoFactorRepo{settings: make(map[string]entity.TwoFactor), recoveryCodes: make(map[string]map[string]bool)}
+}
+
+func (f *fakeTwoFactorRepo) SaveTwoFactor(tf entity.TwoFactor) error {
+	f.settings[tf.UserID] = tf
+	return nil
+}
+
+func (f *fakeTwoFactorRepo) GetTwoFactor(userID string) (*entity.TwoFactor, error) {
+	tf, ok := f.settings[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &tf, nil
+}
+
+func (f *fakeTwoFactorRepo) EnableTwoFactor(userID string, recoveryCodeHashes []string) error {
+	tf, ok := f.settings[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	tf.Enabled = true
+	f.settings[userID] = tf
+	f.recoveryCodes[userID] = make(map[string]bool)
+	for _, hash := range recoveryCodeHashes {
+		f.recoveryCodes[userID][hash] = true
+	}
+	return nil
+}
+
+func (f *fakeTwoFactorRepo) UseTOTPStep(userID string, step int64) (bool, error) {
+	tf := f.settings[userID]
+	if tf.LastUsedStep >= step {
+		return false, nil
+	}
+	tf.LastUsedStep = step
+	f.settings[userID] = tf
+	return true, nil
+}
+
+func (f *fakeTwoFactorRepo) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	if !f.recoveryCodes[userID][codeHash] {
+		return false, nil
+	}
+	delete(f.recoveryCodes[userID], codeHash)
+	return true, nil
+}
+
+// codeAt возвращает код TOTP секрета в момент at.
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+	require.NoError(t, err)
+	return code
+}
+
+func TestTwoFactor_EnrollAndVerify(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tf := NewTwoFactor(newFakeTwoFactorRepo(), "GophKeeper")
+	tf.now = func() time.Time { return now }
+
+	enrollment, err := tf.Enroll("user123", "john_doe")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.URI, "otpauth://totp/GophKeeper:john_doe?"))
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+
+	// До подтверждения второй фактор не действует.
+	enabled, err := tf.Enabled("user123")
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	_, err = tf.Confirm("user123", "000000")
+	assert.ErrorIs(t, err, services.ErrInvalidOTP)
+
+	recoveryCodes, err := tf.Confirm("user123", codeAt(t, enrollment.Secret, now))
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, recoveryCodeCount)
+	enabled, err = tf.Enabled("user123")
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	_, err = tf.Enroll("user123", "john_doe")
+	assert.ErrorIs(t, err, services.ErrTwoFactorEnabled)
+
+	// Код, использованный при подтверждении, повторно не принимается, а код следующего шага принимается.
+	assert.ErrorIs(t, tf.Verify("user123", codeAt(t, enrollment.Secret, now)), services.ErrInvalidOTP)
+	now = now.Add(totpPeriod)
+	assert.NoError(t, tf.Verify("user123", codeAt(t, enrollment.Secret, now)))
+	// Коды за пределами допустимого расхождения часов отклоняются.
+	assert.ErrorIs(t, tf.Verify("user123", codeAt(t, enrollment.Secret, now.Add(5*totpPeriod))), services.ErrInvalidOTP)
+}
+
+func TestTwoFactor_RecoveryCodes(t *testing.T) {
+	now := time.Now()
+	tf := NewTwoFactor(newFakeTwoFactorRepo(), "GophKeeper")
+	tf.now = func() time.Time { return now }
+
+	enrollment, err := tf.Enroll("user123", "john_doe")
+	require.NoError(t, err)
+	recoveryCodes, err := tf.Confirm("user123", codeAt(t, enrollment.Secret, now))
+	require.NoError(t, err)
+
+	// Код восстановления принимается один раз, независимо от регистра и разделителей.
+	code := strings.ToUpper(strings.ReplaceAll(recoveryCodes[0], "-", ""))
+	assert.NoError(t, tf.Verify("user123", code))
+	assert.ErrorIs(t, tf.Verify("user123", recoveryCodes[0]), services.ErrInvalidOTP)
+	assert.NoError(t, tf.Verify("user123", recoveryCodes[1]))
+
+	// У пользователя без второго фактора проверять нечего.
+	assert.ErrorIs(t, tf.Verify("user456", "123456"), services.ErrTwoFactorNotEnrolled)
+}
@@ -0,0 +1,88 @@
+package bbolt
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// SaveConflicts сохраняет серверные версии конфликтующих записей.
+// Для каждой записи хранится только последняя полученная серверная версия.
+func (ls BboltStorage) SaveConflicts(items []entity.DataItem) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(conflictsBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", conflictsBucketName)
+		}
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(item.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetConflicts возвращает серверные версии всех неразрешённых конфликтов.
+func (ls BboltStorage) GetConflicts() ([]entity.DataItem, error) {
+	var items []entity.DataItem
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(conflictsBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", conflictsBucketName)
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var item entity.DataItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			items = append(items, item)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// GetConflictByID возвращает серверную версию конфликтующей записи по её ID.
+func (ls BboltStorage) GetConflictByID(id string) (*entity.DataItem, error) {
+	var item *entity.DataItem
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(conflictsBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", conflictsBucketName)
+		}
+		data := bucket.Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("conflict for item %s not found", id)
+		}
+		if err := json.Unmarshal(data, &item); err != nil {
+			return fmt.Errorf("failed to unmarshal item: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// DeleteConflict удаляет конфликт записи после его разрешения.
+func (ls BboltStorage) DeleteConflict(id string) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(conflictsBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", conflictsBucketName)
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
@@ -0,0 +1,44 @@
+package bbolt
+
+import (
+	"fmt"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// syncCursorKey — ключ курсора последней синхронизации в бакете meta.
+const syncCursorKey = "sync_cursor"
+
+// GetSyncCursor возвращает курсор последней синхронизации; 0, если синхронизации ещё не было.
+func (ls BboltStorage) GetSyncCursor() (int64, error) {
+	var cursor int64
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", metaBucketName)
+		}
+		data := bucket.Get([]byte(syncCursorKey))
+		if data == nil {
+			return nil
+		}
+		var err error
+		cursor, err = strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return fmt.Errorf("failed to parse sync cursor: %w", err)
+		}
+		return nil
+	})
+	return cursor, err
+}
+
+// SaveSyncCursor сохраняет курсор последней синхронизации.
+func (ls BboltStorage) SaveSyncCursor(cursor int64) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucketName))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", metaBucketName)
+		}
+		return bucket.Put([]byte(syncCursorKey), []byte(strconv.FormatInt(cursor, 10)))
+	})
+}
@@ -0,0 +1,115 @@
+package bbolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/andranikuz/gophkeeper/internal/vault"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// schemaVersionKey — ключ версии схемы локальной базы в бакете meta.
+const schemaVersionKey = "schema_version"
+
+// plaintextBucketName — бакет с ID записей, сохранённых версиями клиента без шифрования.
+// Такие записи шифруются при первой разблокировке хранилища, когда известен ключ.
+const plaintextBucketName = "plaintext"
+
+// migration — шаг обновления схемы локальной базы. Шаг выполняется в транзакции открытия базы
+// и может переписывать существующие записи.
+type migration struct {
+	version int
+	name    string
+	apply   func(tx *bolt.Tx) error
+}
+
+// migrations — шаги обновления схемы по возрастанию версии. Базы без версии имеют версию 0.
+var migrations = []migration{
+	{version: 1, name: "flag plaintext items", apply: flagPlaintextItems},
+}
+
+// schemaVersion возвращает текущую версию схемы, которую создаёт и понимает клиент.
+func schemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate обновляет схему базы до текущей версии. База, созданная более новой версией клиента,
+// не открывается, чтобы не повредить записи в незнакомом формате.
+func migrate(tx *bolt.Tx) error {
+	meta := tx.Bucket([]byte(metaBucketName))
+	version := 0
+	if data := meta.Get([]byte(schemaVersionKey)); data != nil {
+		var err error
+		version, err = strconv.Atoi(string(data))
+		if err != nil {
+			return fmt.Errorf("failed to parse schema version: %w", err)
+		}
+	}
+	if version > schemaVersion() {
+		return fmt.Errorf("local database schema version %d is newer than supported %d: update the client", version, schemaVersion())
+	}
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+		if err := m.apply(tx); err != nil {
+			return fmt.Errorf("failed to migrate local database to version %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return meta.Put([]byte(schemaVersionKey), []byte(strconv.Itoa(schemaVersion())))
+}
+
+// flagPlaintextItems отмечает записи, сохранённые версиями клиента без шифрования:
+// их содержимое или метаинформация не являются шифротекстом.
+func flagPlaintextItems(tx *bolt.Tx) error {
+	plaintext, err := tx.CreateBucketIfNotExists([]byte(plaintextBucketName))
+	if err != nil {
+		return err
+	}
+	return tx.Bucket([]byte(bucketName)).ForEach(func(k, v []byte) error {
+		var item entity.DataItem
+		if err := json.Unmarshal(v, &item); err != nil {
+			return fmt.Errorf("failed to unmarshal item %s: %w", k, err)
+		}
+		if item.Deleted || (isSealed(item.Content) && isSealed(item.Meta)) {
+			return nil
+		}
+		return plaintext.Put(k, []byte{})
+	})
+}
+
+// isSealed сообщает, является ли строка шифротекстом хранилища. Пустая строка допустима
+// для надгробий и записей без метаинформации.
+func isSealed(s string) bool {
+	return s == "" || vault.IsEncryptedString(s)
+}
+
+// GetPlaintextItemIDs возвращает ID записей, ещё не зашифрованных после обновления клиента.
+func (ls BboltStorage) GetPlaintextItemIDs() ([]string, error) {
+	var ids []string
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(plaintextBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// ClearPlaintextItem снимает с записи отметку о незашифрованном содержимом.
+func (ls BboltStorage) ClearPlaintextItem(id string) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(plaintextBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
@@ -0,0 +1,112 @@
+package bbolt
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/andranikuz/gophkeeper/internal/vault"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// writeUnversionedDB создаёт базу в формате клиента без версии схемы: только бакет data с записями.
+func writeUnversionedDB(t *testing.T, path string, items []entity.DataItem) {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte(bucketName))
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(item.ID), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// readSchemaVersion возвращает версию схемы, записанную в базе.
+func readSchemaVersion(t *testing.T, ls BboltStorage) string {
+	t.Helper()
+	var version string
+	require.NoError(t, ls.db.View(func(tx *bolt.Tx) error {
+		version = string(tx.Bucket([]byte(metaBucketName)).Get([]byte(schemaVersionKey)))
+		return nil
+	}))
+	return version
+}
+
+func TestOpenLocalStorage_MigratesUnversionedDB(t *testing.T) {
+	cipher, err := vault.NewCipherFromKey(make([]byte, 32))
+	require.NoError(t, err)
+	sealed, err := cipher.EncryptString("secret")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "client.db")
+	items := []entity.DataItem{
+		{ID: "sealed", Content: sealed, Meta: sealed, Revision: 1},
+		{ID: "sealed-no-meta", Content: sealed},
+		{ID: "plain", Content: "plain text", Meta: "meta", Revision: 2},
+		{ID: "plain-meta", Content: sealed, Meta: "meta"},
+		{ID: "tombstone", Deleted: true, Revision: 3},
+	}
+	writeUnversionedDB(t, path, items)
+
+	ls, err := OpenLocalStorage(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1", readSchemaVersion(t, ls))
+	ids, err := ls.GetPlaintextItemIDs()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"plain", "plain-meta"}, ids)
+	// Записи сохраняются без изменений.
+	got, err := ls.GetAllItems()
+	require.NoError(t, err)
+	assert.Len(t, got, len(items))
+
+	// Отметка снимается после шифрования записи, повторное открытие её не возвращает.
+	require.NoError(t, ls.ClearPlaintextItem("plain"))
+	require.NoError(t, ls.Close())
+	ls, err = OpenLocalStorage(path)
+	require.NoError(t, err)
+	ids, err = ls.GetPlaintextItemIDs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"plain-meta"}, ids)
+	require.NoError(t, ls.Close())
+}
+
+func TestOpenLocalStorage_NewDB(t *testing.T) {
+	ls, err := OpenLocalStorage(filepath.Join(t.TempDir(), "client.db"))
+	require.NoError(t, err)
+	defer ls.Close()
+	assert.Equal(t, "1", readSchemaVersion(t, ls))
+	ids, err := ls.GetPlaintextItemIDs()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestOpenLocalStorage_NewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.db")
+	ls, err := OpenLocalStorage(path)
+	require.NoError(t, err)
+	require.NoError(t, ls.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte(metaBucketName)).Put([]byte(schemaVersionKey), []byte("99"))
+	}))
+	require.NoError(t, ls.Close())
+
+	// База более новой версии клиента не открывается.
+	_, err = OpenLocalStorage(path)
+	assert.ErrorContains(t, err, "update the client")
+}
@@ -12,6 +12,12 @@ import (
 
 const bucketName = "data"
 
+// conflictsBucketName — бакет с серверными версиями записей, конфликтующими с локальными изменениями.
+const conflictsBucketName = "conflicts"
+
+// metaBucketName — бакет служебных данных клиента (курсор синхронизации и т.п.).
+const metaBucketName = "meta"
+
 // BboltStorage оборачивает BoltDB для хранения данных.
 type BboltStorage struct {
 	db *bolt.DB
@@ -23,10 +29,14 @@ func OpenLocalStorage(path string) (BboltStorage, error) {
 	if err != nil {
 		return BboltStorage{}, err
 	}
-	// Создаём бакет, если он отсутствует.
+	// Создаём бакеты, если они отсутствуют, и обновляем схему базы, созданной предыдущей версией клиента.
 	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists([]byte(bucketName))
-		return err
+		for _, name := range []string{bucketName, conflictsBucketName, metaBucketName, uploadsBucketName, downloadsBucketName} {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return err
+			}
+		}
+		return migrate(tx)
 	})
 	if err != nil {
 		db.Close()
@@ -0,0 +1,86 @@
+package bbolt
+
+import (
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// uploadsBucketName — бакет с ID файловых записей, файлы которых сервер ждёт от клиента.
+const uploadsBucketName = "uploads"
+
+// downloadsBucketName — бакет с ID файловых записей, новые версии файлов которых ещё не скачаны.
+const downloadsBucketName = "downloads"
+
+// AddPendingUploads запоминает файлы, которые нужно загрузить на сервер.
+func (ls BboltStorage) AddPendingUploads(ids []string) error {
+	return ls.addIDs(uploadsBucketName, ids)
+}
+
+// GetPendingUploads возвращает ID записей, файлы которых ещё не загружены на сервер.
+func (ls BboltStorage) GetPendingUploads() ([]string, error) {
+	return ls.getIDs(uploadsBucketName)
+}
+
+// RemovePendingUpload отмечает файл записи загруженным или больше не требующим загрузки.
+func (ls BboltStorage) RemovePendingUpload(id string) error {
+	return ls.removeID(uploadsBucketName, id)
+}
+
+// AddPendingDownloads запоминает файлы, которые нужно скачать с сервера.
+func (ls BboltStorage) AddPendingDownloads(ids []string) error {
+	return ls.addIDs(downloadsBucketName, ids)
+}
+
+// GetPendingDownloads возвращает ID записей, файлы которых ещё не скачаны с сервера.
+func (ls BboltStorage) GetPendingDownloads() ([]string, error) {
+	return ls.getIDs(downloadsBucketName)
+}
+
+// RemovePendingDownload отмечает файл записи скачанным или больше не требующим скачивания.
+func (ls BboltStorage) RemovePendingDownload(id string) error {
+	return ls.removeID(downloadsBucketName, id)
+}
+
+// addIDs добавляет ID в бакет name.
+func (ls BboltStorage) addIDs(name string, ids []string) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return fmt.Errorf("bucket %s not found", name)
+		}
+		for _, id := range ids {
+			if err := bucket.Put([]byte(id), []byte{}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// getIDs возвращает все ID из бакета name.
+func (ls BboltStorage) getIDs(name string) ([]string, error) {
+	var ids []string
+	err := ls.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			ids = append(ids, string(k))
+			return nil
+		})
+	})
+	return ids, err
+}
+
+// removeID удаляет ID из бакета name.
+func (ls BboltStorage) removeID(name, id string) error {
+	return ls.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
@@ -0,0 +1,84 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// FSStorage хранит данные в файловой системе в виде <dir>/<первые 2 символа ключа>/<ключ>.
+type FSStorage struct {
+	dir string
+}
+
+// NewFSStorage создаёт файловое хранилище в директории dir.
+func NewFSStorage(dir string) (*FSStorage, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FSStorage{dir: dir}, nil
+}
+
+// Put атомарно сохраняет данные, заменяя существующие: файл появляется в хранилище только целиком.
+// Запись не пропускается, даже если файл уже есть: его может удалять сборщик мусора.
+func (s *FSStorage) Put(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get открывает файл на чтение.
+func (s *FSStorage) Get(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, repository.ErrNotFound
+	}
+	return f, err
+}
+
+// Delete удаляет файл.
+func (s *FSStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path возвращает путь к файлу, проверяя, что из ключа нельзя построить путь за пределами хранилища.
+func (s *FSStorage) path(key string) (string, error) {
+	if len(key) < 2 || strings.ContainsAny(key, `/\`) || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(s.dir, key[:2], key), nil
+}
@@ -0,0 +1,118 @@
+// Package blobstore хранит чанки файлов, адресуемые по SHA-256 их содержимого.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// ErrHashMismatch возвращается, если содержимое чанка не соответствует его хэшу.
+var ErrHashMismatch = errors.New("blob content does not match its hash")
+
+// Store хранит чанки под ключом, равным SHA-256 их содержимого, в хранилище BlobStorage.
+// Одинаковые чанки хранятся один раз независимо от того, каким файлам они принадлежат.
+type Store struct {
+	storage repository.BlobStorage
+}
+
+// New создаёт хранилище чанков поверх storage.
+func New(storage repository.BlobStorage) *Store {
+	return &Store{storage: storage}
+}
+
+// Put сохраняет чанк, предварительно проверив, что его содержимое соответствует хэшу.
+func (s *Store) Put(hash string, data []byte) error {
+	if !ValidHash(hash) {
+		return fmt.Errorf("invalid blob hash %q", hash)
+	}
+	if !MatchesHash(hash, data) {
+		return ErrHashMismatch
+	}
+	return s.storage.Put(hash, data)
+}
+
+// MatchesHash сообщает, совпадает ли hash с SHA-256 содержимого data в hex.
+func MatchesHash(hash string, data []byte) bool {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) == hash
+}
+
+// Open открывает чанк на чтение. Для отсутствующего чанка возвращается repository.ErrNotFound.
+func (s *Store) Open(hash string) (io.ReadCloser, error) {
+	if !ValidHash(hash) {
+		return nil, fmt.Errorf("invalid blob hash %q", hash)
+	}
+	return s.storage.Get(hash)
+}
+
+// Delete удаляет чанк. Отсутствие чанка ошибкой не считается.
+func (s *Store) Delete(hash string) error {
+	if !ValidHash(hash) {
+		return fmt.Errorf("invalid blob hash %q", hash)
+	}
+	return s.storage.Delete(hash)
+}
+
+// ValidHash проверяет, что строка является SHA-256 в hex.
+func ValidHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// NewReader возвращает поток, последовательно читающий чанки с указанными хэшами.
+// Чанки открываются по мере чтения.
+func (s *Store) NewReader(hashes []string) io.ReadCloser {
+	return &chunkReader{store: s, hashes: hashes}
+}
+
+// chunkReader последовательно читает чанки из хранилища.
+type chunkReader struct {
+	store  *Store
+	hashes []string
+	cur    io.ReadCloser
+}
+
+// Read читает данные текущего чанка, переходя к следующему по его окончании.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.cur == nil {
+			if len(r.hashes) == 0 {
+				return 0, io.EOF
+			}
+			rc, err := r.store.Open(r.hashes[0])
+			if err != nil {
+				return 0, fmt.Errorf("failed to open blob %s: %w", r.hashes[0], err)
+			}
+			r.cur = rc
+			r.hashes = r.hashes[1:]
+		}
+		n, err := r.cur.Read(p)
+		if errors.Is(err, io.EOF) {
+			r.cur.Close()
+			r.cur = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+// Close закрывает текущий чанк.
+func (r *chunkReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
@@ -0,0 +1,89 @@
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+func hashOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStore(t *testing.T) {
+	storage, err := NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+	store := New(storage)
+	data := []byte("chunk data")
+	hash := hashOf(data)
+
+	// Чанк с неверным хэшем не сохраняется.
+	assert.ErrorIs(t, store.Put(hashOf([]byte("other")), data), ErrHashMismatch)
+
+	// Повторное сохранение одинакового чанка идемпотентно.
+	require.NoError(t, store.Put(hash, data))
+	require.NoError(t, store.Put(hash, data))
+	r, err := store.Open(hash)
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	r.Close()
+	assert.Equal(t, data, got)
+
+	require.NoError(t, store.Delete(hash))
+	_, err = store.Open(hash)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	require.NoError(t, store.Delete(hash), "Удаление отсутствующего чанка не ошибка")
+
+	// Хэш проверяется, чтобы из него нельзя было построить произвольный путь.
+	assert.Error(t, store.Put("../../etc/passwd", data))
+	_, err = store.Open("../x")
+	assert.Error(t, err)
+	assert.Error(t, storage.Put("../x", data))
+}
+
+func TestFSStorage_PutReplaces(t *testing.T) {
+	storage, err := NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+
+	// Существующие данные перезаписываются, как требует интерфейс BlobStorage.
+	require.NoError(t, storage.Put("key1", []byte("old")))
+	require.NoError(t, storage.Put("key1", []byte("new")))
+	r, err := storage.Get("key1")
+	require.NoError(t, err)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	r.Close()
+	assert.Equal(t, "new", string(got))
+}
+
+func TestStoreNewReader(t *testing.T) {
+	storage, err := NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+	store := New(storage)
+	parts := [][]byte{[]byte("first "), []byte("second "), []byte("first ")}
+	var hashes []string
+	for _, part := range parts {
+		hash := hashOf(part)
+		require.NoError(t, store.Put(hash, part))
+		hashes = append(hashes, hash)
+	}
+
+	// Чанки читаются по порядку, один и тот же чанк может встречаться несколько раз.
+	r := store.NewReader(hashes)
+	got, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "first second first ", string(got))
+
+	// Отсутствующий чанк — ошибка чтения.
+	_, err = io.ReadAll(store.NewReader([]string{hashOf([]byte("missing"))}))
+	assert.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DeleteAccount безвозвратно удаляет учётную запись и все данные пользователя на сервере,
+// после чего удаляет локальную сессию. Локальная база и файлы клиента не удаляются.
+func (c *Client) DeleteAccount(ctx context.Context, password string) error {
+	if password == "" {
+		return errors.New("password must be provided")
+	}
+	resp, err := c.doAuthorized(ctx, http.MethodDelete, "/account", map[string]string{"password": password})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return ErrInvalidPassword
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("account deletion failed: %s", string(body))
+	}
+	if err := c.Session.Clear(); err != nil {
+		return fmt.Errorf("account deleted, but failed to remove local session: %w", err)
+	}
+	return nil
+}
+
+// ExportAccount скачивает архив tar.gz со всеми данными пользователя на сервере и сохраняет его по пути dstPath.
+// Содержимое записей и файлов в архиве зашифровано так же, как на сервере. Возвращает размер архива.
+func (c *Client) ExportAccount(ctx context.Context, dstPath string) (int64, error) {
+	resp, err := c.doAuthorized(ctx, http.MethodGet, "/account/export", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("account export failed: %s", string(body))
+	}
+
+	// Пишем во временный файл, чтобы оборванная передача не оставила неполный архив.
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file %s: %w", tmpPath, err)
+	}
+	defer os.Remove(tmpPath)
+	defer dst.Close()
+
+	n, err := io.Copy(dst, resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download archive: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return 0, fmt.Errorf("failed to rename file: %w", err)
+	}
+	return n, nil
+}
@@ -1,28 +1,48 @@
 package client
 
 import (
+	"crypto/tls"
+	"io"
 	"log"
+	"net/http"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
 	"github.com/andranikuz/gophkeeper/pkg/entity"
 )
 
+// requestTimeout — таймаут HTTP-запросов к серверу.
+const requestTimeout = 10 * time.Second
+
 // Client представляет клиента для работы с сервером.
 type Client struct {
 	ServerURL  string
 	Session    SessionService
 	LocalDB    LocalStorage
+	Encryptor  Encryptor
 	grpcClient pb.FileSyncServiceClient
 	grpcConn   *grpc.ClientConn
+	// transport — HTTP-транспорт с TLS-настройками клиента; nil означает транспорт по умолчанию.
+	transport http.RoundTripper
 }
 
-// NewClient создаёт новый экземпляр Client.
-func NewClient(serverURL string, serverGrpcURL string, session SessionService, localDB LocalStorage) *Client {
-	// Устанавливаем gRPC-соединение. Используем insecure для примера.
-	conn, err := grpc.NewClient("127.0.0.1:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
+// NewClient создаёт новый экземпляр Client. Если tlsConfig задан, HTTP- и gRPC-соединения
+// устанавливаются по TLS с этими настройками, иначе gRPC работает без шифрования.
+func NewClient(serverURL string, serverGrpcURL string, session SessionService, localDB LocalStorage, tlsConfig *tls.Config) *Client {
+	transportCredentials := insecure.NewCredentials()
+	var transport http.RoundTripper
+	if tlsConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsConfig)
+		httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+		httpTransport.TLSClientConfig = tlsConfig
+		transport = httpTransport
+	}
+	// Устанавливаем gRPC-соединение.
+	conn, err := grpc.NewClient(serverGrpcURL, grpc.WithTransportCredentials(transportCredentials))
 	if err != nil {
 		log.Fatalf("Failed to dial gRPC server at %s: %v", serverGrpcURL, err)
 	}
@@ -34,25 +54,61 @@ func NewClient(serverURL string, serverGrpcURL string, session SessionService, l
 		LocalDB:    localDB,
 		grpcClient: grpcClient,
 		grpcConn:   conn,
+		transport:  transport,
 	}
 }
 
+// httpClient возвращает HTTP-клиент для запросов к серверу.
+func (c *Client) httpClient() *http.Client {
+	client := &http.Client{Timeout: requestTimeout}
+	if c.transport != nil {
+		client.Transport = c.transport
+	}
+	return client
+}
+
 type LocalStorage interface {
 	SaveItems(items []entity.DataItem) error
 	SaveItem(item *entity.DataItem) error
 	GetAllItems() ([]entity.DataItem, error)
 	DeleteItem(id string) error
 	GetByID(id string) (*entity.DataItem, error)
+	SaveConflicts(items []entity.DataItem) error
+	GetConflicts() ([]entity.DataItem, error)
+	GetConflictByID(id string) (*entity.DataItem, error)
+	DeleteConflict(id string) error
+	GetSyncCursor() (int64, error)
+	SaveSyncCursor(cursor int64) error
+	GetPlaintextItemIDs() ([]string, error)
+	ClearPlaintextItem(id string) error
+	AddPendingUploads(ids []string) error
+	GetPendingUploads() ([]string, error)
+	RemovePendingUpload(id string) error
+	AddPendingDownloads(ids []string) error
+	GetPendingDownloads() ([]string, error)
+	RemovePendingDownload(id string) error
 }
 
-// Token хранит JWT-токен и идентификатор пользователя.
+// Token хранит JWT-токен, токен обновления и идентификатор пользователя.
 type Token struct {
-	Token  string `json:"token"`
-	UserID string `json:"user_id"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	UserID       string `json:"user_id"`
+}
+
+// Encryptor шифрует и расшифровывает содержимое записей и файлов перед сохранением в локальное хранилище.
+type Encryptor interface {
+	EncryptString(plaintext string) (string, error)
+	DecryptString(ciphertext string) (string, error)
+	EncryptStream(dst io.Writer, src io.Reader) error
+	EncryptStreamConvergent(dst io.Writer, src io.ReadSeeker) error
+	DecryptStream(dst io.Writer, src io.Reader) error
 }
 
 type SessionService interface {
 	Save(token Token) error
 	GetSessionToken() string
+	GetRefreshToken() string
 	GetUserID() string
+	Clear() error
 }
@@ -1,22 +1,35 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
+	"github.com/andranikuz/gophkeeper/internal/tlsutil"
+	"github.com/andranikuz/gophkeeper/internal/vault"
 	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/policy"
 	"github.com/andranikuz/gophkeeper/pkg/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -31,6 +44,14 @@ type fakeLocalStorage struct {
 	getAllItemsFunc func() ([]entity.DataItem, error)
 	getByIDFunc     func(id string) (*entity.DataItem, error)
 	deleteItemFunc  func(id string) error
+
+	conflicts map[string]entity.DataItem
+	cursor    int64
+	plaintext []string
+	// Загрузки и скачивания завершаются в отдельных горутинах.
+	mu        sync.Mutex
+	pending   []string
+	downloads []string
 }
 
 func (f *fakeLocalStorage) SaveItem(item *entity.DataItem) error {
@@ -64,10 +85,103 @@ func (f *fakeLocalStorage) DeleteItem(id string) error {
 	return nil
 }
 
+func (f *fakeLocalStorage) SaveConflicts(items []entity.DataItem) error {
+	if f.conflicts == nil {
+		f.conflicts = make(map[string]entity.DataItem)
+	}
+	for _, item := range items {
+		f.conflicts[item.ID] = item
+	}
+	return nil
+}
+func (f *fakeLocalStorage) GetConflicts() ([]entity.DataItem, error) {
+	var items []entity.DataItem
+	for _, item := range f.conflicts {
+		items = append(items, item)
+	}
+	return items, nil
+}
+func (f *fakeLocalStorage) GetConflictByID(id string) (*entity.DataItem, error) {
+	item, ok := f.conflicts[id]
+	if !ok {
+		return nil, fmt.Errorf("conflict for item %s not found", id)
+	}
+	return &item, nil
+}
+func (f *fakeLocalStorage) DeleteConflict(id string) error {
+	delete(f.conflicts, id)
+	return nil
+}
+
+func (f *fakeLocalStorage) GetSyncCursor() (int64, error) {
+	return f.cursor, nil
+}
+func (f *fakeLocalStorage) SaveSyncCursor(cursor int64) error {
+	f.cursor = cursor
+	return nil
+}
+func (f *fakeLocalStorage) GetPlaintextItemIDs() ([]string, error) {
+	return f.plaintext, nil
+}
+func (f *fakeLocalStorage) ClearPlaintextItem(id string) error {
+	for i, plaintextID := range f.plaintext {
+		if plaintextID == id {
+			f.plaintext = append(f.plaintext[:i], f.plaintext[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+func (f *fakeLocalStorage) AddPendingUploads(ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = addPendingIDs(f.pending, ids)
+	return nil
+}
+func (f *fakeLocalStorage) GetPendingUploads() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.pending), nil
+}
+func (f *fakeLocalStorage) RemovePendingUpload(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pending = slices.DeleteFunc(f.pending, func(pendingID string) bool { return pendingID == id })
+	return nil
+}
+func (f *fakeLocalStorage) AddPendingDownloads(ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.downloads = addPendingIDs(f.downloads, ids)
+	return nil
+}
+func (f *fakeLocalStorage) GetPendingDownloads() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.downloads), nil
+}
+func (f *fakeLocalStorage) RemovePendingDownload(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.downloads = slices.DeleteFunc(f.downloads, func(pendingID string) bool { return pendingID == id })
+	return nil
+}
+
+// addPendingIDs добавляет в список ID, которых в нём ещё нет.
+func addPendingIDs(list, ids []string) []string {
+	for _, id := range ids {
+		if !slices.Contains(list, id) {
+			list = append(list, id)
+		}
+	}
+	return list
+}
+
 // fakeSession реализует интерфейс SessionService.
 type fakeSession struct {
-	token  string
-	userID string
+	token        string
+	refreshToken string
+	userID       string
 	// Функция, которую можно подменить для перехвата вызова Save.
 	saveFunc func(token Token) error
 }
@@ -78,17 +192,25 @@ func (fs *fakeSession) Save(token Token) error {
 	}
 	// По умолчанию просто сохраняем данные.
 	fs.token = token.Token
+	fs.refreshToken = token.RefreshToken
 	fs.userID = token.UserID
 	return nil
 }
 func (fs *fakeSession) GetSessionToken() string { return fs.token }
+func (fs *fakeSession) GetRefreshToken() string { return fs.refreshToken }
 func (fs *fakeSession) GetUserID() string       { return fs.userID }
+func (fs *fakeSession) Clear() error {
+	fs.token, fs.refreshToken, fs.userID = "", "", ""
+	return nil
+}
 
 // fakeGrpcClient реализует интерфейс pb.FileSyncServiceClient.
 type fakeGrpcClient struct {
 	syncRecordsFunc  func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error)
 	uploadFileFunc   func(ctx context.Context, opts ...grpc.CallOption) (pb.FileSyncService_UploadFileClient, error)
 	downloadFileFunc func(ctx context.Context, in *pb.FileDownloadRequest, opts ...grpc.CallOption) (pb.FileSyncService_DownloadFileClient, error)
+	// known — хэши чанков, которые уже есть на сервере.
+	known map[string]bool
 }
 
 func (f *fakeGrpcClient) SyncRecords(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
@@ -101,13 +223,24 @@ func (f *fakeGrpcClient) DownloadFile(ctx context.Context, in *pb.FileDownloadRe
 	return f.downloadFileFunc(ctx, in, opts...)
 }
 
+func (f *fakeGrpcClient) GetMissingChunks(ctx context.Context, in *pb.MissingChunksRequest, opts ...grpc.CallOption) (*pb.MissingChunksResponse, error) {
+	resp := &pb.MissingChunksResponse{}
+	for _, hash := range in.Hashes {
+		if !f.known[hash] {
+			resp.Missing = append(resp.Missing, hash)
+		}
+	}
+	return resp, nil
+}
+
 // fakeUploadStream – фиктивный стрим для uploadFileGRPC.
 type fakeUploadStream struct {
 	sentChunks []*pb.FileChunk
 }
 
 func (s *fakeUploadStream) Send(chunk *pb.FileChunk) error {
-	s.sentChunks = append(s.sentChunks, chunk)
+	// Клиент переиспользует буфер, поэтому сохраняем копию, как при сериализации.
+	s.sentChunks = append(s.sentChunks, proto.Clone(chunk).(*pb.FileChunk))
 	return nil
 }
 func (s *fakeUploadStream) CloseAndRecv() (*pb.FileUploadResponse, error) {
@@ -123,11 +256,15 @@ func (s *fakeUploadStream) SendMsg(m interface{}) error  { return nil }
 // fakeDownloadStream – фиктивный стрим для downloadFileGRPC.
 type fakeDownloadStream struct {
 	chunks []*pb.FileChunk
+	err    error // Ошибка, которую вернёт Recv после чанков (по умолчанию io.EOF).
 	index  int
 }
 
 func (s *fakeDownloadStream) Recv() (*pb.FileChunk, error) {
 	if s.index >= len(s.chunks) {
+		if s.err != nil {
+			return nil, s.err
+		}
 		return nil, io.EOF
 	}
 	chunk := s.chunks[s.index]
@@ -141,6 +278,22 @@ func (s *fakeDownloadStream) Context() context.Context     { return context.Back
 func (s *fakeDownloadStream) RecvMsg(m interface{}) error  { return nil }
 func (s *fakeDownloadStream) SendMsg(m interface{}) error  { return nil }
 
+// newTestCipher создаёт шифратор для тестов.
+func newTestCipher(t *testing.T) *vault.Cipher {
+	t.Helper()
+	c, err := vault.NewCipher("master", "user123")
+	require.NoError(t, err)
+	return c
+}
+
+// decrypt расшифровывает строку тестовым шифратором.
+func decrypt(t *testing.T, c *vault.Cipher, ciphertext string) string {
+	t.Helper()
+	plaintext, err := c.DecryptString(ciphertext)
+	require.NoError(t, err)
+	return plaintext
+}
+
 // ===== Тесты для CardDTO.Validate =====
 
 func TestCardDTOValidate_Valid(t *testing.T) {
@@ -237,9 +390,11 @@ func TestSaveCard_Success(t *testing.T) {
 		},
 	}
 	fakeSess := &fakeSession{userID: "user123"}
+	cipher := newTestCipher(t)
 	client := &Client{
-		LocalDB: fakeStore,
-		Session: fakeSess,
+		LocalDB:   fakeStore,
+		Session:   fakeSess,
+		Encryptor: cipher,
 	}
 	// Используем формат MM/YYYY
 	future := time.Now().AddDate(1, 0, 0)
@@ -257,7 +412,7 @@ func TestSaveCard_Success(t *testing.T) {
 
 	// Проверяем, что сохранённое содержимое корректно сериализовано
 	var cardPayload CardDTO
-	err = json.Unmarshal([]byte(savedItem.Content), &cardPayload)
+	err = json.Unmarshal([]byte(decrypt(t, cipher, savedItem.Content)), &cardPayload)
 	require.NoError(t, err)
 	assert.Equal(t, card.CardNumber, cardPayload.CardNumber)
 	assert.Equal(t, card.ExpirationDate, cardPayload.ExpirationDate)
@@ -293,9 +448,11 @@ func TestSaveCredential_Success(t *testing.T) {
 		},
 	}
 	fakeSess := &fakeSession{userID: "user123"}
+	cipher := newTestCipher(t)
 	client := &Client{
-		LocalDB: fakeStore,
-		Session: fakeSess,
+		LocalDB:   fakeStore,
+		Session:   fakeSess,
+		Encryptor: cipher,
 	}
 	cred := CredentialDTO{
 		Login:    "user@example.com",
@@ -307,7 +464,7 @@ func TestSaveCredential_Success(t *testing.T) {
 	assert.Equal(t, entity.DataTypeCredential, savedItem.Type)
 
 	var credPayload CredentialDTO
-	err = json.Unmarshal([]byte(savedItem.Content), &credPayload)
+	err = json.Unmarshal([]byte(decrypt(t, cipher, savedItem.Content)), &credPayload)
 	require.NoError(t, err)
 	assert.Equal(t, cred.Login, credPayload.Login)
 	assert.Equal(t, cred.Password, credPayload.Password)
@@ -324,9 +481,11 @@ func TestSaveText_Success(t *testing.T) {
 		},
 	}
 	fakeSess := &fakeSession{userID: "user123"}
+	cipher := newTestCipher(t)
 	client := &Client{
-		LocalDB: fakeStore,
-		Session: fakeSess,
+		LocalDB:   fakeStore,
+		Session:   fakeSess,
+		Encryptor: cipher,
 	}
 	textDTO := TextDTO{
 		Text: "sample text",
@@ -335,7 +494,25 @@ func TestSaveText_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, savedItem)
 	assert.Equal(t, entity.DataTypeText, savedItem.Type)
-	assert.Equal(t, textDTO.Text, savedItem.Content)
+	assert.NotEqual(t, textDTO.Text, savedItem.Content, "Содержимое должно сохраняться в зашифрованном виде")
+	assert.Equal(t, textDTO.Text, decrypt(t, cipher, savedItem.Content))
+}
+
+func TestSaveText_Locked(t *testing.T) {
+	saveCalled := false
+	fakeStore := &fakeLocalStorage{
+		saveItemFunc: func(item *entity.DataItem) error {
+			saveCalled = true
+			return nil
+		},
+	}
+	client := &Client{
+		LocalDB: fakeStore,
+		Session: &fakeSession{userID: "user123"},
+	}
+	err := client.SaveText(context.Background(), TextDTO{Text: "sample text"})
+	assert.ErrorIs(t, err, ErrLocked)
+	assert.False(t, saveCalled, "Без мастер-пароля запись не должна сохраняться")
 }
 
 // ===== Тест для SaveFile =====
@@ -372,9 +549,11 @@ func TestSaveFile_Success(t *testing.T) {
 		},
 	}
 	fakeSess := &fakeSession{userID: "user123"}
+	cipher := newTestCipher(t)
 	client := &Client{
-		LocalDB: fakeStore,
-		Session: fakeSess,
+		LocalDB:   fakeStore,
+		Session:   fakeSess,
+		Encryptor: cipher,
 	}
 	dto := FileDTO{FilePath: srcFile.Name()}
 	err = client.SaveFile(context.Background(), dto)
@@ -385,9 +564,190 @@ func TestSaveFile_Success(t *testing.T) {
 	destPath := utils.GetLocalFilePath(savedItem)
 	copiedContent, err := os.ReadFile(destPath)
 	require.NoError(t, err)
-	assert.Equal(t, content, copiedContent)
-	// Проверяем, что в DataItem в поле Content записано базовое имя исходного файла.
-	assert.Equal(t, filepath.Base(srcFile.Name()), savedItem.Content)
+	assert.NotContains(t, string(copiedContent), string(content), "Локальная копия файла должна быть зашифрована")
+	var decrypted bytes.Buffer
+	require.NoError(t, cipher.DecryptStream(&decrypted, bytes.NewReader(copiedContent)))
+	assert.Equal(t, content, decrypted.Bytes())
+	// Проверяем, что в DataItem в поле Content записано зашифрованное базовое имя исходного файла.
+	assert.Equal(t, filepath.Base(srcFile.Name()), decrypt(t, cipher, savedItem.Content))
+}
+
+// ===== Тесты для GetFile и downloadFileGRPC =====
+
+// chdirTemp переходит во временную рабочую директорию на время теста.
+func chdirTemp(t *testing.T) {
+	t.Helper()
+	oldWd, err := os.Getwd()
+	require.NoError(t, err)
+	tempWd, err := os.MkdirTemp("", "tempWd")
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(tempWd))
+	t.Cleanup(func() {
+		os.Chdir(oldWd)
+		os.RemoveAll(tempWd)
+	})
+}
+
+func TestGetFile_Success(t *testing.T) {
+	chdirTemp(t)
+	cipher := newTestCipher(t)
+
+	// Сохраняем файл через SaveFile, затем расшифровываем его через GetFile.
+	content := []byte("file content for testing")
+	srcPath := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(srcPath, content, 0644))
+
+	var savedItem *entity.DataItem
+	fakeStore := &fakeLocalStorage{
+		saveItemFunc: func(item *entity.DataItem) error {
+			savedItem = item
+			return nil
+		},
+		getByIDFunc: func(id string) (*entity.DataItem, error) {
+			item := *savedItem
+			return &item, nil
+		},
+	}
+	client := &Client{
+		LocalDB:   fakeStore,
+		Session:   &fakeSession{userID: "user123"},
+		Encryptor: cipher,
+	}
+	require.NoError(t, client.SaveFile(context.Background(), FileDTO{FilePath: srcPath}))
+
+	path, err := client.GetFile(context.Background(), savedItem.ID, "")
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", path)
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, content, restored)
+}
+
+func TestDownloadFileGRPC_IntegrityCheck(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	cipher := newTestCipher(t)
+
+	var encrypted bytes.Buffer
+	require.NoError(t, cipher.EncryptStream(&encrypted, bytes.NewReader([]byte("remote file"))))
+	valid := encrypted.Bytes()
+	corrupted := bytes.Clone(valid)
+	corrupted[len(corrupted)-1] ^= 0xff
+
+	var chunks []*pb.FileChunk
+	fakeGrpc := &fakeGrpcClient{
+		downloadFileFunc: func(ctx context.Context, req *pb.FileDownloadRequest, opts ...grpc.CallOption) (pb.FileSyncService_DownloadFileClient, error) {
+			return &fakeDownloadStream{chunks: chunks}, nil
+		},
+	}
+	client := &Client{
+		Session:    &fakeSession{userID: "user123"},
+		Encryptor:  cipher,
+		grpcClient: fakeGrpc,
+	}
+	item := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary}
+
+	// Файл, не расшифровывающийся ключом пользователя, не сохраняется.
+	chunks = []*pb.FileChunk{{Id: item.ID, ChunkData: corrupted, Sha256: checksum(corrupted), Size: int64(len(corrupted))}}
+	_, err := client.downloadFileGRPC(context.Background(), item)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "integrity check failed")
+	_, err = os.Stat(utils.GetLocalFilePath(&item))
+	assert.True(t, os.IsNotExist(err), "Повреждённый файл не должен сохраняться")
+
+	// Файл, искажённый при передаче, не сохраняется.
+	chunks = []*pb.FileChunk{{Id: item.ID, ChunkData: corrupted, Sha256: checksum(valid), Size: int64(len(valid))}}
+	_, err = client.downloadFileGRPC(context.Background(), item)
+	require.ErrorIs(t, err, errTransferCorrupted)
+	_, err = os.Stat(utils.GetLocalFilePath(&item))
+	assert.True(t, os.IsNotExist(err), "Повреждённый файл не должен сохраняться")
+
+	// Корректный файл сохраняется.
+	chunks = []*pb.FileChunk{{Id: item.ID, ChunkData: valid[:10], Sha256: checksum(valid), Size: int64(len(valid))}, {Id: item.ID, ChunkData: valid[10:]}}
+	path, err := client.downloadFileGRPC(context.Background(), item)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, valid, data)
+}
+
+func TestDownloadFileGRPC_Resume(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	cipher := newTestCipher(t)
+
+	var encrypted bytes.Buffer
+	require.NoError(t, cipher.EncryptStream(&encrypted, bytes.NewReader([]byte("remote file"))))
+	data := encrypted.Bytes()
+	header := &pb.FileChunk{Sha256: checksum(data), Size: int64(len(data))}
+
+	var offsets []int64
+	fakeGrpc := &fakeGrpcClient{
+		downloadFileFunc: func(ctx context.Context, req *pb.FileDownloadRequest, opts ...grpc.CallOption) (pb.FileSyncService_DownloadFileClient, error) {
+			offsets = append(offsets, req.Offset)
+			if len(offsets) == 1 {
+				// Первая попытка обрывается после 10 байт.
+				return &fakeDownloadStream{
+					chunks: []*pb.FileChunk{{Id: req.Id, ChunkData: data[:10], Offset: req.Offset, Sha256: header.Sha256, Size: header.Size}},
+					err:    errors.New("connection reset"),
+				}, nil
+			}
+			return &fakeDownloadStream{
+				chunks: []*pb.FileChunk{{Id: req.Id, ChunkData: data[req.Offset:], Offset: req.Offset, Sha256: header.Sha256, Size: header.Size}},
+			}, nil
+		},
+	}
+	client := &Client{
+		Session:    &fakeSession{userID: "user123"},
+		Encryptor:  cipher,
+		grpcClient: fakeGrpc,
+	}
+	item := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary}
+
+	path, err := client.downloadFileGRPC(context.Background(), item)
+	require.NoError(t, err)
+	// Повторная попытка продолжает скачивание с места обрыва.
+	assert.Equal(t, []int64{0, 10}, offsets)
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, restored)
+}
+
+func TestUploadFileGRPC_MissingChunks(t *testing.T) {
+	// Файл из трёх чанков: два одинаковых полных и неполный последний.
+	content := append(bytes.Repeat([]byte("a"), 2*uploadChunkSize), []byte("tail")...)
+	filePath := filepath.Join(t.TempDir(), "file1")
+	require.NoError(t, os.WriteFile(filePath, content, 0600))
+	full := checksum(content[:uploadChunkSize])
+	tail := checksum([]byte("tail"))
+
+	stream := &fakeUploadStream{}
+	fakeGrpc := &fakeGrpcClient{
+		// Последний чанк уже загружен ранее.
+		known: map[string]bool{tail: true},
+		uploadFileFunc: func(ctx context.Context, opts ...grpc.CallOption) (pb.FileSyncService_UploadFileClient, error) {
+			return stream, nil
+		},
+	}
+	client := &Client{grpcClient: fakeGrpc}
+
+	require.NoError(t, client.uploadFileGRPC(context.Background(), "file1", filePath))
+	// Первое сообщение описывает файл целиком.
+	require.Len(t, stream.sentChunks, 2)
+	header := stream.sentChunks[0]
+	assert.Equal(t, checksum(content), header.Sha256)
+	assert.Equal(t, int64(len(content)), header.Size)
+	assert.Equal(t, []string{full, full, tail}, header.Chunks)
+	assert.Empty(t, header.ChunkData)
+	// Передаётся только недостающий чанк, повторы внутри файла — один раз.
+	assert.Equal(t, full, stream.sentChunks[1].ChunkHash)
+	assert.Equal(t, content[:uploadChunkSize], stream.sentChunks[1].ChunkData)
+}
+
+// checksum возвращает SHA-256 данных в hex.
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 // ===== Тест для SyncGRPC =====
@@ -445,6 +805,837 @@ func TestSyncGRPC_Success(t *testing.T) {
 	assert.Equal(t, "merged text", savedItems[0].Content)
 }
 
+func TestSyncGRPC_AppliesTombstones(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	fileItem := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary}
+	filePath := utils.GetLocalFilePath(&fileItem)
+	require.NoError(t, os.WriteFile(filePath, []byte("data"), 0644))
+
+	var deletedIDs []string
+	var savedItems []entity.DataItem
+	fakeStore := &fakeLocalStorage{
+		deleteItemFunc: func(id string) error {
+			deletedIDs = append(deletedIDs, id)
+			return nil
+		},
+		saveItemsFunc: func(items []entity.DataItem) error {
+			savedItems = items
+			return nil
+		},
+	}
+	now := time.Now().Format(time.RFC3339)
+	fakeGrpc := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, req *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			return &pb.SyncRecordsResponse{
+				MergedRecords: []*pb.DataItem{
+					{Id: "file1", Type: int32(entity.DataTypeBinary), UpdatedAt: now, Deleted: true, DeletedAt: now},
+					{Id: "text1", Type: int32(entity.DataTypeText), Content: "text", UpdatedAt: now},
+				},
+			}, nil
+		},
+	}
+	client := &Client{
+		LocalDB:    fakeStore,
+		Session:    &fakeSession{userID: "user123", token: "testtoken"},
+		grpcClient: fakeGrpc,
+	}
+
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	// Надгробие удаляет локальную запись и файл, живые записи сохраняются.
+	assert.Equal(t, []string{"file1"}, deletedIDs)
+	require.Len(t, savedItems, 1)
+	assert.Equal(t, "text1", savedItems[0].ID)
+	_, err := os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err), "Файл удалённой записи должен быть удалён")
+}
+
+func TestSyncGRPC_KeepsConflictingChanges(t *testing.T) {
+	var sentItems []*pb.DataItem
+	var savedItems []entity.DataItem
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			return []entity.DataItem{
+				{ID: "item1", Type: entity.DataTypeText, Content: "local edit", BaseRevision: 1},
+			}, nil
+		},
+		saveItemsFunc: func(items []entity.DataItem) error {
+			savedItems = items
+			return nil
+		},
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	serverVersion := &pb.DataItem{Id: "item1", Type: int32(entity.DataTypeText), Content: "remote edit", UpdatedAt: now, Revision: 2}
+	fakeGrpc := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, req *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			sentItems = req.Items
+			return &pb.SyncRecordsResponse{
+				MergedRecords: []*pb.DataItem{
+					serverVersion,
+					{Id: "item2", Type: int32(entity.DataTypeText), Content: "other", UpdatedAt: now, Revision: 3},
+				},
+				Conflicts: []*pb.DataItem{serverVersion},
+			}, nil
+		},
+	}
+	client := &Client{
+		LocalDB:    fakeStore,
+		Session:    &fakeSession{userID: "user123", token: "testtoken"},
+		grpcClient: fakeGrpc,
+	}
+
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	require.Len(t, sentItems, 1)
+	assert.Equal(t, int64(1), sentItems[0].BaseRevision, "Клиент должен передавать базовую ревизию изменения")
+	// Конфликтующая запись не перезаписывается серверной версией.
+	require.Len(t, savedItems, 1)
+	assert.Equal(t, "item2", savedItems[0].ID)
+	assert.Equal(t, int64(3), savedItems[0].Revision)
+	// Серверная версия сохраняется для последующего разрешения конфликта.
+	remote, err := fakeStore.GetConflictByID("item1")
+	require.NoError(t, err)
+	assert.Equal(t, "remote edit", remote.Content)
+}
+
+func TestSyncGRPC_DeltaCursor(t *testing.T) {
+	var req *pb.SyncRecordsRequest
+	var savedItems []entity.DataItem
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			return []entity.DataItem{
+				{ID: "synced", Type: entity.DataTypeText, Content: "a", Revision: 5},
+				{ID: "changed", Type: entity.DataTypeText, Content: "b", BaseRevision: 5},
+			}, nil
+		},
+		saveItemsFunc: func(items []entity.DataItem) error {
+			savedItems = items
+			return nil
+		},
+		cursor: 7,
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	fakeGrpc := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			req = in
+			return &pb.SyncRecordsResponse{
+				MergedRecords: []*pb.DataItem{
+					{Id: "changed", Type: int32(entity.DataTypeText), Content: "b", UpdatedAt: now, Revision: 8},
+				},
+				Cursor: 8,
+			}, nil
+		},
+	}
+	client := &Client{
+		LocalDB:    fakeStore,
+		Session:    &fakeSession{userID: "user123", token: "testtoken"},
+		grpcClient: fakeGrpc,
+	}
+
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	// Отправляются только локальные изменения и курсор последней синхронизации.
+	assert.Equal(t, int64(7), req.Cursor)
+	require.Len(t, req.Items, 1)
+	assert.Equal(t, "changed", req.Items[0].Id)
+	// Изменения с сервера применены, курсор обновлён.
+	require.Len(t, savedItems, 1)
+	assert.Equal(t, int64(8), savedItems[0].Revision)
+	assert.Equal(t, int64(8), fakeStore.cursor)
+}
+
+func TestSyncGRPC_RetriesFailedUploads(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	fileItem := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary, Content: "name"}
+	require.NoError(t, os.WriteFile(utils.GetLocalFilePath(&fileItem), []byte("data"), 0600))
+
+	localItems := []entity.DataItem{fileItem}
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) { return localItems, nil },
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	synced := &pb.DataItem{Id: "file1", Type: int32(entity.DataTypeBinary), Content: "name", UpdatedAt: now, Revision: 1}
+	resp := &pb.SyncRecordsResponse{MergedRecords: []*pb.DataItem{synced}, UploadList: []*pb.DataItem{synced}, Cursor: 1}
+	serverDown := true
+	uploads := 0
+	fakeGrpc := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			return resp, nil
+		},
+		uploadFileFunc: func(ctx context.Context, opts ...grpc.CallOption) (pb.FileSyncService_UploadFileClient, error) {
+			if serverDown {
+				return nil, status.Error(codes.Unavailable, "connection refused")
+			}
+			uploads++
+			return &fakeUploadStream{}, nil
+		},
+	}
+	client := &Client{
+		LocalDB:    fakeStore,
+		Session:    &fakeSession{userID: "user123", token: "testtoken"},
+		grpcClient: fakeGrpc,
+	}
+
+	// Ошибка загрузки возвращается, а файл остаётся в списке ожидания.
+	err := client.SyncGRPC(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file1")
+	assert.Equal(t, []string{"file1"}, fakeStore.pending)
+
+	// Запись уже принята сервером, и при следующей синхронизации он не просит загрузить файл:
+	// клиент загружает его сам по списку ожидания.
+	localItems = []entity.DataItem{{ID: "file1", Type: entity.DataTypeBinary, Content: "name", Revision: 1}}
+	resp = &pb.SyncRecordsResponse{Cursor: 1}
+	serverDown = false
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	assert.Equal(t, 1, uploads)
+	assert.Empty(t, fakeStore.pending)
+
+	// Загруженный файл больше не загружается.
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	assert.Equal(t, 1, uploads)
+}
+
+func TestSyncGRPC_RetriesFailedDownloads(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	cipher := newTestCipher(t)
+	encrypt := func(content string) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, cipher.EncryptStream(&buf, strings.NewReader(content)))
+		return buf.Bytes()
+	}
+	name, err := cipher.EncryptString("name")
+	require.NoError(t, err)
+	// Локально лежит старая версия файла.
+	fileItem := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary, Content: name, Revision: 1}
+	filePath := utils.GetLocalFilePath(&fileItem)
+	require.NoError(t, os.WriteFile(filePath, encrypt("old version"), 0600))
+
+	localItems := []entity.DataItem{fileItem}
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) { return localItems, nil },
+		saveItemsFunc: func(items []entity.DataItem) error {
+			for _, item := range items {
+				if item.ID == fileItem.ID {
+					localItems = []entity.DataItem{item}
+				}
+			}
+			return nil
+		},
+	}
+	now := time.Now().Format(time.RFC3339Nano)
+	updated := &pb.DataItem{Id: "file1", Type: int32(entity.DataTypeBinary), Content: name, UpdatedAt: now, Revision: 2}
+	resp := &pb.SyncRecordsResponse{MergedRecords: []*pb.DataItem{updated}, DownloadList: []*pb.DataItem{updated}, Cursor: 2}
+	remote := encrypt("new version")
+	served := bytes.Clone(remote)
+	served[len(served)-1] ^= 0xff
+	downloads := 0
+	fakeGrpc := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			return resp, nil
+		},
+		downloadFileFunc: func(ctx context.Context, req *pb.FileDownloadRequest, opts ...grpc.CallOption) (pb.FileSyncService_DownloadFileClient, error) {
+			downloads++
+			return &fakeDownloadStream{chunks: []*pb.FileChunk{{Id: req.Id, ChunkData: served, Sha256: checksum(served), Size: int64(len(served))}}}, nil
+		},
+	}
+	client := &Client{
+		LocalDB:    fakeStore,
+		Session:    &fakeSession{userID: "user123", token: "testtoken"},
+		Encryptor:  cipher,
+		grpcClient: fakeGrpc,
+	}
+
+	// Файл не прошёл проверку целостности: синхронизация завершается ошибкой, файл остаётся в списке ожидания.
+	err = client.SyncGRPC(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "file1")
+	assert.Equal(t, []string{"file1"}, fakeStore.downloads)
+	assert.Equal(t, int64(2), fakeStore.cursor)
+
+	// Сервер больше не присылает запись, но клиент скачивает файл по списку ожидания.
+	resp = &pb.SyncRecordsResponse{Cursor: 2}
+	served = remote
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	assert.Equal(t, 2, downloads)
+	assert.Empty(t, fakeStore.downloads)
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, remote, data)
+
+	// Скачанный файл больше не скачивается.
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	assert.Equal(t, 2, downloads)
+}
+
+// fakeAuthSyncRecords возвращает SyncRecords, принимающий только токен "Bearer "+token.
+func fakeAuthSyncRecords(token string, calls *[]string) func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+	return func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		auth := md.Get("authorization")
+		*calls = append(*calls, auth...)
+		if len(auth) != 1 || auth[0] != "Bearer "+token {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return &pb.SyncRecordsResponse{}, nil
+	}
+}
+
+func TestSyncGRPC_RefreshesExpiredToken(t *testing.T) {
+	var refreshReq map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/token/refresh", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&refreshReq)
+		json.NewEncoder(w).Encode(map[string]string{
+			"token":         "newtoken",
+			"refresh_token": "newrefresh",
+			"user_id":       "user123",
+		})
+	}))
+	defer ts.Close()
+
+	var calls []string
+	sess := &fakeSession{userID: "user123", token: "expired", refreshToken: "refresh"}
+	client := &Client{
+		ServerURL:  ts.URL,
+		LocalDB:    &fakeLocalStorage{},
+		Session:    sess,
+		grpcClient: &fakeGrpcClient{syncRecordsFunc: fakeAuthSyncRecords("newtoken", &calls)},
+	}
+
+	require.NoError(t, client.SyncGRPC(context.Background()))
+	// Запрос повторён с новым токеном, а в сессии сохранена новая пара токенов.
+	assert.Equal(t, []string{"Bearer expired", "Bearer newtoken"}, calls)
+	assert.Equal(t, "refresh", refreshReq["refresh_token"])
+	assert.Equal(t, "newtoken", sess.token)
+	assert.Equal(t, "newrefresh", sess.refreshToken)
+	assert.Equal(t, "user123", sess.userID)
+}
+
+func TestSyncGRPC_RefreshRejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+	}))
+	defer ts.Close()
+
+	var calls []string
+	sess := &fakeSession{userID: "user123", token: "expired", refreshToken: "revoked"}
+	client := &Client{
+		ServerURL:  ts.URL,
+		LocalDB:    &fakeLocalStorage{},
+		Session:    sess,
+		grpcClient: &fakeGrpcClient{syncRecordsFunc: fakeAuthSyncRecords("newtoken", &calls)},
+	}
+
+	err := client.SyncGRPC(context.Background())
+	assert.ErrorIs(t, err, ErrSessionExpired)
+	assert.Len(t, calls, 1)
+	assert.Equal(t, "expired", sess.token)
+
+	// Без токена обновления повторного входа не избежать.
+	sess.refreshToken = ""
+	err = client.SyncGRPC(context.Background())
+	assert.ErrorIs(t, err, ErrSessionExpired)
+}
+
+func TestLogout(t *testing.T) {
+	var reqs []map[string]any
+	var auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token/refresh":
+			json.NewEncoder(w).Encode(map[string]string{"token": "newtoken", "refresh_token": "newrefresh", "user_id": "user123"})
+		case "/logout":
+			auths = append(auths, r.Header.Get("Authorization"))
+			if r.Header.Get("Authorization") != "Bearer newtoken" {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+			var req map[string]any
+			json.NewDecoder(r.Body).Decode(&req)
+			reqs = append(reqs, req)
+		}
+	}))
+	defer ts.Close()
+
+	// Истёкший токен обновляется, после чего сервер отзывает сессию на всех устройствах.
+	sess := &fakeSession{userID: "user123", token: "expired", refreshToken: "refresh"}
+	client := &Client{ServerURL: ts.URL, Session: sess}
+	require.NoError(t, client.Logout(context.Background(), true))
+	assert.Equal(t, []string{"Bearer expired", "Bearer newtoken"}, auths)
+	require.Len(t, reqs, 1)
+	// Сервер отзывает семейство токенов обновления, поэтому достаточно прежнего токена.
+	assert.Equal(t, "refresh", reqs[0]["refresh_token"])
+	assert.Equal(t, true, reqs[0]["all_devices"])
+	assert.Empty(t, sess.token)
+	assert.Empty(t, sess.userID)
+
+	// Локальная сессия удаляется, даже если сервер недоступен.
+	ts.Close()
+	sess = &fakeSession{userID: "user123", token: "newtoken"}
+	client = &Client{ServerURL: ts.URL, Session: sess}
+	assert.Error(t, client.Logout(context.Background(), false))
+	assert.Empty(t, sess.userID)
+}
+
+func TestChangePassword(t *testing.T) {
+	var reqs []map[string]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/password", r.URL.Path)
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		reqs = append(reqs, req)
+		if req["old_password"] != "old" {
+			http.Error(w, "Invalid old password", http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "newtoken", "refresh_token": "newrefresh", "user_id": "user123"})
+	}))
+	defer ts.Close()
+
+	sess := &fakeSession{userID: "user123", token: "token", refreshToken: "refresh"}
+	client := &Client{ServerURL: ts.URL, Session: sess}
+
+	assert.ErrorIs(t, client.ChangePassword(context.Background(), "wrong", "new"), ErrInvalidPassword)
+	assert.Equal(t, "token", sess.token)
+
+	// Сервер отзывает прежние токены, поэтому клиент сохраняет выданную взамен пару.
+	require.NoError(t, client.ChangePassword(context.Background(), "old", "new"))
+	require.Len(t, reqs, 2)
+	assert.Equal(t, "new", reqs[1]["new_password"])
+	assert.Equal(t, "newtoken", sess.token)
+	assert.Equal(t, "newrefresh", sess.refreshToken)
+}
+
+func TestChangeMasterPassword(t *testing.T) {
+	chdirTemp(t)
+	oldCipher := newTestCipher(t)
+	client := &Client{
+		Session:   &fakeSession{userID: "user123", token: "token"},
+		Encryptor: oldCipher,
+	}
+
+	items := make(map[string]entity.DataItem)
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			var result []entity.DataItem
+			for _, item := range items {
+				result = append(result, item)
+			}
+			return result, nil
+		},
+		getByIDFunc: func(id string) (*entity.DataItem, error) {
+			item := items[id]
+			return &item, nil
+		},
+		saveItemFunc: func(item *entity.DataItem) error {
+			items[item.ID] = *item
+			return nil
+		},
+		saveItemsFunc: func(saved []entity.DataItem) error {
+			for _, item := range saved {
+				items[item.ID] = item
+			}
+			return nil
+		},
+	}
+	client.LocalDB = fakeStore
+
+	// Текст, файл и надгробие, уже синхронизированные с сервером.
+	require.NoError(t, client.SaveText(context.Background(), TextDTO{Text: "secret", Meta: "meta"}))
+	srcPath := filepath.Join(t.TempDir(), "report.txt")
+	require.NoError(t, os.WriteFile(srcPath, []byte("file content"), 0644))
+	require.NoError(t, client.SaveFile(context.Background(), FileDTO{FilePath: srcPath}))
+	items["deleted"] = entity.DataItem{ID: "deleted", Type: entity.DataTypeText, Deleted: true, Revision: 3}
+	for id, item := range items {
+		item.Revision = 5
+		items[id] = item
+	}
+
+	var pushed [][]*pb.DataItem
+	client.grpcClient = &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, req *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			pushed = append(pushed, req.Items)
+			return &pb.SyncRecordsResponse{Cursor: 5}, nil
+		},
+	}
+
+	// Конфликты нужно разрешить до смены ключа.
+	fakeStore.conflicts = map[string]entity.DataItem{"x": {ID: "x"}}
+	assert.ErrorContains(t, client.ChangeMasterPassword(context.Background(), "new-master"), "unresolved conflicts")
+	assert.Same(t, oldCipher, client.Encryptor)
+	fakeStore.conflicts = nil
+
+	require.NoError(t, client.ChangeMasterPassword(context.Background(), "new-master"))
+	// Синхронизация до перешифрования и отправка перешифрованных записей после него.
+	require.Len(t, pushed, 3)
+	assert.Len(t, pushed[2], 2)
+	deleted := items["deleted"]
+	assert.False(t, deleted.IsModified())
+
+	// Записи и файлы открываются только новым ключом.
+	newClient := &Client{LocalDB: fakeStore, Session: &fakeSession{userID: "user123"}, grpcClient: client.grpcClient}
+	require.Error(t, newClient.Unlock(context.Background(), "master"))
+	require.NoError(t, newClient.Unlock(context.Background(), "new-master"))
+	got, err := newClient.GetItems(context.Background())
+	require.NoError(t, err)
+	for _, item := range got {
+		stored := items[item.ID]
+		assert.True(t, stored.IsModified())
+		assert.Equal(t, int64(5), stored.BaseRevision)
+		if item.Type == entity.DataTypeBinary {
+			path, err := newClient.GetFile(context.Background(), item.ID, filepath.Join(t.TempDir(), "out"))
+			require.NoError(t, err)
+			restored, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "file content", string(restored))
+		} else {
+			assert.Equal(t, "secret", item.Content)
+			assert.Equal(t, "meta", item.Meta)
+		}
+	}
+	// Временные файлы перешифрования удалены.
+	entries, err := os.ReadDir(utils.ClientDestDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestChangeMasterPassword_UploadFailure(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	fileItem := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary}
+	require.NoError(t, os.WriteFile(utils.GetLocalFilePath(&fileItem), []byte("data"), 0600))
+	saved := false
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) { return []entity.DataItem{fileItem}, nil },
+		saveItemsFunc: func(items []entity.DataItem) error {
+			saved = saved || len(items) > 0
+			return nil
+		},
+	}
+	cipher := newTestCipher(t)
+	client := &Client{
+		LocalDB:   fakeStore,
+		Session:   &fakeSession{userID: "user123", token: "token"},
+		Encryptor: cipher,
+		grpcClient: &fakeGrpcClient{
+			syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+				return &pb.SyncRecordsResponse{UploadList: []*pb.DataItem{{Id: "file1", Type: int32(entity.DataTypeBinary)}}}, nil
+			},
+			uploadFileFunc: func(ctx context.Context, opts ...grpc.CallOption) (pb.FileSyncService_UploadFileClient, error) {
+				return nil, status.Error(codes.Unavailable, "connection refused")
+			},
+		},
+	}
+
+	// Файл, который не удалось загрузить, не перешифровывается.
+	err := client.ChangeMasterPassword(context.Background(), "new-master")
+	assert.ErrorContains(t, err, "failed to sync before re-encryption")
+	assert.False(t, saved)
+	assert.Same(t, cipher, client.Encryptor)
+}
+
+func TestUnlock_FinishesInterruptedRekey(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	oldCipher := newTestCipher(t)
+	newCipher, err := vault.NewCipher("new-master", "user123")
+	require.NoError(t, err)
+
+	// encryptFile сохраняет файл, зашифрованный шифратором enc.
+	encryptFile := func(enc Encryptor, path, content string) {
+		var buf bytes.Buffer
+		require.NoError(t, enc.EncryptStream(&buf, strings.NewReader(content)))
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0600))
+	}
+	// readFile расшифровывает файл шифратором enc.
+	readFile := func(enc Encryptor, path string) string {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		var buf bytes.Buffer
+		require.NoError(t, enc.DecryptStream(&buf, f))
+		return buf.String()
+	}
+
+	fileItem := entity.DataItem{ID: "file1", Type: entity.DataTypeBinary}
+	path := utils.GetLocalFilePath(&fileItem)
+	encryptFile(oldCipher, path, "old key")
+	encryptFile(newCipher, rekeyFilePath(path), "new key")
+
+	// Смена прервана до сохранения записей: записи зашифрованы старым ключом, копия удаляется.
+	name, err := oldCipher.EncryptString("report.txt")
+	require.NoError(t, err)
+	fileItem.Content = name
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) { return []entity.DataItem{fileItem}, nil },
+	}
+	client := &Client{LocalDB: fakeStore, Session: &fakeSession{userID: "user123"}}
+	require.NoError(t, client.Unlock(context.Background(), "master"))
+	assert.Equal(t, "old key", readFile(oldCipher, path))
+	_, err = os.Stat(rekeyFilePath(path))
+	assert.True(t, os.IsNotExist(err))
+
+	// Смена прервана после сохранения записей: копия заменяет исходный файл.
+	encryptFile(newCipher, rekeyFilePath(path), "new key")
+	name, err = newCipher.EncryptString("report.txt")
+	require.NoError(t, err)
+	fileItem.Content = name
+	client = &Client{LocalDB: fakeStore, Session: &fakeSession{userID: "user123"}}
+	require.NoError(t, client.Unlock(context.Background(), "new-master"))
+	assert.Equal(t, "new key", readFile(newCipher, path))
+	_, err = os.Stat(rekeyFilePath(path))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnlock_MasterPasswordChangedOnAnotherDevice(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	ctx := context.Background()
+	oldCipher := newTestCipher(t)
+	newCipher, err := vault.NewCipher("new-master", "user123")
+	require.NoError(t, err)
+	seal := func(enc Encryptor, plaintext string) string {
+		ciphertext, err := enc.EncryptString(plaintext)
+		require.NoError(t, err)
+		return ciphertext
+	}
+	encryptFile := func(enc Encryptor, content string) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, enc.EncryptStream(&buf, strings.NewReader(content)))
+		return buf.Bytes()
+	}
+
+	// Устройство синхронизировалось до смены мастер-пароля: текст и файл зашифрованы старым ключом.
+	items := map[string]entity.DataItem{
+		"text1": {ID: "text1", Type: entity.DataTypeText, Content: seal(oldCipher, "secret"), Meta: seal(oldCipher, "meta"), Revision: 1},
+		"file1": {ID: "file1", Type: entity.DataTypeBinary, Content: seal(oldCipher, "report.txt"), Meta: seal(oldCipher, ""), Revision: 2},
+	}
+	fileItem := items["file1"]
+	require.NoError(t, os.WriteFile(utils.GetLocalFilePath(&fileItem), encryptFile(oldCipher, "file content"), 0600))
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			var result []entity.DataItem
+			for _, item := range items {
+				result = append(result, item)
+			}
+			return result, nil
+		},
+		getByIDFunc: func(id string) (*entity.DataItem, error) {
+			item := items[id]
+			return &item, nil
+		},
+		saveItemFunc: func(item *entity.DataItem) error {
+			items[item.ID] = *item
+			return nil
+		},
+		saveItemsFunc: func(saved []entity.DataItem) error {
+			for _, item := range saved {
+				items[item.ID] = item
+			}
+			return nil
+		},
+		cursor: 2,
+	}
+
+	// На другом устройстве мастер-пароль сменили: записи на сервере перешифрованы и получили новые ревизии.
+	remoteFile := encryptFile(newCipher, "file content")
+	now := time.Now().Format(time.RFC3339Nano)
+	serverItems := []*pb.DataItem{
+		{Id: "text1", Type: int32(entity.DataTypeText), Content: seal(newCipher, "secret"), Meta: seal(newCipher, "meta"), UpdatedAt: now, Revision: 3},
+		{Id: "file1", Type: int32(entity.DataTypeBinary), Content: seal(newCipher, "report.txt"), Meta: seal(newCipher, ""), UpdatedAt: now, Revision: 4},
+	}
+	var pushed []*pb.DataItem
+	grpcClient := &fakeGrpcClient{
+		syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+			pushed = append(pushed, in.Items...)
+			resp := &pb.SyncRecordsResponse{Cursor: 4}
+			for _, item := range serverItems {
+				if item.Revision > in.Cursor {
+					resp.MergedRecords = append(resp.MergedRecords, item)
+					if item.Type == int32(entity.DataTypeBinary) {
+						resp.DownloadList = append(resp.DownloadList, item)
+					}
+				}
+			}
+			return resp, nil
+		},
+		downloadFileFunc: func(ctx context.Context, req *pb.FileDownloadRequest, opts ...grpc.CallOption) (pb.FileSyncService_DownloadFileClient, error) {
+			return &fakeDownloadStream{chunks: []*pb.FileChunk{{Id: req.Id, ChunkData: remoteFile, Sha256: checksum(remoteFile), Size: int64(len(remoteFile))}}}, nil
+		},
+	}
+	newDevice := func() *Client {
+		return &Client{LocalDB: fakeStore, Session: &fakeSession{userID: "user123", token: "token"}, grpcClient: grpcClient}
+	}
+
+	// Со старым мастер-паролем синхронизация останавливается: записи с сервера не применяются,
+	// а новая запись не отправляется, чтобы на сервере не оказалось записей под старым ключом.
+	client := newDevice()
+	require.NoError(t, client.Unlock(ctx, "master"))
+	require.NoError(t, client.SaveText(ctx, TextDTO{Text: "draft"}))
+	assert.ErrorIs(t, client.SyncGRPC(ctx), ErrMasterPasswordChanged)
+	assert.Empty(t, pushed)
+	assert.Equal(t, int64(2), fakeStore.cursor)
+	assert.Equal(t, int64(1), items["text1"].Revision)
+
+	// Новый мастер-пароль не принимается, пока есть локальные изменения под старым ключом.
+	client = newDevice()
+	assert.ErrorContains(t, client.Unlock(ctx, "new-master"), "1 local changes")
+	assert.Nil(t, client.Encryptor)
+	var draftID string
+	for id, item := range items {
+		if item.IsModified() {
+			draftID = id
+		}
+	}
+	client = newDevice()
+	require.NoError(t, client.Unlock(ctx, "master"))
+	require.NoError(t, client.DeleteItem(ctx, draftID))
+
+	// Неверный мастер-пароль не подходит ни к локальным, ни к серверным записям.
+	client = newDevice()
+	assert.ErrorContains(t, client.Unlock(ctx, "wrong"), "invalid master password")
+	assert.Nil(t, client.Encryptor)
+
+	// Новый мастер-пароль заменяет локальные записи серверными версиями, файл скачивается заново.
+	client = newDevice()
+	require.NoError(t, client.Unlock(ctx, "new-master"))
+	assert.Equal(t, int64(4), fakeStore.cursor)
+	got, err := client.GetItems(ctx)
+	require.NoError(t, err)
+	byID := make(map[string]entity.DataItem)
+	for _, item := range got {
+		byID[item.ID] = item
+	}
+	assert.Equal(t, "secret", byID["text1"].Content)
+	assert.Equal(t, "meta", byID["text1"].Meta)
+	assert.Equal(t, "report.txt", byID["file1"].Content)
+
+	require.NoError(t, client.SyncGRPC(ctx))
+	require.Len(t, pushed, 1)
+	assert.True(t, pushed[0].Deleted, "Удаление черновика отправляется на сервер")
+	path, err := client.GetFile(ctx, "file1", filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "file content", string(restored))
+}
+
+func TestDeleteAccount(t *testing.T) {
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/account", r.URL.Path)
+		methods = append(methods, r.Method)
+		var req map[string]string
+		json.NewDecoder(r.Body).Decode(&req)
+		if req["password"] != "password" {
+			http.Error(w, "Invalid password", http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"message": "ok"})
+	}))
+	defer ts.Close()
+
+	sess := &fakeSession{userID: "user123", token: "token"}
+	client := &Client{ServerURL: ts.URL, Session: sess}
+	assert.ErrorIs(t, client.DeleteAccount(context.Background(), "wrong"), ErrInvalidPassword)
+	assert.Equal(t, "user123", sess.userID)
+
+	require.NoError(t, client.DeleteAccount(context.Background(), "password"))
+	assert.Equal(t, []string{http.MethodDelete, http.MethodDelete}, methods)
+	assert.Empty(t, sess.userID)
+}
+
+func TestExportAccount(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/account/export", r.URL.Path)
+		require.Equal(t, http.MethodGet, r.Method)
+		w.Write([]byte("archive"))
+	}))
+	defer ts.Close()
+
+	client := &Client{ServerURL: ts.URL, Session: &fakeSession{userID: "user123", token: "token"}}
+	dstPath := filepath.Join(t.TempDir(), "export.tar.gz")
+	n, err := client.ExportAccount(context.Background(), dstPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), n)
+	data, err := os.ReadFile(dstPath)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+}
+
+func TestResolveConflict(t *testing.T) {
+	cipher := newTestCipher(t)
+	seal := func(s string) string {
+		enc, err := cipher.EncryptString(s)
+		require.NoError(t, err)
+		return enc
+	}
+	local := entity.DataItem{ID: "item1", Type: entity.DataTypeText, Content: seal("local"), Meta: seal(""), BaseRevision: 1}
+	remote := entity.DataItem{ID: "item1", Type: entity.DataTypeText, Content: seal("remote"), Meta: seal(""), Revision: 3}
+
+	newClient := func(saved *[]entity.DataItem) *Client {
+		store := &fakeLocalStorage{
+			getByIDFunc: func(id string) (*entity.DataItem, error) {
+				item := local
+				return &item, nil
+			},
+			saveItemFunc: func(item *entity.DataItem) error {
+				*saved = append(*saved, *item)
+				return nil
+			},
+		}
+		require.NoError(t, store.SaveConflicts([]entity.DataItem{remote}))
+		return &Client{LocalDB: store, Session: &fakeSession{userID: "user123"}, Encryptor: cipher}
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var saved []entity.DataItem
+		conflicts, err := newClient(&saved).GetConflicts(context.Background())
+		require.NoError(t, err)
+		require.Len(t, conflicts, 1)
+		assert.Equal(t, "local", conflicts[0].Local.Content)
+		assert.Equal(t, "remote", conflicts[0].Remote.Content)
+	})
+
+	t.Run("local", func(t *testing.T) {
+		var saved []entity.DataItem
+		c := newClient(&saved)
+		require.NoError(t, c.ResolveConflict(context.Background(), "item1", KeepLocal))
+		require.Len(t, saved, 1)
+		assert.Equal(t, local.Content, saved[0].Content)
+		assert.Equal(t, int64(3), saved[0].BaseRevision, "Локальное изменение должно основываться на серверной ревизии")
+		assert.True(t, saved[0].IsModified())
+		_, err := c.LocalDB.GetConflictByID("item1")
+		assert.Error(t, err, "Разрешённый конфликт должен быть удалён")
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		var saved []entity.DataItem
+		require.NoError(t, newClient(&saved).ResolveConflict(context.Background(), "item1", KeepRemote))
+		require.Len(t, saved, 1)
+		assert.Equal(t, remote, saved[0])
+	})
+
+	t.Run("both", func(t *testing.T) {
+		var saved []entity.DataItem
+		require.NoError(t, newClient(&saved).ResolveConflict(context.Background(), "item1", KeepBoth))
+		require.Len(t, saved, 2)
+		assert.NotEqual(t, "item1", saved[0].ID)
+		assert.Equal(t, local.Content, saved[0].Content)
+		assert.True(t, saved[0].IsModified())
+		assert.Equal(t, int64(0), saved[0].BaseRevision, "Копия локальной версии – новая запись")
+		assert.Equal(t, remote, saved[1])
+	})
+
+	t.Run("unknown strategy", func(t *testing.T) {
+		var saved []entity.DataItem
+		assert.Error(t, newClient(&saved).ResolveConflict(context.Background(), "item1", "mine"))
+		assert.Empty(t, saved)
+	})
+}
+
 // ===== Тесты для Register =====
 
 func TestRegister_Success(t *testing.T) {
@@ -489,13 +1680,65 @@ func TestRegister_Failure(t *testing.T) {
 	}
 	dto := RegisterDTO{
 		Username: "existinguser",
-		Password: "password",
+		Password: "correct-horse-battery",
 	}
 	err := client.Register(context.Background(), dto)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "registration failed")
 }
 
+func TestRegister_Validation(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "Username already taken", http.StatusConflict)
+	}))
+	defer ts.Close()
+	client := &Client{ServerURL: ts.URL, LocalDB: &fakeLocalStorage{}, Session: &fakeSession{}}
+
+	// Слабый пароль и недопустимое имя отклоняются без обращения к серверу.
+	err := client.Register(context.Background(), RegisterDTO{Username: "newuser", Password: "12345678"})
+	assert.ErrorIs(t, err, policy.ErrWeakPassword)
+	err = client.Register(context.Background(), RegisterDTO{Username: "new user", Password: "correct-horse-battery"})
+	assert.ErrorIs(t, err, policy.ErrInvalidUsername)
+	assert.Zero(t, requests)
+
+	err = client.Register(context.Background(), RegisterDTO{Username: "taken", Password: "correct-horse-battery"})
+	assert.ErrorIs(t, err, ErrUsernameTaken)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_TLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	dto := RegisterDTO{Username: "newuser", Password: "correct-horse-battery"}
+
+	// Клиент с закреплённым отпечатком доверяет самоподписанному сертификату сервера.
+	pinned, err := tlsutil.ClientConfig("", tlsutil.Fingerprint(ts.Certificate()))
+	require.NoError(t, err)
+	client := NewClient(ts.URL, "127.0.0.1:50051", &fakeSession{}, &fakeLocalStorage{}, pinned)
+	require.NoError(t, client.Register(context.Background(), dto))
+
+	// Без закрепления и без УЦ соединение отклоняется.
+	system, err := tlsutil.ClientConfig("", "")
+	require.NoError(t, err)
+	client = NewClient(ts.URL, "127.0.0.1:50051", &fakeSession{}, &fakeLocalStorage{}, system)
+	assert.Error(t, client.Register(context.Background(), dto))
+
+	wrongPin, err := tlsutil.ClientConfig("", strings.Repeat("ab", 32))
+	require.NoError(t, err)
+	client = NewClient(ts.URL, "127.0.0.1:50051", &fakeSession{}, &fakeLocalStorage{}, wrongPin)
+	assert.ErrorIs(t, client.Register(context.Background(), dto), tlsutil.ErrPinMismatch)
+}
+
+func TestNewClient_GrpcTarget(t *testing.T) {
+	client := NewClient("http://staging.example.com:8080", "staging.example.com:6000", &fakeSession{}, &fakeLocalStorage{}, nil)
+	defer client.grpcConn.Close()
+	assert.Equal(t, "staging.example.com:6000", client.grpcConn.Target())
+}
+
 // ===== Тесты для Login =====
 
 func TestLogin_Success(t *testing.T) {
@@ -507,8 +1750,9 @@ func TestLogin_Success(t *testing.T) {
 		}
 		w.Header().Set("Content-Type", "application/json")
 		resp := map[string]string{
-			"token":   "testtoken",
-			"user_id": "user123",
+			"token":         "testtoken",
+			"refresh_token": "testrefresh",
+			"user_id":       "user123",
 		}
 		json.NewEncoder(w).Encode(resp)
 	}))
@@ -538,9 +1782,38 @@ func TestLogin_Success(t *testing.T) {
 	err := client.Login(context.Background(), dto)
 	require.NoError(t, err)
 	assert.Equal(t, "testtoken", savedToken.Token)
+	assert.Equal(t, "testrefresh", savedToken.RefreshToken)
 	assert.Equal(t, "user123", savedToken.UserID)
 }
 
+func TestLogin_TwoFactorRequired(t *testing.T) {
+	var otps []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req LoginDTO
+		json.NewDecoder(r.Body).Decode(&req)
+		otps = append(otps, req.OTP)
+		if req.OTP == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]any{"error": "two-factor authentication required", "two_factor_required": true})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"token": "testtoken", "user_id": "user123"})
+	}))
+	defer ts.Close()
+
+	sess := &fakeSession{}
+	client := &Client{ServerURL: ts.URL, Session: sess}
+	dto := LoginDTO{Username: "testuser", Password: "testpassword"}
+	err := client.Login(context.Background(), dto)
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	assert.Empty(t, sess.token)
+
+	dto.OTP = "123456"
+	require.NoError(t, client.Login(context.Background(), dto))
+	assert.Equal(t, "testtoken", sess.token)
+	assert.Equal(t, []string{"", "123456"}, otps)
+}
+
 func TestLogin_Failure(t *testing.T) {
 	// Сервер возвращает ошибку.
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -635,17 +1908,18 @@ func TestDeleteItem_GetByIDError(t *testing.T) {
 }
 
 func TestDeleteItem_NonBinary(t *testing.T) {
-	deleteCalled := false
+	var savedItem *entity.DataItem
 	fakeStore := &fakeLocalStorage{
 		getByIDFunc: func(id string) (*entity.DataItem, error) {
 			return &entity.DataItem{
 				ID:      id,
 				Type:    entity.DataTypeText, // не бинарный тип
 				Content: "some text",
+				Meta:    "some meta",
 			}, nil
 		},
-		deleteItemFunc: func(id string) error {
-			deleteCalled = true
+		saveItemFunc: func(item *entity.DataItem) error {
+			savedItem = item
 			return nil
 		},
 	}
@@ -656,27 +1930,142 @@ func TestDeleteItem_NonBinary(t *testing.T) {
 	}
 	err := client.DeleteItem(context.Background(), "test-id")
 	require.NoError(t, err)
-	assert.True(t, deleteCalled, "Метод DeleteItem в LocalDB должен быть вызван")
+	// Вместо удаления сохраняется надгробие без содержимого.
+	require.NotNil(t, savedItem, "В LocalDB должно быть сохранено надгробие")
+	assert.Equal(t, "test-id", savedItem.ID)
+	assert.True(t, savedItem.Deleted)
+	assert.False(t, savedItem.DeletedAt.IsZero())
+	assert.Empty(t, savedItem.Content)
+	assert.Empty(t, savedItem.Meta)
 }
 
 // ===== Тест для GetItems =====
 
 func TestGetItems(t *testing.T) {
+	cipher := newTestCipher(t)
 	expectedItems := []entity.DataItem{
-		{ID: "1", Type: entity.DataTypeText, Content: "content1"},
+		{ID: "1", Type: entity.DataTypeText, Content: "content1", Meta: "meta1"},
 		{ID: "2", Type: entity.DataTypeCard, Content: "content2"},
 	}
+	var storedItems []entity.DataItem
+	for _, item := range expectedItems {
+		item.Content, _ = cipher.EncryptString(item.Content)
+		item.Meta, _ = cipher.EncryptString(item.Meta)
+		storedItems = append(storedItems, item)
+	}
+	// Надгробия не возвращаются.
+	storedItems = append(storedItems, entity.DataItem{ID: "3", Type: entity.DataTypeText, Deleted: true})
 	fakeStore := &fakeLocalStorage{
 		getAllItemsFunc: func() ([]entity.DataItem, error) {
-			return expectedItems, nil
+			return storedItems, nil
 		},
 	}
 	fakeSess := &fakeSession{userID: "user123"}
 	client := &Client{
-		LocalDB: fakeStore,
-		Session: fakeSess,
+		LocalDB:   fakeStore,
+		Session:   fakeSess,
+		Encryptor: cipher,
 	}
 	items, err := client.GetItems(context.Background())
 	require.NoError(t, err)
 	assert.Equal(t, expectedItems, items)
 }
+
+// ===== Тесты для Unlock =====
+
+func TestUnlock(t *testing.T) {
+	cipher := newTestCipher(t)
+	content, err := cipher.EncryptString("content1")
+	require.NoError(t, err)
+	meta, err := cipher.EncryptString("")
+	require.NoError(t, err)
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			return []entity.DataItem{{ID: "1", Type: entity.DataTypeText, Content: content, Meta: meta}}, nil
+		},
+	}
+	client := &Client{
+		LocalDB: fakeStore,
+		Session: &fakeSession{userID: "user123"},
+		grpcClient: &fakeGrpcClient{
+			syncRecordsFunc: func(ctx context.Context, in *pb.SyncRecordsRequest, opts ...grpc.CallOption) (*pb.SyncRecordsResponse, error) {
+				return &pb.SyncRecordsResponse{MergedRecords: []*pb.DataItem{{Id: "1", Type: int32(entity.DataTypeText), Content: content, Meta: meta}}}, nil
+			},
+		},
+	}
+
+	// Неверный мастер-пароль не подходит ни к сохранённым записям, ни к записям на сервере.
+	err = client.Unlock(context.Background(), "wrong")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid master password")
+	assert.Nil(t, client.Encryptor)
+
+	require.NoError(t, client.Unlock(context.Background(), "master"))
+	items, err := client.GetItems(context.Background())
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "content1", items[0].Content)
+}
+
+func TestUnlock_EncryptsPlaintextItems(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll(utils.ClientDestDir, 0755))
+	cipher := newTestCipher(t)
+	sealed, err := cipher.EncryptString("content1")
+	require.NoError(t, err)
+
+	// Записи, сохранённые версией клиента без шифрования: текст и файл с расширением в имени.
+	items := map[string]*entity.DataItem{
+		"1": {ID: "1", Type: entity.DataTypeText, Content: sealed, Meta: sealed, Revision: 1},
+		"2": {ID: "2", Type: entity.DataTypeText, Content: "plain text", Meta: "meta", Revision: 2},
+		"3": {ID: "3", Type: entity.DataTypeBinary, Content: "report.txt", Revision: 3},
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(utils.ClientDestDir, "3.txt"), []byte("file content"), 0644))
+	fakeStore := &fakeLocalStorage{
+		getAllItemsFunc: func() ([]entity.DataItem, error) {
+			var result []entity.DataItem
+			for _, item := range items {
+				result = append(result, *item)
+			}
+			return result, nil
+		},
+		getByIDFunc: func(id string) (*entity.DataItem, error) {
+			item := *items[id]
+			return &item, nil
+		},
+		saveItemFunc: func(item *entity.DataItem) error {
+			items[item.ID] = item
+			return nil
+		},
+		plaintext: []string{"2", "3"},
+	}
+	client := &Client{
+		LocalDB: fakeStore,
+		Session: &fakeSession{userID: "user123"},
+	}
+
+	require.NoError(t, client.Unlock(context.Background(), "master"))
+	assert.Empty(t, fakeStore.plaintext)
+	got, err := client.GetItems(context.Background())
+	require.NoError(t, err)
+	byID := make(map[string]entity.DataItem)
+	for _, item := range got {
+		byID[item.ID] = item
+	}
+	assert.Equal(t, "plain text", byID["2"].Content)
+	assert.Equal(t, "meta", byID["2"].Meta)
+	assert.Equal(t, "report.txt", byID["3"].Content)
+	// Зашифрованные записи отправятся на сервер при следующей синхронизации.
+	assert.True(t, items["2"].IsModified())
+	assert.Equal(t, int64(2), items["2"].BaseRevision)
+	assert.False(t, items["1"].IsModified())
+
+	// Файл зашифрован и перемещён по новому пути, открытая копия удалена.
+	_, err = os.Stat(filepath.Join(utils.ClientDestDir, "3.txt"))
+	assert.True(t, os.IsNotExist(err))
+	path, err := client.GetFile(context.Background(), "3", filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	restored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "file content", string(restored))
+}
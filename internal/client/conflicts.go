@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
+)
+
+// Варианты разрешения конфликта.
+const (
+	// KeepLocal оставляет локальную версию: при следующей синхронизации она заменит серверную.
+	KeepLocal = "local"
+	// KeepRemote принимает серверную версию, локальное изменение отбрасывается.
+	KeepRemote = "remote"
+	// KeepBoth принимает серверную версию, а локальная сохраняется как новая запись.
+	KeepBoth = "both"
+)
+
+// Conflict описывает запись, изменённую одновременно локально и на другом устройстве.
+type Conflict struct {
+	Local  entity.DataItem
+	Remote entity.DataItem
+}
+
+// GetConflicts возвращает неразрешённые конфликты с расшифрованными локальной и серверной версиями.
+func (c *Client) GetConflicts(ctx context.Context) ([]Conflict, error) {
+	remotes, err := c.LocalDB.GetConflicts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conflicts: %w", err)
+	}
+	var result []Conflict
+	for _, remote := range remotes {
+		local, err := c.LocalDB.GetByID(remote.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get item by ID: %w", err)
+		}
+		for _, item := range []*entity.DataItem{local, &remote} {
+			if item.Deleted {
+				continue
+			}
+			if err := c.openItem(item); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, Conflict{Local: *local, Remote: remote})
+	}
+	return result, nil
+}
+
+// ResolveConflict разрешает конфликт записи с указанным id выбранным способом (KeepLocal, KeepRemote или KeepBoth).
+func (c *Client) ResolveConflict(ctx context.Context, id string, keep string) error {
+	remote, err := c.LocalDB.GetConflictByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get conflict: %w", err)
+	}
+	local, err := c.LocalDB.GetByID(id)
+	if err != nil {
+		return fmt.Errorf("failed to get item by ID: %w", err)
+	}
+
+	switch keep {
+	case KeepLocal:
+		// Переносим локальное изменение на актуальную серверную ревизию.
+		local.BaseRevision = remote.Revision
+		if err := c.LocalDB.SaveItem(local); err != nil {
+			return err
+		}
+	case KeepRemote:
+		if err := c.acceptRemote(local, remote); err != nil {
+			return err
+		}
+	case KeepBoth:
+		// Локальное удаление сохранять как отдельную запись не нужно.
+		if !local.Deleted {
+			if err := c.copyAsNewItem(local); err != nil {
+				return err
+			}
+		}
+		if err := c.acceptRemote(local, remote); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown resolve strategy %q: expected %s, %s or %s", keep, KeepLocal, KeepRemote, KeepBoth)
+	}
+	return c.LocalDB.DeleteConflict(id)
+}
+
+// acceptRemote заменяет локальную версию записи серверной.
+// Файл серверной версии будет скачан при следующей синхронизации.
+func (c *Client) acceptRemote(local, remote *entity.DataItem) error {
+	if local.Type == entity.DataTypeBinary {
+		removeLocalFile(local)
+	}
+	if remote.Deleted {
+		return c.LocalDB.DeleteItem(remote.ID)
+	}
+	return c.LocalDB.SaveItem(remote)
+}
+
+// copyAsNewItem сохраняет локальную версию записи под новым ID как ещё не синхронизированную запись.
+func (c *Client) copyAsNewItem(local *entity.DataItem) error {
+	id, err := uuid.NewV6()
+	if err != nil {
+		return err
+	}
+	item := *local
+	item.ID = id.String()
+	item.Revision = 0
+	item.BaseRevision = 0
+	if item.Type == entity.DataTypeBinary {
+		if err := os.Rename(utils.GetLocalFilePath(local), utils.GetLocalFilePath(&item)); err != nil {
+			return fmt.Errorf("failed to move local file: %w", err)
+		}
+	}
+	return c.LocalDB.SaveItem(&item)
+}
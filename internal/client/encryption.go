@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	pb "github.com/andranikuz/gophkeeper/internal/filesync"
+	"github.com/andranikuz/gophkeeper/internal/vault"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
+)
+
+// ErrLocked возвращается, если операция требует мастер-пароль, а хранилище не разблокировано.
+var ErrLocked = errors.New("vault is locked: master password is not set")
+
+// ErrMasterPasswordChanged возвращается, если записи на сервере зашифрованы другим ключом:
+// мастер-пароль сменили на другом устройстве.
+var ErrMasterPasswordChanged = errors.New("master password was changed on another device: unlock with the new master password")
+
+// Unlock выводит ключ шифрования из мастер-пароля и проверяет его на уже сохранённых записях.
+// В качестве соли используется идентификатор пользователя, поэтому ключ одинаков на всех устройствах.
+// Если ключ не подходит к локальным записям, он проверяется на записях сервера: мастер-пароль могли
+// сменить на другом устройстве, и тогда локальные записи заменяются серверными версиями.
+// Если смена мастер-пароля была прервана после сохранения записей, Unlock заменяет файлы их перешифрованными копиями.
+func (c *Client) Unlock(ctx context.Context, masterPassword string) error {
+	if masterPassword == "" {
+		return errors.New("master password must be provided")
+	}
+	cipher, err := vault.NewCipher(masterPassword, c.Session.GetUserID())
+	if err != nil {
+		return err
+	}
+	// Записи, сохранённые версиями клиента без шифрования, проверить ключом нельзя.
+	plaintextIDs, err := c.LocalDB.GetPlaintextItemIDs()
+	if err != nil {
+		return fmt.Errorf("failed to get plaintext items: %w", err)
+	}
+	plaintext := make(map[string]bool)
+	for _, id := range plaintextIDs {
+		plaintext[id] = true
+	}
+	// Проверяем ключ на первой записи с содержимым: неверный мастер-пароль не должен
+	// приводить к сохранению записей, зашифрованных другим ключом.
+	items, err := c.LocalDB.GetAllItems()
+	if err != nil {
+		return fmt.Errorf("failed to get local items: %w", err)
+	}
+	for _, item := range items {
+		if item.Content == "" || plaintext[item.ID] {
+			continue
+		}
+		if _, err := cipher.DecryptString(item.Content); err != nil {
+			if err := c.adoptChangedKey(ctx, cipher, items, plaintext); err != nil {
+				return err
+			}
+			if items, err = c.LocalDB.GetAllItems(); err != nil {
+				return fmt.Errorf("failed to get local items: %w", err)
+			}
+		}
+		break
+	}
+	c.Encryptor = cipher
+	if err := c.finishRekey(items); err != nil {
+		return fmt.Errorf("failed to finish master password change: %w", err)
+	}
+	if err := c.encryptPlaintextItems(plaintextIDs); err != nil {
+		return fmt.Errorf("failed to encrypt items saved by an old client version: %w", err)
+	}
+	return nil
+}
+
+// adoptChangedKey переходит на ключ мастер-пароля, сменённого на другом устройстве.
+// При смене мастер-пароля все записи перешифровываются и получают новые ревизии, поэтому ключ
+// проверяется на записях, изменённых на сервере после последней синхронизации. Если он к ним подходит,
+// серверные версии заменяют локальные, а локальные файлы, которые новым ключом не расшифровываются,
+// удаляются и скачиваются заново при синхронизации. Локальные изменения, зашифрованные прежним ключом,
+// новым ключом не прочитать, поэтому при их наличии переход не выполняется.
+func (c *Client) adoptChangedKey(ctx context.Context, cipher Encryptor, items []entity.DataItem, plaintext map[string]bool) error {
+	cursor, err := c.LocalDB.GetSyncCursor()
+	if err != nil {
+		return fmt.Errorf("failed to get sync cursor: %w", err)
+	}
+	var resp *pb.SyncRecordsResponse
+	err = c.withSession(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.grpcClient.SyncRecords(ctx, &pb.SyncRecordsRequest{Cursor: cursor})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("invalid master password (failed to check it against the server: %w)", err)
+	}
+	serverItems := protoToDataItems(resp.MergedRecords)
+	if checked, matched := checkKey(cipher, serverItems); checked == 0 || matched < checked {
+		return errors.New("invalid master password")
+	}
+
+	stale := 0
+	for _, item := range items {
+		if !item.IsModified() || item.Content == "" || plaintext[item.ID] {
+			continue
+		}
+		if _, err := cipher.DecryptString(item.Content); err != nil {
+			stale++
+		}
+	}
+	if stale > 0 {
+		return fmt.Errorf("master password was changed on another device, but %d local changes are encrypted with the previous one: "+
+			"unlock with the previous master password, copy and delete them, then unlock with the new one", stale)
+	}
+
+	logger.InfoLogger.Printf("Master password was changed on another device, replacing local items with server versions")
+	if err := c.applyMergedItems(serverItems, nil); err != nil {
+		return fmt.Errorf("failed to update local DB: %w", err)
+	}
+	if err := c.LocalDB.AddPendingDownloads(binaryIDs(protoToDataItems(resp.DownloadList))); err != nil {
+		return fmt.Errorf("failed to save pending downloads: %w", err)
+	}
+	if err := c.LocalDB.SaveSyncCursor(resp.Cursor); err != nil {
+		return fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+	for _, item := range items {
+		if item.Type != entity.DataTypeBinary || item.Deleted {
+			continue
+		}
+		if err := removeStaleFile(cipher, &item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeStaleFile удаляет локальную копию файла записи, если она не расшифровывается ключом enc.
+// Отсутствующий файл скачивается при следующей синхронизации.
+func removeStaleFile(enc Encryptor, item *entity.DataItem) error {
+	path := utils.GetLocalFilePath(item)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = enc.DecryptStream(io.Discard, f)
+	f.Close()
+	if err == nil {
+		return nil
+	}
+	logger.InfoLogger.Printf("Local copy of file %s is encrypted with another key and will be downloaded again", item.ID)
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove file %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkKey возвращает число записей с содержимым и сколько из них расшифровывается ключом enc.
+func checkKey(enc Encryptor, items []entity.DataItem) (checked, matched int) {
+	for _, item := range items {
+		if item.Deleted || item.Content == "" {
+			continue
+		}
+		checked++
+		if _, err := enc.DecryptString(item.Content); err == nil {
+			matched++
+		}
+	}
+	return checked, matched
+}
+
+// sealItem шифрует содержимое и метаинформацию записи.
+func (c *Client) sealItem(item *entity.DataItem) error {
+	if c.Encryptor == nil {
+		return ErrLocked
+	}
+	return sealItemWith(c.Encryptor, item)
+}
+
+// sealItemWith шифрует содержимое и метаинформацию записи указанным шифратором.
+func sealItemWith(enc Encryptor, item *entity.DataItem) error {
+	content, err := enc.EncryptString(item.Content)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt content: %w", err)
+	}
+	meta, err := enc.EncryptString(item.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt meta: %w", err)
+	}
+	item.Content = content
+	item.Meta = meta
+	return nil
+}
+
+// openItem расшифровывает содержимое и метаинформацию записи.
+func (c *Client) openItem(item *entity.DataItem) error {
+	if c.Encryptor == nil {
+		return ErrLocked
+	}
+	content, err := c.Encryptor.DecryptString(item.Content)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt item %s: %w", item.ID, err)
+	}
+	meta, err := c.Encryptor.DecryptString(item.Meta)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt item %s: %w", item.ID, err)
+	}
+	item.Content = content
+	item.Meta = meta
+	return nil
+}
+
+// verifyFile проверяет целостность зашифрованного файла, полностью расшифровывая его без сохранения.
+func (c *Client) verifyFile(r io.Reader) error {
+	if c.Encryptor == nil {
+		return ErrLocked
+	}
+	if err := c.Encryptor.DecryptStream(io.Discard, r); err != nil {
+		return fmt.Errorf("file integrity check failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
+)
+
+// GetFile расшифровывает локальную копию файла записи с указанным id и сохраняет её по пути dstPath.
+// Если dstPath пуст, файл сохраняется в текущую директорию под исходным именем.
+// Возвращает путь к расшифрованному файлу.
+func (c *Client) GetFile(ctx context.Context, id string, dstPath string) (string, error) {
+	item, err := c.LocalDB.GetByID(id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get item by ID: %w", err)
+	}
+	if item.Type != entity.DataTypeBinary || item.Deleted {
+		return "", fmt.Errorf("item %s is not a file", id)
+	}
+	srcPath := utils.GetLocalFilePath(item)
+	if err := c.openItem(item); err != nil {
+		return "", err
+	}
+	if dstPath == "" {
+		dstPath = filepath.Base(item.Content)
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	// Расшифровываем во временный файл, чтобы при ошибке целостности не оставить частичный результат.
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file %s: %w", tmpPath, err)
+	}
+	defer os.Remove(tmpPath)
+	defer dst.Close()
+
+	if err := c.Encryptor.DecryptStream(dst, src); err != nil {
+		return "", fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return "", fmt.Errorf("failed to rename file: %w", err)
+	}
+	return dstPath, nil
+}
@@ -6,11 +6,22 @@ import (
 	"github.com/andranikuz/gophkeeper/pkg/entity"
 )
 
-// GetItems возвращает данные из локального хранилища.
+// GetItems возвращает расшифрованные данные из локального хранилища.
+// Надгробия удалённых записей не возвращаются.
 func (c *Client) GetItems(ctx context.Context) ([]entity.DataItem, error) {
 	items, err := c.LocalDB.GetAllItems()
 	if err != nil {
 		return nil, err
 	}
-	return items, nil
+	var result []entity.DataItem
+	for _, item := range items {
+		if item.Deleted {
+			continue
+		}
+		if err := c.openItem(&item); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, nil
 }
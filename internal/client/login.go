@@ -4,16 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+	"strings"
 )
 
+// ErrTwoFactorRequired возвращается, если для входа нужен одноразовый код, а он не передан.
+var ErrTwoFactorRequired = errors.New("two-factor authentication required")
+
 // LoginDTO представляет данные для логина.
 type LoginDTO struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	OTP      string `json:"otp,omitempty"` // Код TOTP или код восстановления, если включена двухфакторная аутентификация
 }
 
 // Login отправляет HTTP-запрос на логин и, при успешном ответе, сохраняет JWT-токен и userID в сессии.
@@ -33,8 +38,7 @@ func (c *Client) Login(ctx context.Context, dto LoginDTO) error {
 	req.Header.Set("Content-Type", "application/json")
 
 	// Отправляем запрос с таймаутом.
-	httpClient := &http.Client{Timeout: 10 * time.Second}
-	resp, err := httpClient.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return err
 	}
@@ -43,6 +47,15 @@ func (c *Client) Login(ctx context.Context, dto LoginDTO) error {
 	// Если статус не OK, читаем тело ответа и возвращаем ошибку.
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
+		var challenge struct {
+			TwoFactorRequired bool `json:"two_factor_required"`
+		}
+		if json.Unmarshal(body, &challenge) == nil && challenge.TwoFactorRequired {
+			return ErrTwoFactorRequired
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return fmt.Errorf("login failed: %s, retry in %s s", strings.TrimSpace(string(body)), resp.Header.Get("Retry-After"))
+		}
 		return fmt.Errorf("login failed: %s", string(body))
 	}
 
@@ -61,11 +74,12 @@ func (c *Client) Login(ctx context.Context, dto LoginDTO) error {
 		return fmt.Errorf("user_id not found in response")
 	}
 
-	// Сохраняем токен и userID в сессии.
-	// Метод SaveSession принимает объект типа Token (с полями Token и UserID).
+	// Сохраняем токены и userID в сессии. Токена обновления может не быть,
+	// тогда после истечения JWT потребуется повторный вход.
 	if err := c.Session.Save(Token{
-		Token:  token,
-		UserID: userID,
+		Token:        token,
+		RefreshToken: res["refresh_token"],
+		UserID:       userID,
 	}); err != nil {
 		return err
 	}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Logout завершает сессию: просит сервер отозвать токены сессии и удаляет локальную сессию.
+// С allDevices сервер отзывает токены пользователя на всех устройствах.
+// Локальная сессия удаляется, даже если сервер недоступен; в этом случае возвращается ошибка,
+// так как выданные токены остаются действительными до истечения срока.
+func (c *Client) Logout(ctx context.Context, allDevices bool) error {
+	var revokeErr error
+	if c.Session.GetSessionToken() != "" {
+		revokeErr = c.revokeSession(ctx, allDevices)
+	}
+	if err := c.Session.Clear(); err != nil {
+		return fmt.Errorf("failed to remove local session: %w", err)
+	}
+	if revokeErr != nil {
+		return fmt.Errorf("local session removed, but server logout failed: %w", revokeErr)
+	}
+	return nil
+}
+
+// revokeSession отправляет запрос на отзыв токенов текущей сессии. Если JWT-токен истёк, он обновляется,
+// а сервер получает прежний токен обновления: отзыв любого токена семейства отзывает и выданный взамен.
+func (c *Client) revokeSession(ctx context.Context, allDevices bool) error {
+	resp, err := c.doAuthorized(ctx, http.MethodPost, "/logout", map[string]any{
+		"refresh_token": c.Session.GetRefreshToken(),
+		"all_devices":   allDevices,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("logout failed: %s", string(body))
+	}
+	return nil
+}
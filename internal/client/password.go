@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/andranikuz/gophkeeper/internal/vault"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
+)
+
+// ErrInvalidPassword возвращается, если сервер отклонил текущий пароль учётной записи.
+var ErrInvalidPassword = errors.New("invalid old password")
+
+// ChangePassword меняет пароль учётной записи на сервере. Сервер отзывает все токены пользователя,
+// поэтому на других устройствах потребуется повторный вход; новая пара токенов сохраняется в сессии.
+// Мастер-пароль и шифрование записей при этом не меняются.
+func (c *Client) ChangePassword(ctx context.Context, oldPassword, newPassword string) error {
+	if oldPassword == "" || newPassword == "" {
+		return errors.New("old and new passwords must be provided")
+	}
+	resp, err := c.doAuthorized(ctx, http.MethodPost, "/password", map[string]string{
+		"old_password": oldPassword,
+		"new_password": newPassword,
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusForbidden {
+		return ErrInvalidPassword
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("password change failed: %s", string(body))
+	}
+	var res Token
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+	if res.Token == "" {
+		return fmt.Errorf("token not found in response")
+	}
+	if res.UserID == "" {
+		res.UserID = c.Session.GetUserID()
+	}
+	return c.Session.Save(res)
+}
+
+// ChangeMasterPassword перешифровывает хранилище ключом нового мастер-пароля.
+// Сначала хранилище синхронизируется, чтобы перешифровать актуальные версии записей, затем все записи
+// и локальные копии файлов шифруются новым ключом, отмечаются изменёнными и отправляются на сервер.
+// Остальным устройствам после следующей синхронизации нужен новый мастер-пароль.
+func (c *Client) ChangeMasterPassword(ctx context.Context, newMasterPassword string) error {
+	if c.Encryptor == nil {
+		return ErrLocked
+	}
+	if newMasterPassword == "" {
+		return errors.New("new master password must be provided")
+	}
+	if err := c.SyncGRPC(ctx); err != nil {
+		return fmt.Errorf("failed to sync before re-encryption: %w", err)
+	}
+	// Серверные версии конфликтующих записей зашифрованы старым ключом и после смены ключа
+	// не расшифровались бы, поэтому конфликты нужно разрешить заранее.
+	conflicts, err := c.LocalDB.GetConflicts()
+	if err != nil {
+		return fmt.Errorf("failed to get conflicts: %w", err)
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d unresolved conflicts, resolve them before changing master password", len(conflicts))
+	}
+
+	cipher, err := vault.NewCipher(newMasterPassword, c.Session.GetUserID())
+	if err != nil {
+		return err
+	}
+	items, err := c.LocalDB.GetAllItems()
+	if err != nil {
+		return fmt.Errorf("failed to get local items: %w", err)
+	}
+
+	// Перешифрованные файлы пишутся рядом с исходными. Смена ключа фиксируется сохранением записей
+	// одной транзакцией: до него копии при ошибке удаляются, после него заменяют исходные файлы.
+	// Если смена прервётся после сохранения записей, замену файлов завершит Unlock с новым мастер-паролем.
+	var rekeyedFiles []string
+	committed := false
+	defer func() {
+		if committed {
+			return
+		}
+		for _, path := range rekeyedFiles {
+			os.Remove(rekeyFilePath(path))
+		}
+	}()
+	rekeyed := make([]entity.DataItem, 0, len(items))
+	for _, item := range items {
+		if item.Deleted {
+			continue
+		}
+		if err := c.openItem(&item); err != nil {
+			return err
+		}
+		if err := sealItemWith(cipher, &item); err != nil {
+			return err
+		}
+		if item.Type == entity.DataTypeBinary {
+			path := utils.GetLocalFilePath(&item)
+			rekeyedFiles = append(rekeyedFiles, path)
+			if err := c.rekeyFile(cipher, path); err != nil {
+				return fmt.Errorf("failed to re-encrypt file of item %s: %w", item.ID, err)
+			}
+		}
+		item.MarkModified()
+		rekeyed = append(rekeyed, item)
+	}
+
+	if err := c.LocalDB.SaveItems(rekeyed); err != nil {
+		return fmt.Errorf("failed to save re-encrypted items: %w", err)
+	}
+	committed = true
+	c.Encryptor = cipher
+	for _, path := range rekeyedFiles {
+		if err := os.Rename(rekeyFilePath(path), path); err != nil {
+			return fmt.Errorf("failed to replace file %s, unlock with the new master password to retry: %w", path, err)
+		}
+	}
+	logger.InfoLogger.Printf("Vault re-encrypted with the new master password: %d items", len(rekeyed))
+
+	if err := c.SyncGRPC(ctx); err != nil {
+		return fmt.Errorf("vault re-encrypted locally, but sync failed, run sync again: %w", err)
+	}
+	return nil
+}
+
+// rekeyFile расшифровывает локальную копию файла текущим ключом и шифрует её ключом cipher
+// в файл rekeyFilePath(path). Открытое содержимое временно хранится на диске, так как
+// конвергентное шифрование читает исходные данные дважды.
+func (c *Client) rekeyFile(cipher Encryptor, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	plainPath := path + ".plain"
+	plain, err := os.OpenFile(plainPath, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(plainPath)
+	defer plain.Close()
+	if err := c.Encryptor.DecryptStream(plain, src); err != nil {
+		return fmt.Errorf("failed to decrypt file: %w", err)
+	}
+	if _, err := plain.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	dst, err := os.Create(rekeyFilePath(path))
+	if err != nil {
+		return err
+	}
+	if err := cipher.EncryptStreamConvergent(dst, plain); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to encrypt file: %w", err)
+	}
+	return dst.Close()
+}
+
+// finishRekey завершает смену мастер-пароля, прерванную до замены файлов перешифрованными копиями.
+// Копия, которая расшифровывается текущим ключом, сделана после сохранения перешифрованных записей
+// и заменяет исходный файл. Остальные копии остались от смены, прерванной до сохранения записей,
+// и удаляются.
+func (c *Client) finishRekey(items []entity.DataItem) error {
+	for _, item := range items {
+		if item.Type != entity.DataTypeBinary || item.Deleted {
+			continue
+		}
+		path := utils.GetLocalFilePath(&item)
+		f, err := os.Open(rekeyFilePath(path))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = c.verifyFile(f)
+		f.Close()
+		if err != nil {
+			logger.InfoLogger.Printf("Removing re-encrypted copy of file %s left by an interrupted master password change", item.ID)
+			if err := os.Remove(rekeyFilePath(path)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := os.Rename(rekeyFilePath(path), path); err != nil {
+			return fmt.Errorf("failed to replace file %s: %w", path, err)
+		}
+		logger.InfoLogger.Printf("Finished re-encryption of file %s", item.ID)
+	}
+	return nil
+}
+
+// rekeyFilePath возвращает путь, по которому сохраняется перешифрованная копия файла.
+func rekeyFilePath(path string) string {
+	return path + ".rekey"
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+)
+
+// ErrSessionExpired возвращается, если сессию не удалось продлить и нужно войти заново.
+var ErrSessionExpired = errors.New("session expired, please login again")
+
+// withSession выполняет call с JWT-токеном сессии в метаданных gRPC. Если сервер отклонил токен,
+// сессия один раз обновляется токеном обновления и вызов повторяется с новым токеном.
+func (c *Client) withSession(ctx context.Context, call func(ctx context.Context) error) error {
+	err := call(c.authContext(ctx))
+	if status.Code(err) != codes.Unauthenticated {
+		return err
+	}
+	if c.Session.GetRefreshToken() == "" {
+		return fmt.Errorf("%w: %v", ErrSessionExpired, err)
+	}
+	logger.InfoLogger.Printf("Access token rejected, refreshing session")
+	if err := c.refreshSession(ctx); err != nil {
+		return err
+	}
+	return call(c.authContext(ctx))
+}
+
+// doAuthorized отправляет JSON-запрос к HTTP API с JWT-токеном сессии; при payload == nil запрос
+// отправляется без тела. Если сервер отклонил токен, сессия один раз обновляется и запрос повторяется.
+// Закрыть тело ответа должен вызывающий.
+func (c *Client) doAuthorized(ctx context.Context, method, path string, payload any) (*http.Response, error) {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+	send := func() (*http.Response, error) {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.ServerURL+path, body)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bearer "+c.Session.GetSessionToken())
+		return c.httpClient().Do(req)
+	}
+
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.Session.GetRefreshToken() == "" {
+		return resp, err
+	}
+	resp.Body.Close()
+	logger.InfoLogger.Printf("Access token rejected, refreshing session")
+	if err := c.refreshSession(ctx); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+// authContext добавляет в исходящие метаданные текущий JWT-токен сессии.
+func (c *Client) authContext(ctx context.Context) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.Session.GetSessionToken())
+}
+
+// refreshSession обменивает токен обновления на новую пару токенов и сохраняет её в сессии.
+// Старый токен обновления после этого недействителен.
+func (c *Client) refreshSession(ctx context.Context) error {
+	data, err := json.Marshal(map[string]string{"refresh_token": c.Session.GetRefreshToken()})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", c.ServerURL+"/token/refresh", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to refresh session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrSessionExpired
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to refresh session: %s", string(body))
+	}
+
+	var res Token
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return err
+	}
+	if res.Token == "" || res.RefreshToken == "" {
+		return fmt.Errorf("tokens not found in refresh response")
+	}
+	if res.UserID == "" {
+		res.UserID = c.Session.GetUserID()
+	}
+	return c.Session.Save(res)
+}
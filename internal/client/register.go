@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/policy"
 )
 
 // RegisterDTO представляет данные для регистрации.
@@ -16,8 +18,19 @@ type RegisterDTO struct {
 	Password string `json:"password"`
 }
 
-// Register отправляет запрос на регистрацию пользователя.
+// ErrUsernameTaken возвращается, если имя пользователя уже зарегистрировано на сервере.
+var ErrUsernameTaken = errors.New("username already taken")
+
+// Register отправляет запрос на регистрацию пользователя. Имя и пароль заранее проверяются
+// по правилам сервера по умолчанию, чтобы не отправлять заведомо отклоняемый запрос.
 func (c *Client) Register(ctx context.Context, dto RegisterDTO) error {
+	credentialPolicy := policy.Default()
+	if err := credentialPolicy.ValidateUsername(dto.Username); err != nil {
+		return err
+	}
+	if err := credentialPolicy.ValidatePassword(dto.Password, dto.Username); err != nil {
+		return err
+	}
 	url := c.ServerURL + "/register"
 	data, err := json.Marshal(dto)
 	if err != nil {
@@ -28,12 +41,14 @@ func (c *Client) Register(ctx context.Context, dto RegisterDTO) error {
 		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := c.httpClient().Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusConflict {
+		return ErrUsernameTaken
+	}
 	if resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("registration failed: %s", string(body))
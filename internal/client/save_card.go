@@ -65,7 +65,7 @@ func (dto *CardDTO) Validate() error {
 }
 
 // SaveCard сохраняет данные типа "card" в локальное хранилище.
-// Сначала выполняется валидация DTO, затем сериализация, кодирование в base64 и сохранение.
+// Сначала выполняется валидация DTO, затем сериализация, шифрование и сохранение.
 func (c *Client) SaveCard(ctx context.Context, dto CardDTO) error {
 	if err := dto.Validate(); err != nil {
 		return err
@@ -78,11 +78,15 @@ func (c *Client) SaveCard(ctx context.Context, dto CardDTO) error {
 	if err != nil {
 		return err
 	}
-	return c.LocalDB.SaveItem(entity.NewDataItem(
+	item := entity.NewDataItem(
 		id.String(),
 		entity.DataTypeCard,
 		string(payload),
 		dto.Meta,
 		c.Session.GetUserID(),
-	))
+	)
+	if err := c.sealItem(item); err != nil {
+		return err
+	}
+	return c.LocalDB.SaveItem(item)
 }
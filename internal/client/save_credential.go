@@ -26,11 +26,15 @@ func (c *Client) SaveCredential(ctx context.Context, dto CredentialDTO) error {
 	if err != nil {
 		return err
 	}
-	return c.LocalDB.SaveItem(entity.NewDataItem(
+	item := entity.NewDataItem(
 		id.String(),
 		entity.DataTypeCredential,
 		string(payload),
 		dto.Meta,
 		c.Session.GetUserID(),
-	))
+	)
+	if err := c.sealItem(item); err != nil {
+		return err
+	}
+	return c.LocalDB.SaveItem(item)
 }
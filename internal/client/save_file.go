@@ -2,7 +2,7 @@ package client
 
 import (
 	"context"
-	"io"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -18,9 +18,12 @@ type FileDTO struct {
 	Meta     string `json:"meta"`
 }
 
-// SaveFile копирует исходный файл в директорию ./data/client_files с новым именем,
-// а в объект DataItem сохраняет только базовое имя исходного файла в поле Content.
+// SaveFile шифрует исходный файл и сохраняет его в директорию ./data/client_files с новым именем,
+// а в объект DataItem сохраняет только базовое имя исходного файла в поле Content (в зашифрованном виде).
 func (c *Client) SaveFile(ctx context.Context, dto FileDTO) error {
+	if c.Encryptor == nil {
+		return ErrLocked
+	}
 	// Генерируем новый UUID.
 	id, err := uuid.NewV6()
 	if err != nil {
@@ -48,16 +51,22 @@ func (c *Client) SaveFile(ctx context.Context, dto FileDTO) error {
 	)
 
 	// Создаем новый файл в директории назначения.
-	dstFile, err := os.Create(utils.GetLocalFilePath(item))
+	dstPath := utils.GetLocalFilePath(item)
+	dstFile, err := os.Create(dstPath)
 	if err != nil {
 		return err
 	}
 	defer dstFile.Close()
 
-	// Копируем содержимое файла.
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return err
+	// Шифруем содержимое файла потоково. Шифрование конвергентное: одинаковые файлы дают одинаковый
+	// шифротекст, поэтому сервер хранит и принимает их один раз.
+	if err := c.Encryptor.EncryptStreamConvergent(dstFile, srcFile); err != nil {
+		os.Remove(dstPath)
+		return fmt.Errorf("failed to encrypt file: %w", err)
 	}
 
+	if err := c.sealItem(item); err != nil {
+		return err
+	}
 	return c.LocalDB.SaveItem(item)
 }
@@ -20,11 +20,15 @@ func (c *Client) SaveText(ctx context.Context, dto TextDTO) error {
 	if err != nil {
 		return err
 	}
-	return c.LocalDB.SaveItem(entity.NewDataItem(
+	item := entity.NewDataItem(
 		id.String(),
 		entity.DataTypeText,
 		dto.Text,
 		dto.Meta,
 		c.Session.GetUserID(),
-	))
+	)
+	if err := c.sealItem(item); err != nil {
+		return err
+	}
+	return c.LocalDB.SaveItem(item)
 }
@@ -2,13 +2,17 @@ package client
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sync"
 	"time"
 
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
 	"github.com/andranikuz/gophkeeper/pkg/entity"
@@ -17,50 +21,120 @@ import (
 )
 
 // SyncGRPC выполняет синхронизацию метаданных и файлов с сервером через gRPC.
+// Если срок действия токена истёк, сессия обновляется и синхронизация повторяется.
 func (c *Client) SyncGRPC(ctx context.Context) error {
-	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.Session.GetSessionToken())
-	// 1. Получаем локальные записи.
+	return c.withSession(ctx, c.syncGRPC)
+}
+
+// syncGRPC выполняет одну попытку синхронизации с авторизацией из ctx.
+func (c *Client) syncGRPC(ctx context.Context) error {
+	// 1. Получаем локальные записи и курсор последней синхронизации.
 	localItems, err := c.LocalDB.GetAllItems()
 	if err != nil {
 		return fmt.Errorf("failed to get local items: %w", err)
 	}
+	cursor, err := c.LocalDB.GetSyncCursor()
+	if err != nil {
+		return fmt.Errorf("failed to get sync cursor: %w", err)
+	}
 
-	// 2. Преобразуем записи в protobuf-формат.
-	pbItems := dataItemsToProto(localItems)
+	// 2. Отправляем только записи, изменённые с последней синхронизации.
+	var changedItems []entity.DataItem
+	hasNewItems := false
+	for _, item := range localItems {
+		if item.IsModified() {
+			changedItems = append(changedItems, item)
+			hasNewItems = hasNewItems || (item.BaseRevision == 0 && !item.Deleted)
+		}
+	}
+	pbItems := dataItemsToProto(changedItems)
+
+	// Изменения существующих записей после смены мастер-пароля на другом устройстве вернутся конфликтами,
+	// а новые записи сервер примет как есть. Поэтому перед их отправкой проверяем, что записи на сервере
+	// зашифрованы тем же ключом: иначе новые записи нельзя будет прочитать новым мастер-паролем.
+	if hasNewItems && c.Encryptor != nil {
+		peek, err := c.grpcClient.SyncRecords(ctx, &pb.SyncRecordsRequest{Cursor: cursor})
+		if err != nil {
+			return fmt.Errorf("sync records error: %w", err)
+		}
+		if err := c.checkServerKey(protoToDataItems(peek.MergedRecords)); err != nil {
+			return err
+		}
+	}
 
 	// 3. Формируем запрос на синхронизацию.
-	syncReq := &pb.SyncRecordsRequest{Items: pbItems}
+	syncReq := &pb.SyncRecordsRequest{Items: pbItems, Cursor: cursor}
 	resp, err := c.grpcClient.SyncRecords(ctx, syncReq)
 	if err != nil {
 		return fmt.Errorf("sync records error: %w", err)
 	}
 
-	// 4. Преобразуем объединённый список из ответа в []entity.DataItem и обновляем локальное хранилище.
+	// 4. Преобразуем изменения с сервера в []entity.DataItem и обновляем локальное хранилище.
 	mergedItems := protoToDataItems(resp.MergedRecords)
-	if err := c.LocalDB.SaveItems(mergedItems); err != nil {
+	conflicts := protoToDataItems(resp.Conflicts)
+	// Записи, зашифрованные другим ключом, не применяются, а курсор не сохраняется:
+	// их применит разблокировка новым мастер-паролем.
+	if err := c.checkServerKey(append(mergedItems, conflicts...)); err != nil {
+		return err
+	}
+	if err := c.applyMergedItems(mergedItems, conflicts); err != nil {
 		return fmt.Errorf("failed to update local DB: %w", err)
 	}
 
-	// 5. Обрабатываем списки для передачи файлов.
-	uploadList := protoToDataItems(resp.UploadList)
-	downloadList := protoToDataItems(resp.DownloadList)
+	// Серверные версии конфликтующих записей сохраняем до разрешения конфликта пользователем.
+	if len(conflicts) > 0 {
+		if err := c.LocalDB.SaveConflicts(conflicts); err != nil {
+			return fmt.Errorf("failed to save conflicts: %w", err)
+		}
+		logger.InfoLogger.Printf("SyncGRPC: %d conflicts detected, run `conflicts` to review them", len(conflicts))
+	}
+	// Файлы к скачиванию запоминаются до сохранения курсора: следующая синхронизация их уже не вернёт.
+	if err := c.LocalDB.AddPendingDownloads(binaryIDs(protoToDataItems(resp.DownloadList))); err != nil {
+		return fmt.Errorf("failed to save pending downloads: %w", err)
+	}
+	if err := c.LocalDB.SaveSyncCursor(resp.Cursor); err != nil {
+		return fmt.Errorf("failed to save sync cursor: %w", err)
+	}
+
+	// 5. Обрабатываем списки для передачи файлов. Файлы, которые ждёт сервер или которые нужно скачать,
+	// запоминаются до успешной передачи, поэтому файл, не переданный из-за ошибки, будет передан
+	// при следующей синхронизации.
+	if err := c.LocalDB.AddPendingUploads(binaryIDs(protoToDataItems(resp.UploadList))); err != nil {
+		return fmt.Errorf("failed to save pending uploads: %w", err)
+	}
+	uploadList, err := c.pendingUploads()
+	if err != nil {
+		return fmt.Errorf("failed to get pending uploads: %w", err)
+	}
+	pendingDownloads, err := c.pendingDownloads()
+	if err != nil {
+		return fmt.Errorf("failed to get pending downloads: %w", err)
+	}
+	downloadList := appendMissingFiles(pendingDownloads, localItems, conflicts)
 
 	// Для загрузки файлов с клиента на сервер.
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var transferErrs []error
 	for _, item := range uploadList {
-		if item.Type == entity.DataTypeBinary {
-			wg.Add(1)
-			go func(fileID string) {
-				defer wg.Done()
-				// Получаем локальный путь к файлу по его ID
-				localFilePath := utils.GetLocalFilePath(&item)
-				if err := c.uploadFileGRPC(ctx, fileID, localFilePath); err != nil {
-					logger.ErrorLogger.Printf("Error uploading file %s: %v", fileID, err)
-				} else {
-					logger.InfoLogger.Printf("File %s uploaded successfully", fileID)
-				}
-			}(item.ID)
-		}
+		wg.Add(1)
+		go func(item entity.DataItem) {
+			defer wg.Done()
+			// Получаем локальный путь к файлу по его ID
+			localFilePath := utils.GetLocalFilePath(&item)
+			err := c.uploadFileGRPC(ctx, item.ID, localFilePath)
+			if err == nil {
+				err = c.LocalDB.RemovePendingUpload(item.ID)
+			}
+			if err != nil {
+				logger.ErrorLogger.Printf("Error uploading file %s: %v", item.ID, err)
+				mu.Lock()
+				transferErrs = append(transferErrs, fmt.Errorf("failed to upload file %s: %w", item.ID, err))
+				mu.Unlock()
+				return
+			}
+			logger.InfoLogger.Printf("File %s uploaded successfully", item.ID)
+		}(item)
 	}
 
 	// Для скачивания файлов с сервера на клиента.
@@ -71,23 +145,243 @@ func (c *Client) SyncGRPC(ctx context.Context) error {
 				defer wg.Done()
 				// Скачиваем файл и сохраняем его локально.
 				localFilePath, err := c.downloadFileGRPC(ctx, item)
+				if err == nil {
+					err = c.LocalDB.RemovePendingDownload(item.ID)
+				}
 				if err != nil {
 					logger.ErrorLogger.Printf("Error downloading file %s: %v", item.ID, err)
+					mu.Lock()
+					transferErrs = append(transferErrs, fmt.Errorf("failed to download file %s: %w", item.ID, err))
+					mu.Unlock()
 					return
 				}
 				logger.InfoLogger.Printf("File %s downloaded successfully, saved at %s", item.ID, localFilePath)
-				// При необходимости можно обновить запись в локальном хранилище с новым путем.
 			}(item)
 		}
 	}
 	wg.Wait()
 
+	if len(transferErrs) > 0 {
+		return errors.Join(transferErrs...)
+	}
 	logger.InfoLogger.Printf("SyncGRPC: synchronization completed successfully")
 	return nil
 }
 
-// uploadFileGRPC выполняет загрузку файла с клиента на сервер с использованием стриминга.
+// checkServerKey возвращает ErrMasterPasswordChanged, если записи с сервера не расшифровываются текущим ключом.
+// Без ключа проверять нечем, и записи принимаются как есть.
+func (c *Client) checkServerKey(items []entity.DataItem) error {
+	if c.Encryptor == nil {
+		return nil
+	}
+	if checked, matched := checkKey(c.Encryptor, items); matched < checked {
+		return ErrMasterPasswordChanged
+	}
+	return nil
+}
+
+// binaryIDs возвращает ID файловых записей списка.
+func binaryIDs(items []entity.DataItem) []string {
+	var ids []string
+	for _, item := range items {
+		if item.Type == entity.DataTypeBinary {
+			ids = append(ids, item.ID)
+		}
+	}
+	return ids
+}
+
+// pendingUploads возвращает локальные записи, файлы которых ещё не загружены на сервер.
+// Удалённые с тех пор записи из списка ожидания исключаются: их файлы загружать уже не нужно.
+func (c *Client) pendingUploads() ([]entity.DataItem, error) {
+	ids, err := c.LocalDB.GetPendingUploads()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	localItems, err := c.LocalDB.GetAllItems()
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[string]entity.DataItem)
+	for _, item := range localItems {
+		itemMap[item.ID] = item
+	}
+	var items []entity.DataItem
+	for _, id := range ids {
+		item, ok := itemMap[id]
+		if !ok || item.Deleted || item.Type != entity.DataTypeBinary {
+			if err := c.LocalDB.RemovePendingUpload(id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// pendingDownloads возвращает локальные записи, новые версии файлов которых ещё не скачаны.
+// Удалённые и изменённые локально записи из списка ожидания исключаются: серверная версия файла
+// изменённой записи скачивается при разрешении конфликта.
+func (c *Client) pendingDownloads() ([]entity.DataItem, error) {
+	ids, err := c.LocalDB.GetPendingDownloads()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+	localItems, err := c.LocalDB.GetAllItems()
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[string]entity.DataItem)
+	for _, item := range localItems {
+		itemMap[item.ID] = item
+	}
+	var items []entity.DataItem
+	for _, id := range ids {
+		item, ok := itemMap[id]
+		if !ok || item.Deleted || item.IsModified() || item.Type != entity.DataTypeBinary {
+			if err := c.LocalDB.RemovePendingDownload(id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// applyMergedItems сохраняет объединённый список записей в локальное хранилище.
+// Надгробия применяются удалением локальной записи и её файла: сервер уже знает об удалении
+// и хранит надгробие для остальных устройств, поэтому локально его держать не нужно.
+// Записи из списка конфликтов не перезаписываются: локальное изменение сохраняется до разрешения конфликта.
+func (c *Client) applyMergedItems(mergedItems, conflicts []entity.DataItem) error {
+	conflictIDs := make(map[string]bool)
+	for _, item := range conflicts {
+		conflictIDs[item.ID] = true
+	}
+	var liveItems []entity.DataItem
+	for _, item := range mergedItems {
+		if conflictIDs[item.ID] {
+			continue
+		}
+		if !item.Deleted {
+			liveItems = append(liveItems, item)
+			continue
+		}
+		if item.Type == entity.DataTypeBinary {
+			removeLocalFile(&item)
+		}
+		if err := c.LocalDB.DeleteItem(item.ID); err != nil {
+			return err
+		}
+	}
+	return c.LocalDB.SaveItems(liveItems)
+}
+
+// appendMissingFiles дополняет список скачивания синхронизированными файловыми записями,
+// локальная копия которых отсутствует, например после принятия серверной версии при разрешении конфликта.
+func appendMissingFiles(downloadList, localItems, conflicts []entity.DataItem) []entity.DataItem {
+	skip := make(map[string]bool)
+	for _, item := range downloadList {
+		skip[item.ID] = true
+	}
+	for _, item := range conflicts {
+		skip[item.ID] = true
+	}
+	for _, item := range localItems {
+		if item.Type != entity.DataTypeBinary || item.Deleted || item.IsModified() || skip[item.ID] {
+			continue
+		}
+		if _, err := os.Stat(utils.GetLocalFilePath(&item)); os.IsNotExist(err) {
+			downloadList = append(downloadList, item)
+		}
+	}
+	return downloadList
+}
+
+// maxTransferAttempts — сколько раз передача файла продолжается после обрыва соединения.
+const maxTransferAttempts = 3
+
+// errTransferCorrupted возвращается, если переданный файл не прошёл проверку контрольной суммы
+// или целостности. Такую передачу бессмысленно продолжать с места обрыва.
+var errTransferCorrupted = errors.New("file transfer corrupted")
+
+// retryTransfer выполняет передачу файла, продолжая её после обрывов, но не после повреждения данных.
+func retryTransfer(ctx context.Context, fileID string, transfer func() error) error {
+	var err error
+	for attempt := 1; attempt <= maxTransferAttempts; attempt++ {
+		if err = transfer(); err == nil || errors.Is(err, errTransferCorrupted) || ctx.Err() != nil {
+			return err
+		}
+		logger.ErrorLogger.Printf("Transfer of file %s interrupted (attempt %d of %d): %v", fileID, attempt, maxTransferAttempts, err)
+	}
+	return err
+}
+
+// uploadChunkSize — размер чанков, на которые файл делится при загрузке на сервер.
+const uploadChunkSize = 1 << 20
+
+// fileChunks описывает разбиение файла на чанки для загрузки.
+type fileChunks struct {
+	sum    string   // SHA-256 всего файла в hex.
+	size   int64    // Размер файла.
+	hashes []string // SHA-256 чанков в hex.
+}
+
+// uploadFileGRPC загружает файл на сервер по чанкам. Сервер хранит чанки по хэшу содержимого,
+// поэтому клиент передаёт только чанки, которых на сервере ещё нет, а прерванная загрузка
+// продолжается догрузкой недостающих чанков.
 func (c *Client) uploadFileGRPC(ctx context.Context, fileID, filePath string) error {
+	chunks, err := splitFile(filePath)
+	if err != nil {
+		return err
+	}
+	return retryTransfer(ctx, fileID, func() error {
+		return c.uploadChunks(ctx, fileID, filePath, chunks)
+	})
+}
+
+// splitFile вычисляет хэши чанков и контрольную сумму файла.
+func splitFile(filePath string) (*fileChunks, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer f.Close()
+
+	chunks := &fileChunks{}
+	fileHash := sha256.New()
+	buf := make([]byte, uploadChunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			chunkHash := sha256.Sum256(buf[:n])
+			chunks.hashes = append(chunks.hashes, hex.EncodeToString(chunkHash[:]))
+			fileHash.Write(buf[:n])
+			chunks.size += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	chunks.sum = hex.EncodeToString(fileHash.Sum(nil))
+	return chunks, nil
+}
+
+// uploadChunks выполняет одну попытку загрузки файла: описание файла и недостающие на сервере чанки.
+func (c *Client) uploadChunks(ctx context.Context, fileID, filePath string, chunks *fileChunks) error {
+	missingResp, err := c.grpcClient.GetMissingChunks(ctx, &pb.MissingChunksRequest{Hashes: chunks.hashes})
+	if err != nil {
+		return fmt.Errorf("failed to get missing chunks: %w", err)
+	}
+	missing := make(map[string]bool)
+	for _, hash := range missingResp.Missing {
+		missing[hash] = true
+	}
+
 	f, err := os.Open(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to open file %s: %w", filePath, err)
@@ -98,25 +392,40 @@ func (c *Client) uploadFileGRPC(ctx context.Context, fileID, filePath string) er
 	if err != nil {
 		return fmt.Errorf("failed to start upload stream: %w", err)
 	}
+	header := &pb.FileChunk{
+		Id:     fileID,
+		Sha256: chunks.sum,
+		Size:   chunks.size,
+		Chunks: chunks.hashes,
+	}
+	if err := stream.Send(header); err != nil {
+		return fmt.Errorf("failed to send file header: %w", err)
+	}
 
-	buf := make([]byte, 32*1024) // Чанк размером 32KB.
-	for {
-		n, err := f.Read(buf)
-		if err != nil && err != io.EOF {
+	buf := make([]byte, uploadChunkSize)
+	for _, hash := range chunks.hashes {
+		n, err := io.ReadFull(f, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
 			return fmt.Errorf("failed to read file: %w", err)
 		}
-		if n == 0 {
-			break
+		if !missing[hash] {
+			continue
 		}
+		// Одинаковые чанки внутри файла передаются один раз.
+		delete(missing, hash)
 		chunk := &pb.FileChunk{
 			Id:        fileID,
 			ChunkData: buf[:n],
+			ChunkHash: hash,
 		}
 		if err := stream.Send(chunk); err != nil {
 			return fmt.Errorf("failed to send chunk: %w", err)
 		}
 	}
 	resp, err := stream.CloseAndRecv()
+	if status.Code(err) == codes.DataLoss {
+		return fmt.Errorf("%w: %v", errTransferCorrupted, err)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to close upload stream: %w", err)
 	}
@@ -127,34 +436,97 @@ func (c *Client) uploadFileGRPC(ctx context.Context, fileID, filePath string) er
 }
 
 // downloadFileGRPC скачивает файл с сервера по ID с использованием стриминга и сохраняет его локально.
+// Файл принимается в частичный файл, и прерванное скачивание продолжается с места обрыва.
+// Файл перемещается на место только после проверки контрольной суммы и целостности.
 // Возвращает путь к сохраненному файлу.
 func (c *Client) downloadFileGRPC(ctx context.Context, item entity.DataItem) (string, error) {
-	req := &pb.FileDownloadRequest{Id: item.ID}
-	stream, err := c.grpcClient.DownloadFile(ctx, req)
-	if err != nil {
-		return "", fmt.Errorf("failed to start download stream: %w", err)
-	}
+	var localFilePath string
+	err := retryTransfer(ctx, item.ID, func() error {
+		var err error
+		localFilePath, err = c.downloadFileFrom(ctx, item)
+		return err
+	})
+	return localFilePath, err
+}
 
+// downloadFileFrom выполняет одну попытку скачивания файла, продолжая частичный файл, если он есть.
+func (c *Client) downloadFileFrom(ctx context.Context, item entity.DataItem) (string, error) {
 	// Определяем локальный путь для сохранения файла.
 	localFilePath := utils.GetLocalFilePath(&item)
-	f, err := os.Create(localFilePath)
+	partPath := localFilePath + ".part"
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
 	if err != nil {
-		return "", fmt.Errorf("failed to create file %s: %w", localFilePath, err)
+		return "", fmt.Errorf("failed to create file %s: %w", partPath, err)
 	}
 	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := info.Size()
 
-	for {
+	req := &pb.FileDownloadRequest{Id: item.ID, Offset: offset}
+	stream, err := c.grpcClient.DownloadFile(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to start download stream: %w", err)
+	}
+
+	var size int64
+	var sum string
+	for first := true; ; first = false {
 		chunk, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
+		if status.Code(err) == codes.OutOfRange {
+			// Частичный файл относится к другой версии файла: начинаем скачивание заново.
+			os.Remove(partPath)
+			return "", fmt.Errorf("partial download is stale: %w", err)
+		}
 		if err != nil {
 			return "", fmt.Errorf("failed to receive chunk: %w", err)
 		}
+		if first {
+			if chunk.Offset != offset || chunk.Sha256 == "" {
+				return "", fmt.Errorf("unexpected download header for file %s", item.ID)
+			}
+			size, sum = chunk.Size, chunk.Sha256
+		}
 		if _, err := f.Write(chunk.ChunkData); err != nil {
 			return "", fmt.Errorf("failed to write chunk: %w", err)
 		}
 	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+
+	// Проверяем, что файл получен целиком и без искажений.
+	gotSize, gotSum, err := utils.FileChecksum(partPath)
+	if err != nil {
+		return "", err
+	}
+	if gotSize < size {
+		return "", fmt.Errorf("download incomplete: received %d of %d bytes", gotSize, size)
+	}
+	if gotSum != sum {
+		os.Remove(partPath)
+		return "", fmt.Errorf("%w: checksum mismatch for file %s", errTransferCorrupted, item.ID)
+	}
+
+	// Проверяем целостность: файл должен полностью расшифровываться ключом пользователя.
+	pf, err := os.Open(partPath)
+	if err != nil {
+		return "", err
+	}
+	err = c.verifyFile(pf)
+	pf.Close()
+	if err != nil {
+		os.Remove(partPath)
+		return "", fmt.Errorf("%w: %v", errTransferCorrupted, err)
+	}
+	if err := os.Rename(partPath, localFilePath); err != nil {
+		return "", fmt.Errorf("failed to rename file: %w", err)
+	}
 	return localFilePath, nil
 }
 
@@ -162,13 +534,20 @@ func (c *Client) downloadFileGRPC(ctx context.Context, item entity.DataItem) (st
 func dataItemsToProto(items []entity.DataItem) []*pb.DataItem {
 	var pbItems []*pb.DataItem
 	for _, item := range items {
-		pbItems = append(pbItems, &pb.DataItem{
-			Id:        item.ID,
-			Type:      int32(item.Type),
-			Content:   item.Content,
-			Meta:      item.Meta,
-			UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
-		})
+		pbItem := &pb.DataItem{
+			Id:           item.ID,
+			Type:         int32(item.Type),
+			Content:      item.Content,
+			Meta:         item.Meta,
+			UpdatedAt:    item.UpdatedAt.Format(time.RFC3339Nano),
+			Deleted:      item.Deleted,
+			Revision:     item.Revision,
+			BaseRevision: item.BaseRevision,
+		}
+		if !item.DeletedAt.IsZero() {
+			pbItem.DeletedAt = item.DeletedAt.Format(time.RFC3339Nano)
+		}
+		pbItems = append(pbItems, pbItem)
 	}
 	return pbItems
 }
@@ -177,14 +556,21 @@ func dataItemsToProto(items []entity.DataItem) []*pb.DataItem {
 func protoToDataItems(pbItems []*pb.DataItem) []entity.DataItem {
 	var items []entity.DataItem
 	for _, pbItem := range pbItems {
-		t, _ := time.Parse(time.RFC3339, pbItem.UpdatedAt)
-		items = append(items, entity.DataItem{
-			ID:        pbItem.Id,
-			Type:      entity.DataType(pbItem.Type),
-			Content:   pbItem.Content,
-			Meta:      pbItem.Meta,
-			UpdatedAt: t,
-		})
+		t, _ := time.Parse(time.RFC3339Nano, pbItem.UpdatedAt)
+		item := entity.DataItem{
+			ID:           pbItem.Id,
+			Type:         entity.DataType(pbItem.Type),
+			Content:      pbItem.Content,
+			Meta:         pbItem.Meta,
+			UpdatedAt:    t,
+			Deleted:      pbItem.Deleted,
+			Revision:     pbItem.Revision,
+			BaseRevision: pbItem.BaseRevision,
+		}
+		if pbItem.DeletedAt != "" {
+			item.DeletedAt, _ = time.Parse(time.RFC3339Nano, pbItem.DeletedAt)
+		}
+		items = append(items, item)
 	}
 	return items
 }
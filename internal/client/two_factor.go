@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// TwoFactorEnrollment — данные для добавления аккаунта в приложение-аутентификатор.
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// EnrollTwoFactor начинает подключение двухфакторной аутентификации и возвращает секрет TOTP.
+// Подключение завершается вызовом VerifyTwoFactor с кодом из приложения.
+func (c *Client) EnrollTwoFactor(ctx context.Context) (*TwoFactorEnrollment, error) {
+	resp, err := c.doAuthorized(ctx, http.MethodPost, "/2fa/enroll", struct{}{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("2fa enrollment failed: %s", string(body))
+	}
+	var enrollment TwoFactorEnrollment
+	if err := json.NewDecoder(resp.Body).Decode(&enrollment); err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// VerifyTwoFactor подтверждает подключение кодом из приложения и возвращает коды восстановления.
+func (c *Client) VerifyTwoFactor(ctx context.Context, code string) ([]string, error) {
+	resp, err := c.doAuthorized(ctx, http.MethodPost, "/2fa/verify", map[string]string{"code": code})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("2fa verification failed: %s", string(body))
+	}
+	var res struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	return res.RecoveryCodes, nil
+}
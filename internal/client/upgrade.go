@@ -0,0 +1,79 @@
+package client
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
+)
+
+// encryptPlaintextItems шифрует записи, сохранённые версиями клиента без шифрования, и их файлы.
+// Зашифрованные записи отмечаются изменёнными, чтобы при синхронизации заменить открытые версии на сервере.
+func (c *Client) encryptPlaintextItems(ids []string) error {
+	for _, id := range ids {
+		item, err := c.LocalDB.GetByID(id)
+		if err != nil {
+			// Запись удалена после обновления схемы.
+			if err := c.LocalDB.ClearPlaintextItem(id); err != nil {
+				return err
+			}
+			continue
+		}
+		if item.Type == entity.DataTypeBinary && !item.Deleted {
+			if err := c.encryptLegacyFile(item); err != nil {
+				return err
+			}
+		}
+		if err := c.sealItem(item); err != nil {
+			return err
+		}
+		item.MarkModified()
+		if err := c.LocalDB.SaveItem(item); err != nil {
+			return err
+		}
+		if err := c.LocalDB.ClearPlaintextItem(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptLegacyFile шифрует файл записи, сохранённый версией клиента без шифрования.
+// Такие версии хранили файл под именем <ID><расширение исходного файла>, а в Content — открытое имя файла.
+func (c *Client) encryptLegacyFile(item *entity.DataItem) error {
+	legacyPath := filepath.Join(utils.ClientDestDir, item.ID+filepath.Ext(item.Content))
+	src, err := os.Open(legacyPath)
+	if os.IsNotExist(err) {
+		// Файл ещё не был скачан: зашифрованная версия придёт с сервера.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dstPath := utils.GetLocalFilePath(item)
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if err := c.Encryptor.EncryptStreamConvergent(dst, src); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to encrypt file %s: %w", legacyPath, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return err
+	}
+	if legacyPath != dstPath {
+		return os.Remove(legacyPath)
+	}
+	return nil
+}
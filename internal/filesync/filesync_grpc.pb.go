@@ -19,9 +19,10 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	FileSyncService_SyncRecords_FullMethodName  = "/filesync.FileSyncService/SyncRecords"
-	FileSyncService_UploadFile_FullMethodName   = "/filesync.FileSyncService/UploadFile"
-	FileSyncService_DownloadFile_FullMethodName = "/filesync.FileSyncService/DownloadFile"
+	FileSyncService_SyncRecords_FullMethodName      = "/filesync.FileSyncService/SyncRecords"
+	FileSyncService_UploadFile_FullMethodName       = "/filesync.FileSyncService/UploadFile"
+	FileSyncService_GetMissingChunks_FullMethodName = "/filesync.FileSyncService/GetMissingChunks"
+	FileSyncService_DownloadFile_FullMethodName     = "/filesync.FileSyncService/DownloadFile"
 )
 
 // FileSyncServiceClient is the client API for FileSyncService service.
@@ -34,6 +35,8 @@ type FileSyncServiceClient interface {
 	SyncRecords(ctx context.Context, in *SyncRecordsRequest, opts ...grpc.CallOption) (*SyncRecordsResponse, error)
 	// Загрузка файла: клиент стримит данные (чанки файла) на сервер.
 	UploadFile(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[FileChunk, FileUploadResponse], error)
+	// Чанки из списка, которых ещё нет на сервере: остальные клиент не загружает.
+	GetMissingChunks(ctx context.Context, in *MissingChunksRequest, opts ...grpc.CallOption) (*MissingChunksResponse, error)
 	// Скачивание файла: клиент запрашивает файл по ID, сервер стримит файл чанками.
 	DownloadFile(ctx context.Context, in *FileDownloadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error)
 }
@@ -69,6 +72,16 @@ func (c *fileSyncServiceClient) UploadFile(ctx context.Context, opts ...grpc.Cal
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FileSyncService_UploadFileClient = grpc.ClientStreamingClient[FileChunk, FileUploadResponse]
 
+func (c *fileSyncServiceClient) GetMissingChunks(ctx context.Context, in *MissingChunksRequest, opts ...grpc.CallOption) (*MissingChunksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MissingChunksResponse)
+	err := c.cc.Invoke(ctx, FileSyncService_GetMissingChunks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *fileSyncServiceClient) DownloadFile(ctx context.Context, in *FileDownloadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FileChunk], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	stream, err := c.cc.NewStream(ctx, &FileSyncService_ServiceDesc.Streams[1], FileSyncService_DownloadFile_FullMethodName, cOpts...)
@@ -98,6 +111,8 @@ type FileSyncServiceServer interface {
 	SyncRecords(context.Context, *SyncRecordsRequest) (*SyncRecordsResponse, error)
 	// Загрузка файла: клиент стримит данные (чанки файла) на сервер.
 	UploadFile(grpc.ClientStreamingServer[FileChunk, FileUploadResponse]) error
+	// Чанки из списка, которых ещё нет на сервере: остальные клиент не загружает.
+	GetMissingChunks(context.Context, *MissingChunksRequest) (*MissingChunksResponse, error)
 	// Скачивание файла: клиент запрашивает файл по ID, сервер стримит файл чанками.
 	DownloadFile(*FileDownloadRequest, grpc.ServerStreamingServer[FileChunk]) error
 	mustEmbedUnimplementedFileSyncServiceServer()
@@ -116,6 +131,9 @@ func (UnimplementedFileSyncServiceServer) SyncRecords(context.Context, *SyncReco
 func (UnimplementedFileSyncServiceServer) UploadFile(grpc.ClientStreamingServer[FileChunk, FileUploadResponse]) error {
 	return status.Errorf(codes.Unimplemented, "method UploadFile not implemented")
 }
+func (UnimplementedFileSyncServiceServer) GetMissingChunks(context.Context, *MissingChunksRequest) (*MissingChunksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMissingChunks not implemented")
+}
 func (UnimplementedFileSyncServiceServer) DownloadFile(*FileDownloadRequest, grpc.ServerStreamingServer[FileChunk]) error {
 	return status.Errorf(codes.Unimplemented, "method DownloadFile not implemented")
 }
@@ -165,6 +183,24 @@ func _FileSyncService_UploadFile_Handler(srv interface{}, stream grpc.ServerStre
 // This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
 type FileSyncService_UploadFileServer = grpc.ClientStreamingServer[FileChunk, FileUploadResponse]
 
+func _FileSyncService_GetMissingChunks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MissingChunksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileSyncServiceServer).GetMissingChunks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileSyncService_GetMissingChunks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileSyncServiceServer).GetMissingChunks(ctx, req.(*MissingChunksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _FileSyncService_DownloadFile_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(FileDownloadRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -187,6 +223,10 @@ var FileSyncService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SyncRecords",
 			Handler:    _FileSyncService_SyncRecords_Handler,
 		},
+		{
+			MethodName: "GetMissingChunks",
+			Handler:    _FileSyncService_GetMissingChunks_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
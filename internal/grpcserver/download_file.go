@@ -1,46 +1,91 @@
 package grpcserver
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/utils"
 )
 
-// DownloadFile открывает файл по заданному ID и отправляет его чанками.
+// DownloadFile собирает файл с заданным ID из чанков и отправляет его начиная с запрошенного смещения.
+// Первый чанк содержит размер и SHA-256 всего файла, по которым клиент проверяет результат.
 func (s *fileSyncServiceServer) DownloadFile(req *pb.FileDownloadRequest, stream pb.FileSyncService_DownloadFileServer) error {
 	userID, err := s.authenticator.GetUserIdFromCtx(stream.Context())
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
-	uploadDir := s.uploadDir + `/` + userID
-	filePath := filepath.Join(uploadDir, req.Id)
-	file, err := os.Open(filePath)
+	file, size, sum, err := s.openFile(userID, req.Id)
 	if err != nil {
-		return fmt.Errorf("failed to open file %s: %w", filePath, err)
+		return fmt.Errorf("failed to open file %s: %w", req.Id, err)
 	}
 	defer file.Close()
+	if req.Offset < 0 || req.Offset > size {
+		return status.Errorf(codes.OutOfRange, "invalid offset %d: file size is %d", req.Offset, size)
+	}
+	if _, err := io.CopyN(io.Discard, file, req.Offset); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
 
 	buf := make([]byte, 32*1024) // 32 KB чанки
+	first := true
 	for {
 		n, err := file.Read(buf)
 		if err != nil && err != io.EOF {
 			return fmt.Errorf("failed to read file: %w", err)
 		}
-		if n == 0 {
+		// Первый чанк отправляется даже для пустого остатка, чтобы передать контрольную сумму.
+		if n == 0 && !first {
 			break
 		}
 		chunk := &pb.FileChunk{
 			Id:        req.Id,
 			ChunkData: buf[:n],
 		}
+		if first {
+			chunk.Offset = req.Offset
+			chunk.Sha256 = sum
+			chunk.Size = size
+			first = false
+		}
 		if err := stream.Send(chunk); err != nil {
 			return fmt.Errorf("failed to send chunk: %w", err)
 		}
+		s.metrics.DownloadedBytes(n)
+		if n == 0 {
+			break
+		}
 	}
-	logger.InfoLogger.Printf("DownloadFile: file %s sent successfully", req.Id)
+	logger.InfoLogger.Printf("DownloadFile: file %s sent successfully from offset %d", req.Id, req.Offset)
 	return nil
 }
+
+// openFile открывает файл пользователя по манифесту в хранилище блобов и возвращает поток его данных,
+// размер и SHA-256. Файлы, загруженные до появления хранилища блобов, читаются из uploadDir.
+func (s *fileSyncServiceServer) openFile(userID, fileID string) (io.ReadCloser, int64, string, error) {
+	manifest, err := s.blobRepository.GetManifest(userID, fileID)
+	if err == nil {
+		return s.blobStore.NewReader(manifest.Chunks), manifest.Size, manifest.SHA256, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, 0, "", err
+	}
+	filePath := filepath.Join(s.uploadDir, userID, fileID)
+	size, sum, err := utils.FileChecksum(filePath)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return file, size, sum, nil
+}
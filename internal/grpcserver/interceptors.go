@@ -2,44 +2,31 @@ package grpcserver
 
 import (
 	"context"
-	"errors"
 	"strings"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/andranikuz/gophkeeper/internal/auth"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
 )
 
 // JwtUnaryInterceptor проверяет JWT для униарных gRPC вызовов.
+// Ошибки авторизации возвращаются с кодом Unauthenticated: по нему клиент обновляет истёкший токен.
 func JwtUnaryInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{},
 		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-
-		// Извлекаем метаданные из контекста.
-		md, ok := metadata.FromIncomingContext(ctx)
-		if !ok {
-			return nil, errors.New("missing metadata")
-		}
-
-		authHeaders := md["authorization"]
-		if len(authHeaders) == 0 {
-			return nil, errors.New("authorization token is not supplied")
-		}
-
-		tokenStr := authHeaders[0]
-		parts := strings.Split(tokenStr, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			return nil, errors.New("invalid authorization format")
+		if isPublicMethod(info.FullMethod) {
+			return handler(ctx, req)
 		}
-		tokenStr = parts[1]
 
-		// Проверяем токен.
-		claims, err := authenticator.ValidateToken(tokenStr)
+		ctx, err := authorize(ctx, authenticator)
 		if err != nil {
-			return nil, errors.New("invalid token")
+			return nil, err
 		}
-		ctx = context.WithValue(ctx, auth.ContextKeyUserID, claims.UserID)
 		// Токен действителен, вызываем обработчик.
 		return handler(ctx, req)
 	}
@@ -49,34 +36,59 @@ func JwtUnaryInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInte
 func JwtStreamInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
 	return func(srv interface{}, stream grpc.ServerStream,
 		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-
-		md, ok := metadata.FromIncomingContext(stream.Context())
-		if !ok {
-			return errors.New("missing metadata")
-		}
-		authHeaders := md["authorization"]
-		if len(authHeaders) == 0 {
-			return errors.New("authorization token is not supplied")
-		}
-		tokenStr := authHeaders[0]
-		parts := strings.Split(tokenStr, " ")
-		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			return errors.New("invalid authorization format")
+		if isPublicMethod(info.FullMethod) {
+			return handler(srv, stream)
 		}
-		tokenStr = parts[1]
 
-		// Проверяем токен.
-		claims, err := authenticator.ValidateToken(tokenStr)
+		newCtx, err := authorize(stream.Context(), authenticator)
 		if err != nil {
-			return errors.New("invalid token")
+			return err
 		}
 		// Пробрасываем userID в контекст стрима.
-		newCtx := context.WithValue(stream.Context(), auth.ContextKeyUserID, claims.UserID)
 		wrapped := grpcServerStreamWithContext{ServerStream: stream, ctx: newCtx}
 		return handler(srv, wrapped)
 	}
 }
 
+// isPublicMethod сообщает, доступен ли метод без токена. Без авторизации работает только
+// сервис grpc.health.v1: его опрашивают балансировщики и оркестраторы.
+func isPublicMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// authorize проверяет JWT из метаданных запроса: подпись, срок действия и отсутствие в списке отозванных.
+// Возвращает контекст с userID владельца токена.
+func authorize(ctx context.Context, authenticator *auth.Authenticator) (context.Context, error) {
+	// Извлекаем метаданные из контекста.
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing metadata")
+	}
+	authHeaders := md["authorization"]
+	if len(authHeaders) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is not supplied")
+	}
+	parts := strings.Split(authHeaders[0], " ")
+	if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+		return nil, status.Error(codes.Unauthenticated, "invalid authorization format")
+	}
+
+	// Проверяем токен.
+	claims, err := authenticator.ValidateToken(parts[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid token")
+	}
+	revoked, err := authenticator.IsTokenRevoked(claims)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to check token revocation: %v", err)
+		return nil, status.Error(codes.Internal, "failed to check token")
+	}
+	if revoked {
+		return nil, status.Error(codes.Unauthenticated, "token revoked")
+	}
+	return context.WithValue(ctx, auth.ContextKeyUserID, claims.UserID), nil
+}
+
 // grpcServerStreamWithContext оборачивает grpc.ServerStream, позволяя изменять контекст.
 type grpcServerStreamWithContext struct {
 	grpc.ServerStream
@@ -0,0 +1,78 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/andranikuz/gophkeeper/internal/auth"
+)
+
+// fakeRevokedTokenRepo хранит отозванные jti в памяти.
+type fakeRevokedTokenRepo struct {
+	revoked map[string]bool
+}
+
+func (f *fakeRevokedTokenRepo) RevokeToken(jti, userID string, expiresAt time.Time) error {
+	f.revoked[jti] = true
+	return nil
+}
+func (f *fakeRevokedTokenRepo) RevokeUserTokens(userID string, before time.Time) error { return nil }
+func (f *fakeRevokedTokenRepo) IsTokenRevoked(jti, userID string, issuedAt time.Time) (bool, error) {
+	return f.revoked[jti], nil
+}
+func (f *fakeRevokedTokenRepo) PurgeExpiredRevokedTokens(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestAuthorize(t *testing.T) {
+	authenticator := auth.NewAuthenticator("secret", 60).WithDenylist(&fakeRevokedTokenRepo{revoked: map[string]bool{}})
+	token, err := authenticator.GenerateToken("user123", "john_doe")
+	require.NoError(t, err)
+	withToken := func(value string) context.Context {
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", value))
+	}
+
+	ctx, err := authorize(withToken("Bearer "+token), authenticator)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", ctx.Value(auth.ContextKeyUserID))
+
+	// Ошибки авторизации возвращаются с кодом Unauthenticated.
+	for _, ctx := range []context.Context{
+		context.Background(),
+		withToken(token),
+		withToken("Bearer invalid"),
+	} {
+		_, err := authorize(ctx, authenticator)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	}
+
+	// Отозванный токен отклоняется.
+	claims, err := authenticator.ValidateToken(token)
+	require.NoError(t, err)
+	require.NoError(t, authenticator.RevokeToken(claims))
+	_, err = authorize(withToken("Bearer "+token), authenticator)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Contains(t, err.Error(), "revoked")
+}
+
+func TestJwtUnaryInterceptor_HealthIsPublic(t *testing.T) {
+	interceptor := JwtUnaryInterceptor(auth.NewAuthenticator("secret", 60))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	resp, err := interceptor(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(context.Background(), nil,
+		&grpc.UnaryServerInfo{FullMethod: "/filesync.FileSyncService/SyncRecords"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -1,26 +1,35 @@
 package grpcserver
 
 import (
+	"os"
+
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
+	"github.com/andranikuz/gophkeeper/internal/metrics"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
 	"github.com/andranikuz/gophkeeper/pkg/repository"
 	"github.com/andranikuz/gophkeeper/pkg/services"
-	"os"
 )
 
 // fileSyncServiceServer реализует pb.FileSyncServiceServer.
 type fileSyncServiceServer struct {
 	pb.UnimplementedFileSyncServiceServer
-	uploadDir          string                          // Директория для хранения файлов
+	uploadDir          string                          // Директория файлов, загруженных до появления хранилища блобов
 	dataItemRepository repository.DataItemRepository   // Репозиторий data_item
+	blobRepository     repository.BlobRepository       // Манифесты файлов и счётчики ссылок на чанки
+	blobStore          *blobstore.Store                // Хранилище чанков файлов
 	authenticator      services.AuthenticatorInterface // Сервис авторизации
+	metrics            *metrics.Metrics                // Метрики синхронизации и передачи файлов; nil — не собираются
 }
 
 // NewFileSyncServiceServer создаёт новый экземпляр сервиса.
 func NewFileSyncServiceServer(
 	uploadDir string,
 	dataItemRepository repository.DataItemRepository,
+	blobRepository repository.BlobRepository,
+	blobStore *blobstore.Store,
 	authenticator services.AuthenticatorInterface,
+	metrics *metrics.Metrics,
 ) pb.FileSyncServiceServer {
 	// Создаем директорию для загрузок, если её нет.
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
@@ -29,6 +38,9 @@ func NewFileSyncServiceServer(
 	return &fileSyncServiceServer{
 		uploadDir:          uploadDir,
 		dataItemRepository: dataItemRepository,
+		blobRepository:     blobRepository,
+		blobStore:          blobStore,
 		authenticator:      authenticator,
+		metrics:            metrics,
 	}
 }
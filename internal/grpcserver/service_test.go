@@ -3,20 +3,30 @@ package grpcserver
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
 
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
+	"github.com/andranikuz/gophkeeper/internal/migrations"
+	"github.com/andranikuz/gophkeeper/internal/sqlite"
 	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 	"github.com/andranikuz/gophkeeper/pkg/services"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -50,6 +60,28 @@ func (fa *fakeAuthenticator) GenerateToken(userID, username string) (string, err
 	return fmt.Sprintf("token:%s:%s", userID, username), nil
 }
 
+// IssueRefreshToken не используется gRPC-сервисом.
+func (fa *fakeAuthenticator) IssueRefreshToken(userID, username string) (string, error) {
+	return "", nil
+}
+
+// RefreshTokens не используется gRPC-сервисом.
+func (fa *fakeAuthenticator) RefreshTokens(refreshToken string) (*services.TokenPair, error) {
+	return nil, services.ErrInvalidRefreshToken
+}
+
+// RevokeToken не используется gRPC-сервисом.
+func (fa *fakeAuthenticator) RevokeToken(claims *services.Claims) error { return nil }
+
+// RevokeAllTokens не используется gRPC-сервисом.
+func (fa *fakeAuthenticator) RevokeAllTokens(userID string) error { return nil }
+
+// RevokeRefreshToken не используется gRPC-сервисом.
+func (fa *fakeAuthenticator) RevokeRefreshToken(refreshToken string) error { return nil }
+
+// IsTokenRevoked считает все токены действующими.
+func (fa *fakeAuthenticator) IsTokenRevoked(claims *services.Claims) (bool, error) { return false, nil }
+
 // ValidateToken разбирает фиктивный токен и возвращает Claims, если токен корректный.
 func (fa *fakeAuthenticator) ValidateToken(tokenStr string) (*services.Claims, error) {
 	parts := strings.Split(tokenStr, ":")
@@ -70,25 +102,133 @@ func (fa *fakeAuthenticator) ValidateToken(tokenStr string) (*services.Claims, e
 // -------------------------
 // Фиктивный репозиторий (для SyncRecords)
 // -------------------------
+
+// fakeRepository хранит записи в памяти и применяет изменения по тем же правилам, что и репозитории БД.
 type fakeRepository struct {
-	getUserItemsFunc func(userID string) ([]entity.DataItem, error)
-	saveItemsFunc    func(items []entity.DataItem) error
+	items   []entity.DataItem
+	applied [][]entity.DataItem // Принятые изменения каждого вызова ApplyChanges.
 }
 
 func (fr *fakeRepository) GetUserItems(userID string) ([]entity.DataItem, error) {
-	if fr.getUserItemsFunc != nil {
-		return fr.getUserItemsFunc(userID)
+	var items []entity.DataItem
+	for _, item := range fr.items {
+		if item.UserID == userID {
+			items = append(items, item)
+		}
 	}
-	return nil, nil
+	return items, nil
+}
+
+func (fr *fakeRepository) ApplyChanges(userID string, changes []entity.DataItem) ([]entity.DataItem, []string, error) {
+	var revision int64
+	for _, item := range fr.items {
+		if item.UserID == userID && item.Revision > revision {
+			revision = item.Revision
+		}
+	}
+	var accepted []entity.DataItem
+	var rejected []string
+	for _, change := range changes {
+		i := -1
+		for j, item := range fr.items {
+			if item.ID == change.ID {
+				i = j
+			}
+		}
+		if i >= 0 && (fr.items[i].UserID != userID || fr.items[i].Revision != change.BaseRevision) {
+			rejected = append(rejected, change.ID)
+			continue
+		}
+		revision++
+		change.UserID = userID
+		change.Revision = revision
+		change.BaseRevision = 0
+		if i >= 0 {
+			fr.items[i] = change
+		} else {
+			fr.items = append(fr.items, change)
+		}
+		accepted = append(accepted, change)
+	}
+	fr.applied = append(fr.applied, accepted)
+	return accepted, rejected, nil
+}
+
+func (fr *fakeRepository) PurgeDeletedItems(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// -------------------------
+// Фиктивный репозиторий блобов
+// -------------------------
+type fakeBlobRepository struct {
+	blobs          map[string]int // Счётчики ссылок зарегистрированных чанков.
+	manifests      map[string]entity.FileManifest
+	onSaveManifest func() // Вызывается перед сохранением манифеста, чтобы имитировать сборку мусора.
+}
+
+func newFakeBlobRepository() *fakeBlobRepository {
+	return &fakeBlobRepository{blobs: make(map[string]int), manifests: make(map[string]entity.FileManifest)}
 }
 
-func (fr *fakeRepository) SaveItems(items []entity.DataItem) error {
-	if fr.saveItemsFunc != nil {
-		return fr.saveItemsFunc(items)
+func (r *fakeBlobRepository) AddBlob(hash string, size int64) error {
+	if _, ok := r.blobs[hash]; !ok {
+		r.blobs[hash] = 0
 	}
 	return nil
 }
 
+func (r *fakeBlobRepository) MissingBlobs(hashes []string) ([]string, error) {
+	var missing []string
+	for _, hash := range hashes {
+		if _, ok := r.blobs[hash]; !ok {
+			missing = append(missing, hash)
+		}
+	}
+	return missing, nil
+}
+
+func (r *fakeBlobRepository) GetManifest(userID, fileID string) (*entity.FileManifest, error) {
+	manifest, ok := r.manifests[userID+"/"+fileID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &manifest, nil
+}
+
+func (r *fakeBlobRepository) SaveManifest(manifest entity.FileManifest) error {
+	if r.onSaveManifest != nil {
+		r.onSaveManifest()
+	}
+	for _, hash := range manifest.Chunks {
+		if _, ok := r.blobs[hash]; !ok {
+			return repository.ErrNotFound
+		}
+	}
+	r.DeleteManifest(manifest.UserID, manifest.FileID)
+	r.manifests[manifest.UserID+"/"+manifest.FileID] = manifest
+	for _, hash := range manifest.Chunks {
+		r.blobs[hash]++
+	}
+	return nil
+}
+
+func (r *fakeBlobRepository) DeleteManifest(userID, fileID string) error {
+	manifest, ok := r.manifests[userID+"/"+fileID]
+	if !ok {
+		return nil
+	}
+	delete(r.manifests, userID+"/"+fileID)
+	for _, hash := range manifest.Chunks {
+		r.blobs[hash]--
+	}
+	return nil
+}
+
+func (r *fakeBlobRepository) PurgeUnreferencedBlobs(before time.Time, remove func(hash string) error) ([]string, error) {
+	return nil, nil
+}
+
 // -------------------------
 // Фиктивный grpc‑стрим для DownloadFile
 // -------------------------
@@ -102,7 +242,9 @@ func (s *fakeDownloadStream) Send(chunk *pb.FileChunk) error {
 	if s.sendErr != nil {
 		return s.sendErr
 	}
-	s.chunks = append(s.chunks, chunk)
+	// Как и настоящий стрим, сериализуем сообщение при отправке: сервер переиспользует буфер.
+	sent := proto.Clone(chunk).(*pb.FileChunk)
+	s.chunks = append(s.chunks, sent)
 	return nil
 }
 
@@ -142,6 +284,7 @@ func (s *fakeDownloadStream) Trailer() metadata.MD {
 // -------------------------
 type fakeUploadStream struct {
 	chunks []*pb.FileChunk // Чанки, которые вернёт метод Recv.
+	err    error           // Ошибка, которую вернёт Recv после чанков (по умолчанию io.EOF).
 	index  int
 	ctx    context.Context
 	resp   *pb.FileUploadResponse
@@ -149,6 +292,9 @@ type fakeUploadStream struct {
 
 func (s *fakeUploadStream) Recv() (*pb.FileChunk, error) {
 	if s.index >= len(s.chunks) {
+		if s.err != nil {
+			return nil, s.err
+		}
 		return nil, io.EOF
 	}
 	chunk := s.chunks[s.index]
@@ -210,10 +356,11 @@ func TestDownloadFile_Success(t *testing.T) {
 	auth := &fakeAuthenticator{userID: userID}
 
 	// Создаем сервер с нужной uploadDir и фиктивным авторизатором.
+	// Файл загружен до появления хранилища блобов: манифеста у него нет.
 	srv := &fileSyncServiceServer{
-		uploadDir:     tempUploadDir,
-		authenticator: auth,
-		// Репозиторий не используется в DownloadFile, можно оставить nil.
+		uploadDir:      tempUploadDir,
+		authenticator:  auth,
+		blobRepository: newFakeBlobRepository(),
 	}
 
 	// Создаем fakeDownloadStream с контекстом.
@@ -236,6 +383,22 @@ func TestDownloadFile_Success(t *testing.T) {
 		receivedContent.Write(chunk.ChunkData)
 	}
 	assert.Equal(t, fileContent, receivedContent.String())
+	// Первый чанк содержит контрольную сумму и размер всего файла.
+	assert.Equal(t, checksum(fileContent), fakeStream.chunks[0].Sha256)
+	assert.Equal(t, int64(len(fileContent)), fakeStream.chunks[0].Size)
+
+	// Скачивание продолжается с указанного смещения.
+	resumed := &fakeDownloadStream{ctx: context.Background()}
+	require.NoError(t, srv.DownloadFile(&pb.FileDownloadRequest{Id: fileID, Offset: 10}, resumed))
+	receivedContent.Reset()
+	for _, chunk := range resumed.chunks {
+		receivedContent.Write(chunk.ChunkData)
+	}
+	assert.Equal(t, fileContent[10:], receivedContent.String())
+	assert.Equal(t, int64(10), resumed.chunks[0].Offset)
+
+	// Смещение за пределами файла отклоняется.
+	assert.Error(t, srv.DownloadFile(&pb.FileDownloadRequest{Id: fileID, Offset: 100}, &fakeDownloadStream{ctx: context.Background()}))
 }
 
 // -------------------------
@@ -246,18 +409,8 @@ func TestSyncRecords_Success(t *testing.T) {
 	userID := "user123"
 	auth := &fakeAuthenticator{userID: userID}
 
-	// Фиктивный репозиторий.
-	var savedItems []entity.DataItem
-	repo := &fakeRepository{
-		getUserItemsFunc: func(u string) ([]entity.DataItem, error) {
-			// Для теста сервер не имеет записей.
-			return []entity.DataItem{}, nil
-		},
-		saveItemsFunc: func(items []entity.DataItem) error {
-			savedItems = items
-			return nil
-		},
-	}
+	// Фиктивный репозиторий: для теста сервер не имеет записей.
+	repo := &fakeRepository{}
 
 	// Создаем экземпляр сервера.
 	srv := &fileSyncServiceServer{
@@ -288,66 +441,466 @@ func TestSyncRecords_Success(t *testing.T) {
 	assert.Len(t, resp.UploadList, 1)
 	assert.Len(t, resp.DownloadList, 0)
 	// Проверяем, что сохраненные записи совпадают.
+	savedItems, err := repo.GetUserItems(userID)
+	require.NoError(t, err)
 	require.Len(t, savedItems, 1)
 	assert.Equal(t, "item1", savedItems[0].ID)
 	assert.Equal(t, "client content", savedItems[0].Content)
 	assert.Equal(t, userID, savedItems[0].UserID)
+	assert.Equal(t, int64(1), savedItems[0].Revision)
 }
 
 // -------------------------
-// Тест для UploadFile
+// Тест для надгробий в SyncRecords
 // -------------------------
-func TestUploadFile_Success(t *testing.T) {
-	// Создаем временную директорию для загрузок.
-	tempUploadDir, err := os.MkdirTemp("", "uploadDir")
+func TestSyncRecords_TombstoneWins(t *testing.T) {
+	tempUploadDir := t.TempDir()
+	userID := "user123"
+	updatedAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+
+	// На сервере лежит файл и живая запись о нём.
+	require.NoError(t, os.MkdirAll(filepath.Join(tempUploadDir, userID), 0755))
+	filePath := filepath.Join(tempUploadDir, userID, "file1")
+	require.NoError(t, os.WriteFile(filePath, []byte("data"), 0644))
+	serverItems := []entity.DataItem{
+		{ID: "file1", Type: entity.DataTypeBinary, Content: "name", UserID: userID, UpdatedAt: updatedAt},
+		{ID: "text1", Type: entity.DataTypeText, Content: "text", UserID: userID, UpdatedAt: updatedAt},
+	}
+	repo := &fakeRepository{items: serverItems}
+	blobRepo := newFakeBlobRepository()
+	require.NoError(t, blobRepo.AddBlob("chunk", 4))
+	require.NoError(t, blobRepo.SaveManifest(entity.FileManifest{UserID: userID, FileID: "file1", Chunks: []string{"chunk"}}))
+	srv := &fileSyncServiceServer{
+		uploadDir:          tempUploadDir,
+		authenticator:      &fakeAuthenticator{userID: userID},
+		dataItemRepository: repo,
+		blobRepository:     blobRepo,
+	}
+
+	// Клиент удалил файл; запись text1 у клиента отсутствует (ещё не скачана).
+	deletedAt := time.Now().UTC().Format(time.RFC3339)
+	req := &pb.SyncRecordsRequest{
+		Items: []*pb.DataItem{
+			{Id: "file1", Type: int32(entity.DataTypeBinary), UpdatedAt: deletedAt, Deleted: true, DeletedAt: deletedAt},
+		},
+	}
+	resp, err := srv.SyncRecords(context.Background(), req)
 	require.NoError(t, err)
-	defer os.RemoveAll(tempUploadDir)
 
-	// Фиктивный авторизатор, возвращающий userID.
-	userID := "testuser"
-	auth := &fakeAuthenticator{userID: userID}
+	merged := make(map[string]*pb.DataItem)
+	for _, item := range resp.MergedRecords {
+		merged[item.Id] = item
+	}
+	require.Len(t, merged, 2)
+	assert.True(t, merged["file1"].Deleted, "Надгробие должно вытеснить более старую версию")
+	assert.Equal(t, deletedAt, merged["file1"].DeletedAt)
+	assert.False(t, merged["text1"].Deleted)
+	// Надгробие не требует передачи файлов.
+	assert.Len(t, resp.UploadList, 0)
+	require.Len(t, resp.DownloadList, 1)
+	assert.Equal(t, "text1", resp.DownloadList[0].Id)
+	// Сохраняется только принятое изменение.
+	require.Len(t, repo.applied, 1)
+	require.Len(t, repo.applied[0], 1)
+	assert.Equal(t, "file1", repo.applied[0][0].ID)
+
+	// Файл удалённой записи удалён с сервера.
+	_, err = os.Stat(filePath)
+	assert.True(t, os.IsNotExist(err), "Файл удалённой записи должен быть удалён")
+	_, err = blobRepo.GetManifest(userID, "file1")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "Манифест удалённой записи должен быть удалён")
+}
 
-	// Создаем экземпляр сервера.
+func TestSyncRecords_DeltaCursor(t *testing.T) {
+	userID := "user123"
+	repo := &fakeRepository{items: []entity.DataItem{
+		{ID: "item1", Type: entity.DataTypeText, Content: "old", UserID: userID, Revision: 1},
+		{ID: "item2", Type: entity.DataTypeText, Content: "seen", UserID: userID, Revision: 2},
+		{ID: "item3", Type: entity.DataTypeText, Content: "new", UserID: userID, Revision: 3},
+	}}
 	srv := &fileSyncServiceServer{
-		uploadDir:     tempUploadDir,
-		authenticator: auth,
+		authenticator:      &fakeAuthenticator{userID: userID},
+		dataItemRepository: repo,
 	}
 
-	// Формируем набор чанков для эмуляции загрузки файла.
-	// Пусть файл состоит из двух чанков.
-	fileID := "file456"
-	chunk1 := &pb.FileChunk{
-		Id:        fileID,
-		ChunkData: []byte("Hello "),
+	// Клиент уже видел ревизию 2 и изменил item1.
+	req := &pb.SyncRecordsRequest{
+		Cursor: 2,
+		Items: []*pb.DataItem{
+			{Id: "item1", Type: int32(entity.DataTypeText), Content: "edit", UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano), BaseRevision: 1},
+		},
 	}
-	chunk2 := &pb.FileChunk{
-		Id:        fileID,
-		ChunkData: []byte("World!"),
+	resp, err := srv.SyncRecords(context.Background(), req)
+	require.NoError(t, err)
+
+	// Возвращаются только записи, изменённые после курсора.
+	ids := make(map[string]int64)
+	for _, item := range resp.MergedRecords {
+		ids[item.Id] = item.Revision
 	}
-	chunks := []*pb.FileChunk{chunk1, chunk2}
+	assert.Equal(t, map[string]int64{"item1": 4, "item3": 3}, ids)
+	assert.Equal(t, int64(4), resp.Cursor)
+	require.Len(t, resp.DownloadList, 1)
+	assert.Equal(t, "item3", resp.DownloadList[0].Id)
+}
 
-	// Создаем фиктивный uploadStream с подготовленным списком чанков.
-	fakeStream := &fakeUploadStream{
-		chunks: chunks,
-		ctx:    context.Background(),
+func TestMergeDataItems_Revisions(t *testing.T) {
+	serverItems := []entity.DataItem{
+		{ID: "1", Content: "v1", Revision: 1},
+		{ID: "2", Content: "v3", Revision: 3},
 	}
+	clientItems := []entity.DataItem{
+		// Изменение на основе актуальной ревизии принимается.
+		{ID: "1", Content: "v1-edit", BaseRevision: 1},
+		// Изменение на основе устаревшей ревизии – конфликт.
+		{ID: "2", Content: "v2-edit", BaseRevision: 2},
+		// Новая запись принимается.
+		{ID: "3", Content: "new"},
+		// Неизменённая копия игнорируется.
+		{ID: "4", Content: "synced", Revision: 2},
+	}
+
+	changes, conflicts := mergeDataItems(serverItems, clientItems)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "v1-edit", changes[0].Content)
+	assert.Equal(t, int64(1), changes[0].BaseRevision, "Хранилище проверит, что ревизия записи не изменилась")
+	assert.Equal(t, "3", changes[1].ID)
+	// Конфликтующая запись остаётся в серверной версии.
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "v3", conflicts[0].Content)
+
+	// Изменение записи, которой на сервере нет, применяется как новая запись.
+	changes, conflicts = mergeDataItems(nil, []entity.DataItem{{ID: "5", Content: "purged", BaseRevision: 7}})
+	require.Len(t, changes, 1)
+	assert.Equal(t, int64(0), changes[0].BaseRevision)
+	assert.Empty(t, conflicts)
+
+	// Одинаковые надгробия с разных устройств конфликтом не считаются.
+	serverItems = []entity.DataItem{{ID: "1", Deleted: true, Revision: 2}}
+	clientItems = []entity.DataItem{{ID: "1", Deleted: true, BaseRevision: 1}}
+	changes, conflicts = mergeDataItems(serverItems, clientItems)
+	assert.Empty(t, changes)
+	assert.Empty(t, conflicts)
+}
 
-	// Вызываем UploadFile.
-	err = srv.UploadFile(fakeStream)
+func TestSyncRecords_Conflict(t *testing.T) {
+	userID := "user123"
+	repo := &fakeRepository{items: []entity.DataItem{
+		{ID: "item1", Type: entity.DataTypeText, Content: "server edit", UserID: userID, Revision: 2},
+	}}
+	srv := &fileSyncServiceServer{
+		authenticator:      &fakeAuthenticator{userID: userID},
+		dataItemRepository: repo,
+	}
+
+	// Два изменения в пределах одной секунды: клиент редактировал ревизию 1, сервер уже на ревизии 2.
+	now := time.Now().UTC()
+	req := &pb.SyncRecordsRequest{
+		Items: []*pb.DataItem{
+			{Id: "item1", Type: int32(entity.DataTypeText), Content: "client edit", UpdatedAt: now.Format(time.RFC3339Nano), BaseRevision: 1},
+		},
+	}
+	resp, err := srv.SyncRecords(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, resp.Conflicts, 1)
+	assert.Equal(t, "server edit", resp.Conflicts[0].Content)
+	assert.Equal(t, int64(2), resp.Conflicts[0].Revision)
+	assert.Empty(t, resp.UploadList)
+	// Локальное изменение клиента не перезаписывается скачиванием.
+	assert.Empty(t, resp.DownloadList)
+	assert.Empty(t, repo.applied, "Конфликтующее изменение не должно сохраняться")
+}
+
+// barrierRepository задерживает первые parties чтений записей, пока их не выполнят все параллельные
+// синхронизации, чтобы каждая из них сравнивала изменения клиента с одним и тем же снимком.
+type barrierRepository struct {
+	repository.DataItemRepository
+	parties int32
+	reads   atomic.Int32
+	barrier sync.WaitGroup
+}
+
+func (r *barrierRepository) GetUserItems(userID string) ([]entity.DataItem, error) {
+	items, err := r.DataItemRepository.GetUserItems(userID)
+	if r.reads.Add(1) <= r.parties {
+		r.barrier.Done()
+		r.barrier.Wait()
+	}
+	return items, err
+}
+
+// openTestDB создаёт базу SQLite во временной директории и применяет к ней миграции.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.New(db, migrations.SQLite)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db
+}
+
+func TestSyncRecords_ConcurrentChanges(t *testing.T) {
+	items, err := sqlite.NewDataItemRepository(openTestDB(t))
+	require.NoError(t, err)
+
+	userID := "user123"
+	_, _, err = items.ApplyChanges(userID, []entity.DataItem{{ID: "item1", Type: entity.DataTypeText, Content: "base", UpdatedAt: time.Now()}})
 	require.NoError(t, err)
-	require.NotNil(t, fakeStream.resp)
-	// Проверяем, что в ответе указано fileID и успех.
-	assert.Equal(t, fileID, fakeStream.resp.Id)
-	assert.True(t, fakeStream.resp.Success)
-	// Проверяем, что файл перемещен в директорию uploadDir/userID с именем fileID.
-	finalPath := filepath.Join(tempUploadDir, userID, fileID)
-	// Убеждаемся, что файл существует.
-	_, err = os.Stat(finalPath)
-	require.NoError(t, err, "Файл должен быть перемещен в итоговую директорию")
-	// Считываем содержимое файла и сверяем.
-	data, err := os.ReadFile(finalPath)
+	repo := &barrierRepository{DataItemRepository: items, parties: 2}
+	repo.barrier.Add(2)
+	srv := &fileSyncServiceServer{
+		authenticator:      &fakeAuthenticator{userID: userID},
+		dataItemRepository: repo,
+	}
+
+	// Два устройства одновременно редактируют ревизию 1 одной записи.
+	contents := []string{"edit A", "edit B"}
+	responses := make([]*pb.SyncRecordsResponse, len(contents))
+	errs := make([]error, len(contents))
+	var wg sync.WaitGroup
+	for i, content := range contents {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = srv.SyncRecords(context.Background(), &pb.SyncRecordsRequest{
+				Cursor: 1,
+				Items: []*pb.DataItem{
+					{Id: "item1", Type: int32(entity.DataTypeText), Content: content, UpdatedAt: time.Now().UTC().Format(time.RFC3339Nano), BaseRevision: 1},
+				},
+			})
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+
+	// Принимается ровно одно изменение, второе устройство получает его как конфликт.
+	winner, loser := 0, 1
+	if len(responses[0].Conflicts) > 0 {
+		winner, loser = 1, 0
+	}
+	assert.Empty(t, responses[winner].Conflicts)
+	assert.Len(t, responses[winner].UploadList, 1)
+	require.Len(t, responses[loser].Conflicts, 1)
+	assert.Equal(t, contents[winner], responses[loser].Conflicts[0].Content)
+	assert.Equal(t, int64(2), responses[loser].Conflicts[0].Revision)
+	assert.Empty(t, responses[loser].UploadList)
+	assert.Equal(t, int64(2), responses[loser].Cursor)
+
+	got, err := items.GetUserItems(userID)
 	require.NoError(t, err)
-	expectedContent := "Hello World!"
-	// Удаляем возможные лишние пробелы или символы переноса строк.
-	assert.Equal(t, expectedContent, strings.TrimSpace(string(data)))
+	require.Len(t, got, 1)
+	assert.Equal(t, contents[winner], got[0].Content)
+	assert.Equal(t, int64(2), got[0].Revision)
+}
+
+// -------------------------
+// Тесты для UploadFile
+// -------------------------
+
+// newBlobServer создаёт сервер с хранилищем блобов во временной директории.
+func newBlobServer(t *testing.T, userID string) (*fileSyncServiceServer, *fakeBlobRepository) {
+	t.Helper()
+	storage, err := blobstore.NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+	store := blobstore.New(storage)
+	blobRepo := newFakeBlobRepository()
+	return &fileSyncServiceServer{
+		uploadDir:      t.TempDir(),
+		authenticator:  &fakeAuthenticator{userID: userID},
+		blobRepository: blobRepo,
+		blobStore:      store,
+	}, blobRepo
+}
+
+// uploadStream формирует поток загрузки файла из частей: описание файла и указанные чанки.
+func uploadStream(fileID string, parts []string, send ...int) *fakeUploadStream {
+	var hashes []string
+	for _, part := range parts {
+		hashes = append(hashes, checksum(part))
+	}
+	whole := strings.Join(parts, "")
+	chunks := []*pb.FileChunk{{Id: fileID, Sha256: checksum(whole), Size: int64(len(whole)), Chunks: hashes}}
+	for _, i := range send {
+		chunks = append(chunks, &pb.FileChunk{Id: fileID, ChunkHash: hashes[i], ChunkData: []byte(parts[i])})
+	}
+	return &fakeUploadStream{chunks: chunks, ctx: context.Background()}
+}
+
+// downloadAll скачивает файл целиком и возвращает его содержимое.
+func downloadAll(t *testing.T, srv *fileSyncServiceServer, fileID string) string {
+	t.Helper()
+	stream := &fakeDownloadStream{ctx: context.Background()}
+	require.NoError(t, srv.DownloadFile(&pb.FileDownloadRequest{Id: fileID}, stream))
+	var buf bytes.Buffer
+	for _, chunk := range stream.chunks {
+		buf.Write(chunk.ChunkData)
+	}
+	return buf.String()
+}
+
+func TestUploadFile_Success(t *testing.T) {
+	userID := "testuser"
+	srv, blobRepo := newBlobServer(t, userID)
+
+	// Файл состоит из двух чанков.
+	fileID := "file456"
+	stream := uploadStream(fileID, []string{"Hello ", "World!"}, 0, 1)
+	require.NoError(t, srv.UploadFile(stream))
+	require.NotNil(t, stream.resp)
+	assert.Equal(t, fileID, stream.resp.Id)
+	assert.True(t, stream.resp.Success)
+
+	// Файл собирается из чанков при скачивании.
+	assert.Equal(t, "Hello World!", downloadAll(t, srv, fileID))
+	manifest, err := blobRepo.GetManifest(userID, fileID)
+	require.NoError(t, err)
+	assert.Equal(t, checksum("Hello World!"), manifest.SHA256)
+}
+
+func TestUploadFile_Dedup(t *testing.T) {
+	userID := "testuser"
+	srv, blobRepo := newBlobServer(t, userID)
+	parts := []string{"Hello ", "World!"}
+	require.NoError(t, srv.UploadFile(uploadStream("file1", parts, 0, 1)))
+
+	// Сервер сообщает, что все чанки уже есть.
+	missing, err := srv.GetMissingChunks(context.Background(), &pb.MissingChunksRequest{Hashes: []string{checksum("Hello "), checksum("World!"), checksum("new")}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{checksum("new")}, missing.Missing)
+
+	// Второй файл с тем же содержимым загружается без передачи чанков и хранится один раз.
+	stream := uploadStream("file2", parts)
+	require.NoError(t, srv.UploadFile(stream))
+	assert.True(t, stream.resp.Success)
+	assert.Equal(t, "Hello World!", downloadAll(t, srv, "file2"))
+	assert.Equal(t, 2, blobRepo.blobs[checksum("Hello ")], "На чанк ссылаются оба файла")
+
+	// Удаление одного файла оставляет чанки второму.
+	require.NoError(t, blobRepo.DeleteManifest(userID, "file1"))
+	assert.Equal(t, 1, blobRepo.blobs[checksum("Hello ")])
+	assert.Equal(t, "Hello World!", downloadAll(t, srv, "file2"))
+}
+
+func TestUploadFile_Resume(t *testing.T) {
+	userID := "testuser"
+	srv, _ := newBlobServer(t, userID)
+	fileID := "file456"
+	parts := []string{"Hello ", "World!"}
+
+	// Первая попытка обрывается после первого чанка: чанк сохраняется, файл — нет.
+	broken := uploadStream(fileID, parts, 0)
+	broken.err = errors.New("connection reset")
+	require.Error(t, srv.UploadFile(broken))
+	missing, err := srv.GetMissingChunks(context.Background(), &pb.MissingChunksRequest{Hashes: []string{checksum("Hello "), checksum("World!")}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{checksum("World!")}, missing.Missing)
+	assert.Error(t, srv.DownloadFile(&pb.FileDownloadRequest{Id: fileID}, &fakeDownloadStream{ctx: context.Background()}),
+		"Незавершённая загрузка не должна становиться файлом")
+
+	// Поток без недостающего чанка не завершает загрузку.
+	incomplete := uploadStream(fileID, parts)
+	require.NoError(t, srv.UploadFile(incomplete))
+	assert.False(t, incomplete.resp.Success)
+
+	// Вторая попытка догружает только недостающий чанк.
+	resumed := uploadStream(fileID, parts, 1)
+	require.NoError(t, srv.UploadFile(resumed))
+	assert.True(t, resumed.resp.Success)
+	assert.Equal(t, "Hello World!", downloadAll(t, srv, fileID))
+}
+
+func TestUploadFile_ChunkPurgedBeforeSave(t *testing.T) {
+	userID := "testuser"
+	srv, blobRepo := newBlobServer(t, userID)
+	fileID := "file456"
+	parts := []string{"Hello ", "World!"}
+
+	// Сборщик мусора удаляет чанк между проверкой и сохранением манифеста.
+	blobRepo.onSaveManifest = func() { delete(blobRepo.blobs, checksum("World!")) }
+	stream := uploadStream(fileID, parts, 0, 1)
+	require.NoError(t, srv.UploadFile(stream))
+	assert.False(t, stream.resp.Success)
+	assert.Empty(t, blobRepo.manifests, "Манифест не должен ссылаться на удалённый чанк")
+
+	// Клиент догружает удалённый чанк и завершает загрузку.
+	blobRepo.onSaveManifest = nil
+	resumed := uploadStream(fileID, parts, 1)
+	require.NoError(t, srv.UploadFile(resumed))
+	assert.True(t, resumed.resp.Success)
+	assert.Equal(t, "Hello World!", downloadAll(t, srv, fileID))
+}
+
+func TestUploadFile_DuringBlobCollection(t *testing.T) {
+	db := openTestDB(t)
+	blobRepo, err := sqlite.NewBlobRepository(db)
+	require.NoError(t, err)
+	storage, err := blobstore.NewFSStorage(t.TempDir())
+	require.NoError(t, err)
+	store := blobstore.New(storage)
+	srv := &fileSyncServiceServer{
+		uploadDir:      t.TempDir(),
+		authenticator:  &fakeAuthenticator{userID: "testuser"},
+		blobRepository: blobRepo,
+		blobStore:      store,
+	}
+
+	// Чанк остался от давней незавершённой загрузки, и его удаляет сборщик мусора.
+	data := "Hello World!"
+	hash := checksum(data)
+	require.NoError(t, blobRepo.AddBlob(hash, int64(len(data))))
+	require.NoError(t, store.Put(hash, []byte(data)))
+	_, err = db.Exec(`UPDATE blobs SET updated_at = ?;`, time.Now().Add(-48*time.Hour).UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+
+	// Клиент загружает файл из того же чанка между удалением записи и удалением данных.
+	stream := uploadStream("file1", []string{data}, 0)
+	uploaded := make(chan error, 1)
+	purged, err := blobRepo.PurgeUnreferencedBlobs(time.Now().Add(-time.Hour), func(hash string) error {
+		go func() { uploaded <- srv.UploadFile(stream) }()
+		// Даём загрузке дойти до регистрации чанка.
+		time.Sleep(100 * time.Millisecond)
+		return store.Delete(hash)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{hash}, purged)
+
+	// Загрузка дождалась сборщика мусора и сохранила данные заново.
+	require.NoError(t, <-uploaded)
+	assert.True(t, stream.resp.Success)
+	assert.Equal(t, data, downloadAll(t, srv, "file1"))
+}
+
+func TestUploadFile_ChecksumMismatch(t *testing.T) {
+	userID := "testuser"
+	srv, blobRepo := newBlobServer(t, userID)
+	fileID := "file456"
+
+	// Данные чанка не соответствуют его хэшу.
+	stream := uploadStream(fileID, []string{"original"})
+	stream.chunks = append(stream.chunks, &pb.FileChunk{Id: fileID, ChunkHash: checksum("original"), ChunkData: []byte("corrupted")})
+	require.Error(t, srv.UploadFile(stream))
+	assert.Empty(t, blobRepo.blobs, "Повреждённый чанк не должен сохраняться")
+
+	// Контрольная сумма собранного файла не совпадает с заявленной.
+	stream = uploadStream(fileID, []string{"data"}, 0)
+	stream.chunks[0].Sha256 = checksum("other")
+	require.Error(t, srv.UploadFile(stream))
+	assert.Empty(t, blobRepo.manifests)
+
+	// Загрузка без контрольной суммы отклоняется.
+	stream = &fakeUploadStream{
+		chunks: []*pb.FileChunk{{Id: fileID}},
+		ctx:    context.Background(),
+	}
+	assert.Error(t, srv.UploadFile(stream))
+}
+
+// checksum возвращает SHA-256 строки в hex.
+func checksum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
 }
@@ -10,46 +10,90 @@ import (
 	"github.com/andranikuz/gophkeeper/pkg/logger"
 )
 
-// SyncRecords принимает от клиента массив записей, объединяет их с данными из хранилища,
-// определяет какие файлы нужно загрузить с клиента и какие скачать с сервера,
-// сохраняет объединённый список в хранилище и возвращает его вместе с массивами для загрузки.
+// SyncRecords принимает от клиента записи, изменённые с последней синхронизации, применяет их к данным
+// из хранилища, определяет какие файлы нужно загрузить с клиента и какие скачать с сервера,
+// и возвращает записи, изменённые после курсора клиента, вместе с массивами для загрузки,
+// списком конфликтов и новым курсором.
 func (s *fileSyncServiceServer) SyncRecords(ctx context.Context, req *pb.SyncRecordsRequest) (*pb.SyncRecordsResponse, error) {
 	// 1. Извлекаем записи от клиента и записи с сервера.
-	clientItems, serverItems, err := s.extractClientAndServerItems(ctx, req)
+	userID, clientItems, serverItems, err := s.extractClientAndServerItems(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract items: %w", err)
 	}
 
-	// 2. Объединяем записи.
-	mergedItems := mergeDataItems(serverItems, clientItems)
+	// 2. Отбираем изменения клиента, основанные на актуальных серверных версиях.
+	changes, conflicts := mergeDataItems(serverItems, clientItems)
+
+	// 3. Применяем изменения в БД. Хранилище повторно проверяет ревизии в транзакции: пока шли шаги 1–2,
+	// записи могла изменить параллельная синхронизация другого устройства.
+	mergedItems := serverItems
+	var acceptedItems []entity.DataItem
+	if len(changes) > 0 {
+		var rejected []string
+		acceptedItems, rejected, err = s.dataItemRepository.ApplyChanges(userID, changes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply changes: %w", err)
+		}
+		// Перечитываем записи, чтобы ответ и курсор включали изменения параллельных синхронизаций.
+		mergedItems, err = s.dataItemRepository.GetUserItems(userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server items: %w", err)
+		}
+		conflicts = append(conflicts, rejectedConflicts(mergedItems, changes, rejected)...)
+	}
 
-	// 3. Вычисляем списки файлов для загрузки/скачивания.
-	uploadList, downloadList := computeSyncLists(clientItems, serverItems)
+	// 4. Отбираем записи, изменённые после курсора клиента: остальные у клиента уже есть.
+	changedItems, cursor := changesSince(mergedItems, clientItems, req.Cursor)
 
-	// 4. Сохраняем объединённый список в БД.
-	if err := s.dataItemRepository.SaveItems(mergedItems); err != nil {
-		return nil, fmt.Errorf("failed to save merged items: %w", err)
-	}
+	// 5. Вычисляем списки файлов для загрузки/скачивания.
+	uploadList, downloadList := computeSyncLists(clientItems, changedItems, acceptedItems)
+
+	// 6. Удаляем файлы записей, которые стали надгробиями.
+	s.removeDeletedFiles(serverItems, acceptedItems)
 
-	// 5. Формируем и возвращаем ответ.
+	// 7. Формируем и возвращаем ответ.
 	resp := &pb.SyncRecordsResponse{
 		UploadList:    dataItemsToProto(uploadList),
 		DownloadList:  dataItemsToProto(downloadList),
-		MergedRecords: dataItemsToProto(mergedItems),
+		MergedRecords: dataItemsToProto(changedItems),
+		Conflicts:     dataItemsToProto(conflicts),
+		Cursor:        cursor,
 	}
 
-	logger.InfoLogger.Printf("SyncRecords: merged %d items; upload: %d, download: %d",
-		len(mergedItems), len(uploadList), len(downloadList))
+	s.metrics.ObserveSync(len(changedItems), len(uploadList), len(downloadList))
+	logger.InfoLogger.Printf("SyncRecords: cursor %d -> %d, changed %d items; accepted: %d, conflicts: %d, upload: %d, download: %d",
+		req.Cursor, cursor, len(changedItems), len(acceptedItems), len(conflicts), len(uploadList), len(downloadList))
 	return resp, nil
 }
 
+// changesSince возвращает записи с ревизией больше cursor и новый курсор — максимальную ревизию записей.
+// Записи, присланные клиентом, возвращаются всегда, чтобы клиент получил их актуальную ревизию.
+// Нулевой курсор означает полную синхронизацию: возвращаются все записи, включая созданные
+// до появления ревизий.
+func changesSince(items, clientItems []entity.DataItem, cursor int64) (changed []entity.DataItem, newCursor int64) {
+	sent := make(map[string]bool)
+	for _, item := range clientItems {
+		sent[item.ID] = true
+	}
+	newCursor = cursor
+	for _, item := range items {
+		if cursor == 0 || item.Revision > cursor || sent[item.ID] {
+			changed = append(changed, item)
+		}
+		if item.Revision > newCursor {
+			newCursor = item.Revision
+		}
+	}
+	return changed, newCursor
+}
+
 // extractClientAndServerItems извлекает userID из контекста, преобразует записи, полученные от клиента,
 // и получает записи пользователя из БД.
-func (s *fileSyncServiceServer) extractClientAndServerItems(ctx context.Context, req *pb.SyncRecordsRequest) (clientItems, serverItems []entity.DataItem, err error) {
+func (s *fileSyncServiceServer) extractClientAndServerItems(ctx context.Context, req *pb.SyncRecordsRequest) (userID string, clientItems, serverItems []entity.DataItem, err error) {
 	// Извлекаем userID из контекста.
-	userID, err := s.authenticator.GetUserIdFromCtx(ctx)
+	userID, err = s.authenticator.GetUserIdFromCtx(ctx)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get userID: %w", err)
+		return "", nil, nil, fmt.Errorf("failed to get userID: %w", err)
 	}
 
 	// Преобразуем записи, полученные от клиента, в объекты entity.DataItem, устанавливая userID.
@@ -58,33 +102,36 @@ func (s *fileSyncServiceServer) extractClientAndServerItems(ctx context.Context,
 	// Получаем серверные записи для этого пользователя.
 	serverItems, err = s.dataItemRepository.GetUserItems(userID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get server items: %w", err)
+		return "", nil, nil, fmt.Errorf("failed to get server items: %w", err)
 	}
-	return clientItems, serverItems, nil
+	return userID, clientItems, serverItems, nil
 }
 
 // computeSyncLists вычисляет, какие файлы нужно загрузить с клиента (uploadList)
-// и какие файлы нужно скачать с сервера (downloadList) на основе сравнений записей.
-func computeSyncLists(clientItems, serverItems []entity.DataItem) (uploadList, downloadList []entity.DataItem) {
+// и какие файлы нужно скачать с сервера (downloadList) на основе ревизий записей.
+// mergedItems содержит только записи, которых у клиента ещё нет в актуальной версии.
+func computeSyncLists(clientItems, mergedItems, acceptedItems []entity.DataItem) (uploadList, downloadList []entity.DataItem) {
 	// Создаем маппы для быстрого поиска.
 	clientMap := make(map[string]entity.DataItem)
-	serverMap := make(map[string]entity.DataItem)
+	acceptedMap := make(map[string]bool)
 	for _, item := range clientItems {
 		clientMap[item.ID] = item
 	}
-	for _, item := range serverItems {
-		serverMap[item.ID] = item
-	}
-
-	// Если запись есть у клиента и либо отсутствует на сервере, либо версия от клиента новее – upload.
-	for id, cItem := range clientMap {
-		if sItem, ok := serverMap[id]; !ok || cItem.UpdatedAt.After(sItem.UpdatedAt) {
-			uploadList = append(uploadList, cItem)
+	// Принятые изменения клиента – upload. Для надгробий передавать нечего.
+	for _, item := range acceptedItems {
+		acceptedMap[item.ID] = true
+		if !item.Deleted {
+			uploadList = append(uploadList, item)
 		}
 	}
-	// Если запись есть на сервере и либо отсутствует у клиента, либо серверная версия новее – download.
-	for id, sItem := range serverMap {
-		if cItem, ok := clientMap[id]; !ok || sItem.UpdatedAt.After(cItem.UpdatedAt) {
+	// Если запись есть на сервере и либо отсутствует у клиента, либо у клиента более старая
+	// ревизия без локальных изменений – download.
+	for _, sItem := range mergedItems {
+		if sItem.Deleted || acceptedMap[sItem.ID] {
+			continue
+		}
+		cItem, ok := clientMap[sItem.ID]
+		if !ok || (!cItem.IsModified() && cItem.Revision < sItem.Revision) {
 			downloadList = append(downloadList, sItem)
 		}
 	}
@@ -97,15 +144,22 @@ func computeSyncLists(clientItems, serverItems []entity.DataItem) (uploadList, d
 func protoToDataItems(pbItems []*pb.DataItem, userID string) []entity.DataItem {
 	var items []entity.DataItem
 	for _, pbItem := range pbItems {
-		t, _ := time.Parse(time.RFC3339, pbItem.UpdatedAt)
-		items = append(items, entity.DataItem{
-			ID:        pbItem.Id,
-			Type:      entity.DataType(pbItem.Type),
-			Content:   pbItem.Content,
-			Meta:      pbItem.Meta,
-			UserID:    userID,
-			UpdatedAt: t,
-		})
+		t, _ := time.Parse(time.RFC3339Nano, pbItem.UpdatedAt)
+		item := entity.DataItem{
+			ID:           pbItem.Id,
+			Type:         entity.DataType(pbItem.Type),
+			Content:      pbItem.Content,
+			Meta:         pbItem.Meta,
+			UserID:       userID,
+			UpdatedAt:    t,
+			Deleted:      pbItem.Deleted,
+			Revision:     pbItem.Revision,
+			BaseRevision: pbItem.BaseRevision,
+		}
+		if pbItem.DeletedAt != "" {
+			item.DeletedAt, _ = time.Parse(time.RFC3339Nano, pbItem.DeletedAt)
+		}
+		items = append(items, item)
 	}
 	return items
 }
@@ -114,37 +168,79 @@ func protoToDataItems(pbItems []*pb.DataItem, userID string) []entity.DataItem {
 func dataItemsToProto(items []entity.DataItem) []*pb.DataItem {
 	var pbItems []*pb.DataItem
 	for _, item := range items {
-		pbItems = append(pbItems, &pb.DataItem{
-			Id:        item.ID,
-			Type:      int32(item.Type),
-			Content:   item.Content,
-			Meta:      item.Meta,
-			UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
-		})
+		pbItem := &pb.DataItem{
+			Id:           item.ID,
+			Type:         int32(item.Type),
+			Content:      item.Content,
+			Meta:         item.Meta,
+			UpdatedAt:    item.UpdatedAt.Format(time.RFC3339Nano),
+			Deleted:      item.Deleted,
+			Revision:     item.Revision,
+			BaseRevision: item.BaseRevision,
+		}
+		if !item.DeletedAt.IsZero() {
+			pbItem.DeletedAt = item.DeletedAt.Format(time.RFC3339Nano)
+		}
+		pbItems = append(pbItems, pbItem)
 	}
 	return pbItems
 }
 
-// mergeDataItems объединяет два среза записей по принципу "последнее обновление выигрывает".
-func mergeDataItems(serverItems, clientItems []entity.DataItem) []entity.DataItem {
-	mergedMap := make(map[string]entity.DataItem)
-	// Сначала кладем все серверные записи.
+// mergeDataItems отбирает изменения клиента, которые можно применить к серверным записям.
+// Изменением считается клиентская запись без ревизии. Оно применяется, если основано на текущей
+// серверной ревизии записи; для записи, которой на сервере нет, базовая ревизия сбрасывается в ноль.
+// Если запись на сервере успела измениться, изменения конкурентны: серверная версия остаётся без
+// изменений и возвращается в списке конфликтов. Надгробия участвуют в слиянии как обычные изменения.
+// Ревизии назначает хранилище при применении изменений.
+func mergeDataItems(serverItems, clientItems []entity.DataItem) (changes, conflicts []entity.DataItem) {
+	serverMap := make(map[string]entity.DataItem)
 	for _, item := range serverItems {
-		mergedMap[item.ID] = item
+		serverMap[item.ID] = item
 	}
-	// Затем перебираем клиентские записи.
 	for _, item := range clientItems {
-		if exist, ok := mergedMap[item.ID]; ok {
-			if item.UpdatedAt.After(exist.UpdatedAt) {
-				mergedMap[item.ID] = item
+		if !item.IsModified() {
+			continue
+		}
+		sItem, exists := serverMap[item.ID]
+		if !exists {
+			item.BaseRevision = 0
+		} else if sItem.Revision != item.BaseRevision {
+			// Одинаковое изменение на двух устройствах (например, двойное удаление) конфликтом не считается.
+			if !sameContent(sItem, item) {
+				conflicts = append(conflicts, sItem)
 			}
-		} else {
-			mergedMap[item.ID] = item
+			continue
 		}
+		changes = append(changes, item)
 	}
-	var merged []entity.DataItem
-	for _, item := range mergedMap {
-		merged = append(merged, item)
+	return changes, conflicts
+}
+
+// rejectedConflicts возвращает текущие серверные версии изменений, отклонённых хранилищем.
+// Записи, которых среди items нет, принадлежат другому пользователю и в ответ не попадают.
+func rejectedConflicts(items, changes []entity.DataItem, rejected []string) (conflicts []entity.DataItem) {
+	itemMap := make(map[string]entity.DataItem)
+	for _, item := range items {
+		itemMap[item.ID] = item
+	}
+	changeMap := make(map[string]entity.DataItem)
+	for _, item := range changes {
+		changeMap[item.ID] = item
+	}
+	for _, id := range rejected {
+		sItem, ok := itemMap[id]
+		if !ok {
+			logger.ErrorLogger.Printf("SyncRecords: rejected change of item %s owned by another user", id)
+			continue
+		}
+		if !sameContent(sItem, changeMap[id]) {
+			conflicts = append(conflicts, sItem)
+		}
 	}
-	return merged
+	return conflicts
+}
+
+// sameContent сообщает, совпадает ли содержимое двух версий записи.
+func sameContent(a, b entity.DataItem) bool {
+	return a.Type == b.Type && a.Content == b.Content && a.Meta == b.Meta && a.Deleted == b.Deleted
 }
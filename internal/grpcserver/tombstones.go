@@ -0,0 +1,33 @@
+package grpcserver
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+)
+
+// removeDeletedFiles удаляет файлы записей, которые стали надгробиями в результате принятых изменений.
+// Манифест файла удаляется, а его чанки освобождаются и удаляются сборщиком мусора, если на них
+// больше нет ссылок.
+func (s *fileSyncServiceServer) removeDeletedFiles(serverItems, acceptedItems []entity.DataItem) {
+	alreadyDeleted := make(map[string]bool)
+	for _, item := range serverItems {
+		if item.Deleted {
+			alreadyDeleted[item.ID] = true
+		}
+	}
+	for _, item := range acceptedItems {
+		if !item.Deleted || item.Type != entity.DataTypeBinary || alreadyDeleted[item.ID] {
+			continue
+		}
+		if err := s.blobRepository.DeleteManifest(item.UserID, item.ID); err != nil {
+			logger.ErrorLogger.Printf("Failed to remove manifest of deleted file %s: %v", item.ID, err)
+		}
+		filePath := filepath.Join(s.uploadDir, item.UserID, item.ID)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			logger.ErrorLogger.Printf("Failed to remove deleted file %s: %v", filePath, err)
+		}
+	}
+}
@@ -1,67 +1,155 @@
 package grpcserver
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 )
 
-// UploadFile принимает поток чанков файла от клиента и сохраняет файл.
+// maxChunkSize — максимальный размер чанка, принимаемого от клиента.
+const maxChunkSize = 2 << 20
+
+// UploadFile принимает от клиента описание файла и чанки, которых ещё нет в хранилище блобов.
+// Каждый чанк сохраняется сразу после получения, поэтому прерванная загрузка продолжается
+// догрузкой недостающих чанков. Файл становится доступен только после того, как все его чанки
+// есть на сервере и контрольная сумма собранного файла совпала с заявленной.
 func (s *fileSyncServiceServer) UploadFile(stream pb.FileSyncService_UploadFileServer) error {
 	userID, err := s.authenticator.GetUserIdFromCtx(stream.Context())
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
-	var fileID string
-	// Создаем временный файл.
-	tmpFile, err := os.CreateTemp(s.uploadDir, "upload-*")
+
+	// Первое сообщение описывает файл: контрольную сумму, размер и список чанков.
+	header, err := stream.Recv()
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		return err
+	}
+	if !blobstore.ValidHash(header.Sha256) {
+		return status.Error(codes.InvalidArgument, "sha256 of the file must be provided")
+	}
+	expected := make(map[string]bool)
+	for _, hash := range header.Chunks {
+		if !blobstore.ValidHash(hash) {
+			return status.Errorf(codes.InvalidArgument, "invalid chunk hash %q", hash)
+		}
+		expected[hash] = true
 	}
-	defer tmpFile.Close()
 
-	logger.InfoLogger.Printf("UploadFile: receiving file into %s", tmpFile.Name())
+	logger.InfoLogger.Printf("UploadFile: receiving file %s (%d chunks)", header.Id, len(header.Chunks))
+	received := 0
 	for {
 		chunk, err := stream.Recv()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
+			// Принятые чанки уже сохранены и будут пропущены при следующей попытке.
 			return err
 		}
-		// При первом чанке сохраняем fileID.
-		if fileID == "" {
-			fileID = chunk.Id
+		if !expected[chunk.ChunkHash] {
+			return status.Errorf(codes.InvalidArgument, "chunk %s is not part of file %s", chunk.ChunkHash, header.Id)
 		}
-		if _, err := tmpFile.Write(chunk.ChunkData); err != nil {
-			return fmt.Errorf("failed to write chunk: %w", err)
+		if len(chunk.ChunkData) > maxChunkSize {
+			return status.Errorf(codes.InvalidArgument, "chunk %s exceeds %d bytes", chunk.ChunkHash, maxChunkSize)
 		}
+		if !blobstore.MatchesHash(chunk.ChunkHash, chunk.ChunkData) {
+			return status.Errorf(codes.DataLoss, "chunk %s is corrupted", chunk.ChunkHash)
+		}
+		// Чанк регистрируется до записи данных: если его как раз удаляет сборщик мусора,
+		// AddBlob дождётся удаления, и данные будут записаны уже после него.
+		if err := s.blobRepository.AddBlob(chunk.ChunkHash, int64(len(chunk.ChunkData))); err != nil {
+			return fmt.Errorf("failed to register chunk: %w", err)
+		}
+		if err := s.blobStore.Put(chunk.ChunkHash, chunk.ChunkData); err != nil {
+			return fmt.Errorf("failed to save chunk: %w", err)
+		}
+		received++
+		s.metrics.UploadedBytes(len(chunk.ChunkData))
 	}
-	// Закрываем файл перед переименованием.
-	if err := tmpFile.Close(); err != nil {
-		return err
+
+	missing, err := s.blobRepository.MissingBlobs(header.Chunks)
+	if err != nil {
+		return fmt.Errorf("failed to check chunks: %w", err)
+	}
+	if len(missing) > 0 {
+		return stream.SendAndClose(&pb.FileUploadResponse{
+			Id:      header.Id,
+			Success: false,
+			Message: fmt.Sprintf("upload incomplete: %d chunks missing", len(missing)),
+		})
 	}
 
-	uploadDir := s.uploadDir + `/` + userID
-	if err := os.MkdirAll(uploadDir, 0755); err != nil {
-		logger.ErrorLogger.Printf("Failed to create upload directory: %v", err)
+	// Проверяем файл, собранный из чанков, целиком.
+	r := s.blobStore.NewReader(header.Chunks)
+	h := sha256.New()
+	size, err := io.Copy(h, r)
+	r.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read chunks: %w", err)
+	}
+	if size != header.Size || hex.EncodeToString(h.Sum(nil)) != header.Sha256 {
+		return status.Errorf(codes.DataLoss, "checksum mismatch for file %s", header.Id)
 	}
 
-	// Переименовываем файл в окончательное имя.
-	finalPath := filepath.Join(uploadDir, fileID)
-	if err := os.Rename(tmpFile.Name(), finalPath); err != nil {
-		return fmt.Errorf("failed to rename file: %w", err)
+	err = s.blobRepository.SaveManifest(entity.FileManifest{
+		UserID: userID,
+		FileID: header.Id,
+		SHA256: header.Sha256,
+		Size:   size,
+		Chunks: header.Chunks,
+	})
+	if errors.Is(err, repository.ErrNotFound) {
+		// Чанк удалил сборщик мусора после проверки; клиент повторит загрузку недостающих чанков.
+		return stream.SendAndClose(&pb.FileUploadResponse{
+			Id:      header.Id,
+			Success: false,
+			Message: "upload incomplete: chunk removed, retry",
+		})
 	}
-	logger.InfoLogger.Printf("UploadFile: file %s saved as %s", fileID, finalPath)
+	if err != nil {
+		return fmt.Errorf("failed to save manifest: %w", err)
+	}
+	// Файл, загруженный до появления хранилища блобов, заменён новой версией.
+	if err := os.Remove(filepath.Join(s.uploadDir, userID, header.Id)); err != nil && !os.IsNotExist(err) {
+		logger.ErrorLogger.Printf("Failed to remove legacy file %s: %v", header.Id, err)
+	}
+	logger.InfoLogger.Printf("UploadFile: file %s saved, %d of %d chunks transferred", header.Id, received, len(header.Chunks))
 
 	resp := &pb.FileUploadResponse{
-		Id:      fileID,
+		Id:      header.Id,
 		Success: true,
-		Message: fmt.Sprintf("File uploaded successfully, saved at %s", finalPath),
+		Message: "File uploaded successfully",
 	}
 	return stream.SendAndClose(resp)
 }
+
+// GetMissingChunks возвращает чанки из списка, которых ещё нет в хранилище блобов.
+func (s *fileSyncServiceServer) GetMissingChunks(ctx context.Context, req *pb.MissingChunksRequest) (*pb.MissingChunksResponse, error) {
+	if _, err := s.authenticator.GetUserIdFromCtx(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	for _, hash := range req.Hashes {
+		if !blobstore.ValidHash(hash) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid chunk hash %q", hash)
+		}
+	}
+	missing, err := s.blobRepository.MissingBlobs(req.Hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check chunks: %w", err)
+	}
+	return &pb.MissingChunksResponse{Missing: missing}, nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// DeleteAccount безвозвратно удаляет учётную запись после подтверждения паролем.
+// Сначала отзываются все токены пользователя, чтобы ни одно устройство не успело
+// синхронизировать данные в удаляемую учётную запись, затем удаляются записи и файлы.
+func (h *Handler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if h.Account == nil {
+		http.Error(w, "Account deletion is not available", http.StatusNotImplemented)
+		return
+	}
+	var req struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		http.Error(w, "Password is required", http.StatusBadRequest)
+		return
+	}
+	claims := claimsFromContext(r.Context())
+	if !h.passwordCheckAllowed(w, claims.Username) {
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to get user: %v", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.passwordCheckFailed(w, claims.Username, "Invalid password")
+		return
+	}
+	h.LoginLockout.Reset(claims.Username)
+
+	if err := h.Authenticator.RevokeAllTokens(user.ID); err != nil {
+		logger.ErrorLogger.Printf("Failed to revoke user tokens before account deletion: %v", err)
+		http.Error(w, "Failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Account.DeleteAccount(user.ID); err != nil {
+		logger.ErrorLogger.Printf("Failed to delete account: %v", err)
+		http.Error(w, "Failed to delete account", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoLogger.Printf("Account deleted: %s", user.Username)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Account deleted successfully",
+	})
+}
+
+// ExportAccount отдаёт архив tar.gz со всеми данными, которые сервер хранит о пользователе.
+// Архив передаётся потоково; если ошибка возникла после начала передачи, соединение обрывается,
+// чтобы клиент не принял неполный архив за целый.
+func (h *Handler) ExportAccount(w http.ResponseWriter, r *http.Request) {
+	if h.Account == nil {
+		http.Error(w, "Account export is not available", http.StatusNotImplemented)
+		return
+	}
+	claims := claimsFromContext(r.Context())
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", `attachment; filename="gophkeeper-export.tar.gz"`)
+	cw := &countingWriter{w: w}
+	if err := h.Account.ExportAccount(r.Context(), claims.UserID, cw); err != nil {
+		logger.ErrorLogger.Printf("Failed to export account %s: %v", claims.UserID, err)
+		if cw.n > 0 {
+			panic(http.ErrAbortHandler)
+		}
+		w.Header().Del("Content-Disposition")
+		http.Error(w, "Failed to export account", http.StatusInternalServerError)
+		return
+	}
+	logger.InfoLogger.Printf("Account exported: %s (%d bytes)", claims.Username, cw.n)
+}
+
+// countingWriter считает записанные байты, чтобы понять, начата ли уже передача ответа.
+type countingWriter struct {
+	w http.ResponseWriter
+	n int64
+}
+
+// Write передаёт данные в ответ и учитывает их размер.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
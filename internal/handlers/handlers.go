@@ -1,7 +1,14 @@
 package handlers
 
 import (
+	"net/http"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/internal/healthcheck"
+	"github.com/andranikuz/gophkeeper/internal/metrics"
+	"github.com/andranikuz/gophkeeper/internal/ratelimit"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/policy"
 	"github.com/andranikuz/gophkeeper/pkg/repository"
 	"github.com/andranikuz/gophkeeper/pkg/services"
 
@@ -13,6 +20,22 @@ type Handler struct {
 	DataItemRepo  repository.DataItemRepository
 	UserRepo      repository.UserRepository
 	Authenticator services.AuthenticatorInterface
+	// TwoFactor — двухфакторная аутентификация; если не задана, вход выполняется только по паролю.
+	TwoFactor services.TwoFactorInterface
+	// Account — удаление и выгрузка учётной записи; если не задан, эти маршруты возвращают 501.
+	Account services.AccountInterface
+	// RateLimiter ограничивает частоту запросов к публичным маршрутам с одного IP-адреса.
+	RateLimiter *ratelimit.Limiter
+	// LoginLockout блокирует вход в учётную запись после серии неудачных попыток.
+	LoginLockout *ratelimit.Lockout
+	// Policy — требования к имени пользователя и паролю при регистрации и смене пароля.
+	Policy policy.Policy
+	// Readiness — проверки зависимостей для /readyz; если не заданы, сервер считается готовым.
+	Readiness *healthcheck.Checker
+	// ReadinessMaxAge — насколько старым может быть результат проверок, отдаваемый /readyz.
+	ReadinessMaxAge time.Duration
+	// Metrics — метрики Prometheus; если не заданы, маршрут /metrics не регистрируется.
+	Metrics *metrics.Metrics
 }
 
 // NewHandler создаёт новый Handler.
@@ -25,15 +48,33 @@ func NewHandler(
 		DataItemRepo:  dataItemRepo,
 		UserRepo:      userRepo,
 		Authenticator: authenticator,
+		Policy:        policy.Default(),
 	}
 }
 
 // RegisterRoutes регистрирует маршруты с использованием chi и применяет middleware авторизации.
 func (h *Handler) RegisterRoutes() chi.Router {
 	r := chi.NewRouter()
-	// Публичные маршруты.
-	r.Post("/register", h.Register)
-	r.Post("/login", h.Login)
+	r.Use(h.Metrics.Middleware)
+	// Проверки состояния и метрики для балансировщика, оркестратора и Prometheus: без авторизации и ограничения частоты.
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+	if h.Metrics != nil {
+		r.Method(http.MethodGet, "/metrics", h.Metrics.Handler())
+	}
+	// Публичные маршруты. Частота запросов к ним ограничена: иначе пароли можно перебирать
+	// с разных имён пользователей, обходя блокировку учётной записи.
+	r.With(h.RateLimiter.Middleware).Post("/register", h.Register)
+	r.With(h.RateLimiter.Middleware).Post("/login", h.Login)
+	r.With(h.RateLimiter.Middleware).Post("/token/refresh", h.RefreshToken)
+	// Маршруты, требующие авторизации.
+	r.With(h.RequireAuth).Post("/logout", h.Logout)
+	// Смена пароля и удаление учётной записи проверяют пароль, поэтому ограничены так же, как вход.
+	r.With(h.RateLimiter.Middleware, h.RequireAuth).Post("/password", h.ChangePassword)
+	r.With(h.RateLimiter.Middleware, h.RequireAuth).Delete("/account", h.DeleteAccount)
+	r.With(h.RequireAuth).Get("/account/export", h.ExportAccount)
+	r.With(h.RequireAuth).Post("/2fa/enroll", h.EnrollTwoFactor)
+	r.With(h.RequireAuth).Post("/2fa/verify", h.VerifyTwoFactor)
 	logger.InfoLogger.Println("Routes registered successfully")
 
 	return r
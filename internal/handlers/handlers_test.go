@@ -4,14 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/andranikuz/gophkeeper/internal/handlers"
+	"github.com/andranikuz/gophkeeper/internal/healthcheck"
+	"github.com/andranikuz/gophkeeper/internal/metrics"
+	"github.com/andranikuz/gophkeeper/internal/ratelimit"
 	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 	"github.com/andranikuz/gophkeeper/pkg/services"
+	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/crypto/bcrypt"
@@ -21,6 +28,8 @@ import (
 type fakeUserRepo struct {
 	GetUserByUsernameFunc func(username string) (*entity.User, error)
 	SaveUserFunc          func(user entity.User) error
+	// users — пользователи для GetUserByID и UpdatePassword.
+	users map[string]*entity.User
 }
 
 func (f *fakeUserRepo) GetUserByUsername(username string) (*entity.User, error) {
@@ -37,9 +46,31 @@ func (f *fakeUserRepo) SaveUser(user entity.User) error {
 	return nil
 }
 
+func (f *fakeUserRepo) GetUserByID(id string) (*entity.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeUserRepo) UpdatePassword(userID, passwordHash string) error {
+	user, ok := f.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.Password = passwordHash
+	return nil
+}
+
 // fakeAuthenticator реализует интерфейс services.AuthenticatorInterface.
 type fakeAuthenticator struct {
 	token string
+	// refreshTokens — действующие токены обновления и ID их владельцев.
+	refreshTokens map[string]string
+	// revoked — отозванные токены; revokedUsers — пользователи, вышедшие на всех устройствах.
+	revoked      map[string]bool
+	revokedUsers map[string]bool
 }
 
 func (fa *fakeAuthenticator) GenerateToken(userID, username string) (string, error) {
@@ -56,13 +87,60 @@ func (fa *fakeAuthenticator) CheckPasswordHash(password, hash string) bool {
 }
 
 func (fa *fakeAuthenticator) ValidateToken(tokenStr string) (*services.Claims, error) {
-	// Минимальная реализация для тестов
+	// Минимальная реализация для тестов: сам токен служит его идентификатором.
 	return &services.Claims{
-		UserID:   "dummy",
-		Username: "dummy",
+		UserID:         "dummy",
+		Username:       "dummy",
+		StandardClaims: jwt.StandardClaims{Id: tokenStr},
 	}, nil
 }
 
+func (fa *fakeAuthenticator) RevokeToken(claims *services.Claims) error {
+	if fa.revoked == nil {
+		fa.revoked = make(map[string]bool)
+	}
+	fa.revoked[claims.Id] = true
+	return nil
+}
+
+func (fa *fakeAuthenticator) RevokeAllTokens(userID string) error {
+	if fa.revokedUsers == nil {
+		fa.revokedUsers = make(map[string]bool)
+	}
+	fa.revokedUsers[userID] = true
+	return nil
+}
+
+func (fa *fakeAuthenticator) RevokeRefreshToken(refreshToken string) error {
+	delete(fa.refreshTokens, refreshToken)
+	return nil
+}
+
+func (fa *fakeAuthenticator) IsTokenRevoked(claims *services.Claims) (bool, error) {
+	return fa.revoked[claims.Id] || fa.revokedUsers[claims.UserID], nil
+}
+
+func (fa *fakeAuthenticator) IssueRefreshToken(userID, username string) (string, error) {
+	if fa.refreshTokens == nil {
+		fa.refreshTokens = make(map[string]string)
+	}
+	token := "refresh:" + userID
+	fa.refreshTokens[token] = userID
+	return token, nil
+}
+
+func (fa *fakeAuthenticator) RefreshTokens(refreshToken string) (*services.TokenPair, error) {
+	userID, ok := fa.refreshTokens[refreshToken]
+	if !ok {
+		return nil, services.ErrInvalidRefreshToken
+	}
+	// Предъявленный токен отзывается и заменяется новым.
+	delete(fa.refreshTokens, refreshToken)
+	next := refreshToken + "+"
+	fa.refreshTokens[next] = userID
+	return &services.TokenPair{UserID: userID, AccessToken: fa.token, RefreshToken: next}, nil
+}
+
 func (fa *fakeAuthenticator) GetUserIdFromCtx(ctx context.Context) (string, error) {
 	return "dummy", nil
 }
@@ -120,6 +198,7 @@ func TestLogin_Success(t *testing.T) {
 
 	assert.Equal(t, "testtoken", respData["token"])
 	assert.Equal(t, "user123", respData["user_id"])
+	assert.Equal(t, "refresh:user123", respData["refresh_token"])
 }
 
 func TestLogin_InvalidCredentials(t *testing.T) {
@@ -166,6 +245,84 @@ func TestLogin_InvalidCredentials(t *testing.T) {
 	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 }
 
+func TestLogin_Lockout(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	testUser := &entity.User{ID: "user123", Username: "testuser", Password: string(hashed)}
+	fakeRepo := &fakeUserRepo{
+		GetUserByUsernameFunc: func(username string) (*entity.User, error) {
+			if username == "testuser" {
+				return testUser, nil
+			}
+			return nil, repository.ErrNotFound
+		},
+	}
+	h := handlers.NewHandler(nil, fakeRepo, &fakeAuthenticator{token: "testtoken"})
+	h.LoginLockout = ratelimit.NewLockout(2, time.Minute, time.Hour)
+
+	login := func(username, password string) *http.Response {
+		body, err := json.Marshal(map[string]string{"username": username, "password": password})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.Login(rec, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body)))
+		return rec.Result()
+	}
+	readBody := func(res *http.Response) string {
+		defer res.Body.Close()
+		data, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	// Неизвестное имя и неверный пароль неразличимы.
+	res := login("nobody", "password123")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	unknownBody := readBody(res)
+	res = login("testuser", "wrongpassword")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, unknownBody, readBody(res))
+
+	// После второй неудачи вход блокируется даже с верным паролем.
+	res = login("testuser", "wrongpassword")
+	readBody(res)
+	res = login("testuser", "password123")
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+	readBody(res)
+
+	// Несуществующие учётные записи блокируются так же.
+	res = login("nobody", "password123")
+	readBody(res)
+	res = login("nobody", "password123")
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	readBody(res)
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, &fakeAuthenticator{token: "testtoken"})
+	h.RateLimiter = ratelimit.NewLimiter(1, 1)
+	router := h.RegisterRoutes()
+
+	register := func(addr string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader([]byte(`{}`)))
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+	res := register("10.0.0.1:1000")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	res = register("10.0.0.1:1001")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.NotEmpty(t, res.Header.Get("Retry-After"))
+	// Лимит считается для каждого адреса отдельно.
+	res = register("10.0.0.2:1000")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
 func TestRegister_Success(t *testing.T) {
 	var savedUser entity.User
 	fakeRepo := &fakeUserRepo{
@@ -230,3 +387,498 @@ func TestRegister_MissingFields(t *testing.T) {
 	// Ожидаем статус 400 Bad Request.
 	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 }
+
+func TestRegister_Validation(t *testing.T) {
+	saved := map[string]bool{"taken": true}
+	fakeRepo := &fakeUserRepo{
+		SaveUserFunc: func(user entity.User) error {
+			if saved[user.Username] {
+				return repository.ErrUsernameTaken
+			}
+			saved[user.Username] = true
+			return nil
+		},
+	}
+	h := handlers.NewHandler(nil, fakeRepo, &fakeAuthenticator{token: "testtoken"})
+
+	register := func(username, password string) *http.Response {
+		body, err := json.Marshal(map[string]string{"username": username, "password": password})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.Register(rec, httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(body)))
+		return rec.Result()
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		status   int
+	}{
+		{"короткое имя", "ab", "correct-horse-battery", http.StatusBadRequest},
+		{"недопустимые символы в имени", "new user", "correct-horse-battery", http.StatusBadRequest},
+		{"короткий пароль", "newuser", "Xy7!", http.StatusBadRequest},
+		{"предсказуемый пароль", "newuser", "12345678", http.StatusBadRequest},
+		{"пароль содержит имя", "newuser", "newuser-2024!", http.StatusBadRequest},
+		{"занятое имя", "taken", "correct-horse-battery", http.StatusConflict},
+		{"успешная регистрация", "newuser", "correct-horse-battery", http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := register(tt.username, tt.password)
+			defer res.Body.Close()
+			assert.Equal(t, tt.status, res.StatusCode)
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	fakeAuth := &fakeAuthenticator{token: "newtoken"}
+	refreshToken, err := fakeAuth.IssueRefreshToken("user123", "testuser")
+	require.NoError(t, err)
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, fakeAuth)
+
+	refresh := func(token string) *http.Response {
+		body, err := json.Marshal(map[string]string{"refresh_token": token})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/token/refresh", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.RefreshToken(rec, req)
+		return rec.Result()
+	}
+
+	res := refresh(refreshToken)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var respData map[string]string
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&respData))
+	assert.Equal(t, "newtoken", respData["token"])
+	assert.Equal(t, "user123", respData["user_id"])
+	assert.NotEmpty(t, respData["refresh_token"])
+	assert.NotEqual(t, refreshToken, respData["refresh_token"])
+
+	// Использованный токен больше не принимается.
+	res = refresh(refreshToken)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Пустой токен — ошибка запроса.
+	res = refresh("")
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+}
+
+func TestLogout(t *testing.T) {
+	fakeAuth := &fakeAuthenticator{token: "testtoken"}
+	refreshToken, err := fakeAuth.IssueRefreshToken("dummy", "dummy")
+	require.NoError(t, err)
+	router := handlers.NewHandler(nil, &fakeUserRepo{}, fakeAuth).RegisterRoutes()
+
+	logout := func(token string, payload map[string]any) *http.Response {
+		var body []byte
+		if payload != nil {
+			var err error
+			body, err = json.Marshal(payload)
+			require.NoError(t, err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/logout", bytes.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	// Без токена выход невозможен.
+	res := logout("", nil)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Выход отзывает токен запроса и токен обновления.
+	res = logout("token1", map[string]any{"refresh_token": refreshToken})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, fakeAuth.revoked["token1"])
+	assert.Empty(t, fakeAuth.refreshTokens)
+
+	// Отозванный токен больше не принимается.
+	res = logout("token1", nil)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	// Выход на всех устройствах отзывает все токены пользователя.
+	res = logout("token2", map[string]any{"all_devices": true})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.True(t, fakeAuth.revokedUsers["dummy"])
+	res = logout("token3", nil)
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+}
+
+// fakeTwoFactor реализует интерфейс services.TwoFactorInterface: действующий код — "123456".
+type fakeTwoFactor struct {
+	enabled map[string]bool
+}
+
+func (ft *fakeTwoFactor) Enroll(userID, username string) (*services.TOTPEnrollment, error) {
+	if ft.enabled[userID] {
+		return nil, services.ErrTwoFactorEnabled
+	}
+	return &services.TOTPEnrollment{Secret: "SECRET", URI: "otpauth://totp/GophKeeper:" + username + "?secret=SECRET"}, nil
+}
+
+func (ft *fakeTwoFactor) Confirm(userID, code string) ([]string, error) {
+	if code != "123456" {
+		return nil, services.ErrInvalidOTP
+	}
+	ft.enabled[userID] = true
+	return []string{"aaaa-bbbb"}, nil
+}
+
+func (ft *fakeTwoFactor) Enabled(userID string) (bool, error) {
+	return ft.enabled[userID], nil
+}
+
+func (ft *fakeTwoFactor) Verify(userID, code string) error {
+	if code != "123456" {
+		return services.ErrInvalidOTP
+	}
+	return nil
+}
+
+func TestLogin_TwoFactor(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	fakeRepo := &fakeUserRepo{
+		GetUserByUsernameFunc: func(username string) (*entity.User, error) {
+			return &entity.User{ID: "user123", Username: username, Password: string(hashed)}, nil
+		},
+	}
+	h := handlers.NewHandler(nil, fakeRepo, &fakeAuthenticator{token: "testtoken"})
+	h.TwoFactor = &fakeTwoFactor{enabled: map[string]bool{"user123": true}}
+
+	login := func(otp string) (*http.Response, map[string]any) {
+		body, err := json.Marshal(map[string]string{"username": "testuser", "password": "password123", "otp": otp})
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.Login(rec, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body)))
+		res := rec.Result()
+		var respData map[string]any
+		json.NewDecoder(res.Body).Decode(&respData)
+		res.Body.Close()
+		return res, respData
+	}
+
+	// Без кода сервер запрашивает второй фактор.
+	res, respData := login("")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.Equal(t, true, respData["two_factor_required"])
+
+	res, _ = login("000000")
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+	res, respData = login("123456")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "testtoken", respData["token"])
+}
+
+func TestTwoFactorEnrollment(t *testing.T) {
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, &fakeAuthenticator{token: "testtoken"})
+	h.TwoFactor = &fakeTwoFactor{enabled: map[string]bool{}}
+	router := h.RegisterRoutes()
+
+	post := func(path string, payload map[string]string) (*http.Response, map[string]any) {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		res := rec.Result()
+		var respData map[string]any
+		json.NewDecoder(res.Body).Decode(&respData)
+		res.Body.Close()
+		return res, respData
+	}
+
+	res, respData := post("/2fa/enroll", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "SECRET", respData["secret"])
+	assert.Equal(t, "otpauth://totp/GophKeeper:dummy?secret=SECRET", respData["otpauth_uri"])
+
+	res, _ = post("/2fa/verify", map[string]string{"code": "000000"})
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, respData = post("/2fa/verify", map[string]string{"code": "123456"})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []any{"aaaa-bbbb"}, respData["recovery_codes"])
+
+	// Повторно подключить включённый второй фактор нельзя.
+	res, _ = post("/2fa/enroll", nil)
+	assert.Equal(t, http.StatusConflict, res.StatusCode)
+}
+
+func TestChangePassword(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("old-password"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	user := &entity.User{ID: "dummy", Username: "dummy", Password: string(hashed)}
+	fakeAuth := &fakeAuthenticator{token: "newtoken"}
+	router := handlers.NewHandler(nil, &fakeUserRepo{users: map[string]*entity.User{"dummy": user}}, fakeAuth).RegisterRoutes()
+
+	changePassword := func(payload map[string]string) (*http.Response, map[string]string) {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/password", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		res := rec.Result()
+		var respData map[string]string
+		json.NewDecoder(res.Body).Decode(&respData)
+		res.Body.Close()
+		return res, respData
+	}
+
+	res, _ := changePassword(map[string]string{"old_password": "old-password"})
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	res, _ = changePassword(map[string]string{"old_password": "wrong", "new_password": "new-password"})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Empty(t, fakeAuth.revokedUsers)
+
+	// Новый пароль должен соответствовать тем же требованиям, что и при регистрации.
+	res, _ = changePassword(map[string]string{"old_password": "old-password", "new_password": "12345678"})
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.Empty(t, fakeAuth.revokedUsers)
+
+	res, respData := changePassword(map[string]string{"old_password": "old-password", "new_password": "new-password"})
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.Password), []byte("new-password")))
+	// Сессии на других устройствах отозваны, текущий клиент получил новую пару токенов.
+	assert.True(t, fakeAuth.revokedUsers["dummy"])
+	assert.Equal(t, "newtoken", respData["token"])
+	assert.Equal(t, "refresh:dummy", respData["refresh_token"])
+}
+
+// fakeAccount реализует интерфейс services.AccountInterface.
+type fakeAccount struct {
+	deleted []string
+	export  string
+}
+
+func (fa *fakeAccount) DeleteAccount(userID string) error {
+	fa.deleted = append(fa.deleted, userID)
+	return nil
+}
+
+func (fa *fakeAccount) ExportAccount(ctx context.Context, userID string, w io.Writer) error {
+	_, err := io.WriteString(w, fa.export+userID)
+	return err
+}
+
+func TestDeleteAccount(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.DefaultCost)
+	require.NoError(t, err)
+	users := &fakeUserRepo{users: map[string]*entity.User{"dummy": {ID: "dummy", Username: "dummy", Password: string(hashed)}}}
+	fakeAuth := &fakeAuthenticator{token: "testtoken"}
+	h := handlers.NewHandler(nil, users, fakeAuth)
+	router := h.RegisterRoutes()
+
+	deleteAccount := func(payload map[string]string) *http.Response {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodDelete, "/account", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	// Без сервиса учётных записей маршрут недоступен.
+	res := deleteAccount(map[string]string{"password": "password123"})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusNotImplemented, res.StatusCode)
+
+	account := &fakeAccount{}
+	h.Account = account
+	res = deleteAccount(map[string]string{"password": "wrong"})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	assert.Empty(t, account.deleted)
+
+	res = deleteAccount(map[string]string{"password": "password123"})
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []string{"dummy"}, account.deleted)
+	assert.True(t, fakeAuth.revokedUsers["dummy"])
+}
+
+func TestPasswordChecks_Lockout(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &entity.User{ID: "dummy", Username: "dummy", Password: string(hashed)}
+	h := handlers.NewHandler(nil, &fakeUserRepo{users: map[string]*entity.User{"dummy": user}}, &fakeAuthenticator{token: "testtoken"})
+	account := &fakeAccount{}
+	h.Account = account
+	h.LoginLockout = ratelimit.NewLockout(2, time.Minute, time.Hour)
+	router := h.RegisterRoutes()
+
+	send := func(method, path string, payload map[string]string) *http.Response {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		req := httptest.NewRequest(method, path, bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		res := rec.Result()
+		res.Body.Close()
+		return res
+	}
+
+	// Неверные пароли при смене пароля и удалении учётной записи считаются вместе.
+	res := send(http.MethodPost, "/password", map[string]string{"old_password": "wrong", "new_password": "new-password"})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	res = send(http.MethodDelete, "/account", map[string]string{"password": "wrong"})
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// После блокировки не принимается даже верный пароль.
+	res = send(http.MethodDelete, "/account", map[string]string{"password": "password123"})
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+	assert.Empty(t, account.deleted)
+	res = send(http.MethodPost, "/password", map[string]string{"old_password": "password123", "new_password": "new-password"})
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+
+	// Блокировка общая со входом.
+	body, err := json.Marshal(map[string]string{"username": "dummy", "password": "password123"})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body)))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+}
+
+func TestPasswordChecks_RateLimit(t *testing.T) {
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, &fakeAuthenticator{token: "testtoken"})
+	h.RateLimiter = ratelimit.NewLimiter(1, 1)
+	router := h.RegisterRoutes()
+
+	// Каждый маршрут проверяется со своего адреса, чтобы лимиты не смешивались.
+	for _, route := range []struct{ method, path, addr string }{
+		{http.MethodPost, "/password", "10.0.0.1"},
+		{http.MethodDelete, "/account", "10.0.0.2"},
+	} {
+		send := func(addr string) *http.Response {
+			req := httptest.NewRequest(route.method, route.path, bytes.NewReader([]byte(`{}`)))
+			req.Header.Set("Authorization", "Bearer token1")
+			req.RemoteAddr = addr
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			return rec.Result()
+		}
+		res := send(route.addr + ":1000")
+		res.Body.Close()
+		assert.NotEqual(t, http.StatusTooManyRequests, res.StatusCode, route.path)
+		res = send(route.addr + ":1001")
+		res.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode, route.path)
+	}
+}
+
+func TestExportAccount(t *testing.T) {
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, &fakeAuthenticator{token: "testtoken"})
+	h.Account = &fakeAccount{export: "archive of "}
+	router := h.RegisterRoutes()
+
+	req := httptest.NewRequest(http.MethodGet, "/account/export", nil)
+	req.Header.Set("Authorization", "Bearer token1")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	res := rec.Result()
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "application/gzip", res.Header.Get("Content-Type"))
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "archive of dummy", string(body))
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	h := handlers.NewHandler(nil, &fakeUserRepo{}, &fakeAuthenticator{})
+	storageErr := error(nil)
+	h.Readiness = healthcheck.NewChecker(time.Second)
+	h.Readiness.Add("database", func(ctx context.Context) error { return nil })
+	h.Readiness.Add("storage", func(ctx context.Context) error { return storageErr })
+	router := h.RegisterRoutes()
+
+	get := func(path string) (*http.Response, map[string]any) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		res := rec.Result()
+		defer res.Body.Close()
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res, body
+	}
+
+	// Маршруты доступны без токена.
+	res, body := get("/healthz")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "ok", body["status"])
+
+	res, body = get("/readyz")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, map[string]any{"database": "ok", "storage": "ok"}, body["checks"])
+
+	// Недоступное хранилище делает сервер неготовым, но не влияет на liveness; текст ошибки не раскрывается.
+	storageErr = errors.New("permission denied: /var/lib/gophkeeper/blobs")
+	res, body = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "fail", body["status"])
+	assert.Equal(t, map[string]any{"database": "ok", "storage": "fail"}, body["checks"])
+	res, _ = get("/healthz")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Пока результат не устарел, /readyz не запускает проверки заново.
+	h.ReadinessMaxAge = time.Hour
+	storageErr = nil
+	res, _ = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+}
+
+func TestMetrics(t *testing.T) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+	fakeRepo := &fakeUserRepo{
+		GetUserByUsernameFunc: func(username string) (*entity.User, error) {
+			return &entity.User{ID: "user123", Username: username, Password: string(hashed)}, nil
+		},
+	}
+	h := handlers.NewHandler(nil, fakeRepo, &fakeAuthenticator{token: "testtoken"})
+	h.LoginLockout = ratelimit.NewLockout(1, time.Minute, time.Hour)
+	h.Metrics = metrics.New()
+	router := h.RegisterRoutes()
+
+	do := func(method, path string, payload any) int {
+		body, err := json.Marshal(payload)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, bytes.NewReader(body)))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/login", map[string]string{"username": "alice", "password": "password123"}))
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "/login", map[string]string{"username": "bob", "password": "wrong"}))
+	assert.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "/login", map[string]string{"username": "bob", "password": "password123"}))
+
+	// /metrics доступен без токена.
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, result := range []string{"success", "failure", "locked"} {
+		assert.Contains(t, body, `gophkeeper_login_attempts_total{result="`+result+`"} 1`)
+	}
+	assert.Contains(t, body, `gophkeeper_http_requests_total{code="401",method="POST",route="/login"} 1`)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+)
+
+// Healthz отвечает 200, пока процесс сервера работает. Зависимости не проверяются:
+// их недоступность не лечится перезапуском сервера.
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Readyz сообщает результат проверок базы данных и хранилища файлов. Проверки не запускаются на
+// каждый запрос: отдаётся результат не старше ReadinessMaxAge, который обычно обновляет Checker.Watch.
+// Если хотя бы одна проверка не пройдена, возвращается 503, и балансировщик перестаёт направлять
+// запросы на этот экземпляр. Текст ошибок пишется только в лог сервера.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	status := "ok"
+	checks := make(map[string]string)
+	if h.Readiness != nil {
+		for name, err := range h.Readiness.Latest(r.Context(), h.ReadinessMaxAge) {
+			checks[name] = "ok"
+			if err != nil {
+				logger.ErrorLogger.Printf("Readiness check %s failed: %v", name, err)
+				checks[name] = "fail"
+				status = "fail"
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(map[string]any{"status": status, "checks": checks})
+}
@@ -2,19 +2,35 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
-	"github.com/andranikuz/gophkeeper/pkg/logger"
 	"golang.org/x/crypto/bcrypt"
+
+	"github.com/andranikuz/gophkeeper/internal/metrics"
+	"github.com/andranikuz/gophkeeper/internal/ratelimit"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+	"github.com/andranikuz/gophkeeper/pkg/services"
 )
 
+// dummyPasswordHash — bcrypt-хэш случайной строки. С ним сверяется пароль, если пользователя нет,
+// чтобы время ответа не выдавало, зарегистрировано ли имя.
+const dummyPasswordHash = "$2a$10$2ygV3V8rIiLvvfX8C3TwoOWFFP2WnCNE3SP30Q/94yNDlovSzaziS"
+
 // Login реализует аутентификацию пользователя.
 // При успешном логине генерируется JWT-токен и возвращается вместе с userID.
+// Если у пользователя включена двухфакторная аутентификация, без поля otp возвращается
+// 401 с two_factor_required: клиент повторяет запрос с кодом TOTP или кодом восстановления.
+// Неизвестное имя и неверный пароль дают одинаковый ответ 401, а после серии неудачных попыток
+// вход в учётную запись блокируется (429) независимо от того, существует ли она.
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Декодируем JSON-пейлоад запроса, содержащий имя пользователя и пароль.
 	var req struct {
 		Username string `json:"username"`
 		Password string `json:"password"`
+		OTP      string `json:"otp"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
@@ -25,20 +41,36 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Username and password are required", http.StatusBadRequest)
 		return
 	}
+	if wait := h.LoginLockout.Locked(req.Username); wait > 0 {
+		h.Metrics.LoginAttempt(metrics.LoginLocked)
+		ratelimit.TooManyRequests(w, wait, "Too many failed login attempts")
+		return
+	}
 
 	// Получаем пользователя из базы по имени.
 	user, err := h.UserRepo.GetUserByUsername(req.Username)
-	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		logger.ErrorLogger.Printf("Failed to get user: %v", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
 		return
 	}
+	passwordHash := dummyPasswordHash
+	if user != nil {
+		passwordHash = user.Password
+	}
 
 	// Сравниваем хэшированный пароль пользователя с введённым.
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		http.Error(w, "Invalid credentials", http.StatusUnauthorized)
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil || user == nil {
+		h.loginFailed(w, req.Username, "Invalid credentials")
 		return
 	}
 
+	if !h.checkSecondFactor(w, user, req.OTP) {
+		return
+	}
+	h.LoginLockout.Reset(req.Username)
+	h.Metrics.LoginAttempt(metrics.LoginSuccess)
+
 	// Генерируем JWT-токен с информацией о пользователе.
 	// Токен содержит user.ID, user.Username и срок действия (например, 24 часа).
 	token, err := h.Authenticator.GenerateToken(user.ID, user.Username)
@@ -46,12 +78,68 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
 		return
 	}
+	// Токен обновления позволяет клиенту продлевать сессию без повторного ввода пароля.
+	refreshToken, err := h.Authenticator.IssueRefreshToken(user.ID, user.Username)
+	if err != nil {
+		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		return
+	}
 	// Логируем успешный вход пользователя.
 	logger.InfoLogger.Printf("User logged in: %s", user.Username)
 
-	// Отправляем клиенту JSON-ответ с токеном и userID.
-	json.NewEncoder(w).Encode(map[string]string{
+	// Отправляем клиенту JSON-ответ с токенами и userID.
+	resp := map[string]string{
 		"token":   token,
 		"user_id": user.ID,
-	})
+	}
+	if refreshToken != "" {
+		resp["refresh_token"] = refreshToken
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// checkSecondFactor проверяет второй фактор пользователя, если он включён, и при ошибке пишет ответ.
+// Неверный код считается неудачной попыткой входа, как и неверный пароль.
+func (h *Handler) checkSecondFactor(w http.ResponseWriter, user *entity.User, otp string) bool {
+	if h.TwoFactor == nil {
+		return true
+	}
+	enabled, err := h.TwoFactor.Enabled(user.ID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to check two-factor authentication: %v", err)
+		http.Error(w, "Failed to check two-factor authentication", http.StatusInternalServerError)
+		return false
+	}
+	if !enabled {
+		return true
+	}
+	if otp == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]any{
+			"error":               "two-factor authentication required",
+			"two_factor_required": true,
+		})
+		return false
+	}
+	err = h.TwoFactor.Verify(user.ID, otp)
+	if errors.Is(err, services.ErrInvalidOTP) {
+		h.loginFailed(w, user.Username, "Invalid one-time password")
+		return false
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to verify one-time password: %v", err)
+		http.Error(w, "Failed to verify one-time password", http.StatusInternalServerError)
+		return false
+	}
+	return true
+}
+
+// loginFailed учитывает неудачную попытку входа под именем username и отвечает 401.
+func (h *Handler) loginFailed(w http.ResponseWriter, username, message string) {
+	h.Metrics.LoginAttempt(metrics.LoginFailure)
+	if lock := h.LoginLockout.Fail(username); lock > 0 {
+		logger.InfoLogger.Printf("Login for %q locked for %s after failed attempts", username, lock)
+	}
+	http.Error(w, message, http.StatusUnauthorized)
 }
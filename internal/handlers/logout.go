@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+)
+
+// Logout завершает сессию: отзывает JWT-токен запроса и переданный токен обновления.
+// С all_devices отзываются все токены пользователя, то есть сессии на всех устройствах.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Тело запроса необязательно.
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+		AllDevices   bool   `json:"all_devices"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	claims := claimsFromContext(r.Context())
+
+	if err := h.Authenticator.RevokeToken(claims); err != nil {
+		logger.ErrorLogger.Printf("Failed to revoke token: %v", err)
+		http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Authenticator.RevokeRefreshToken(req.RefreshToken); err != nil {
+		logger.ErrorLogger.Printf("Failed to revoke refresh token: %v", err)
+		http.Error(w, "Failed to revoke refresh token", http.StatusInternalServerError)
+		return
+	}
+	if req.AllDevices {
+		if err := h.Authenticator.RevokeAllTokens(claims.UserID); err != nil {
+			logger.ErrorLogger.Printf("Failed to revoke user tokens: %v", err)
+			http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	logger.InfoLogger.Printf("User logged out: %s (all devices: %t)", claims.Username, req.AllDevices)
+	json.NewEncoder(w).Encode(map[string]string{
+		"message": "Logged out successfully",
+	})
+}
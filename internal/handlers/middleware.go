@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// claimsKey — ключ контекста запроса, под которым RequireAuth сохраняет claims токена.
+type claimsKey struct{}
+
+// RequireAuth пропускает запрос только с действительным и не отозванным JWT-токеном
+// в заголовке Authorization: Bearer <token>. Claims токена доступны обработчику через claimsFromContext.
+func (h *Handler) RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+			http.Error(w, "Authorization token is required", http.StatusUnauthorized)
+			return
+		}
+		claims, err := h.Authenticator.ValidateToken(parts[1])
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+		revoked, err := h.Authenticator.IsTokenRevoked(claims)
+		if err != nil {
+			logger.ErrorLogger.Printf("Failed to check token revocation: %v", err)
+			http.Error(w, "Failed to check token", http.StatusInternalServerError)
+			return
+		}
+		if revoked {
+			http.Error(w, "Token revoked", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+	})
+}
+
+// claimsFromContext возвращает claims токена, проверенного RequireAuth.
+func claimsFromContext(ctx context.Context) *services.Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*services.Claims)
+	return claims
+}
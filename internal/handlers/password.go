@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/andranikuz/gophkeeper/internal/ratelimit"
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// ChangePassword меняет пароль учётной записи после проверки старого пароля.
+// Все выданные ранее токены пользователя отзываются, поэтому сессии на других устройствах
+// должны войти заново; текущему клиенту возвращается новая пара токенов в формате ответа Login.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OldPassword string `json:"old_password"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.OldPassword == "" || req.NewPassword == "" {
+		http.Error(w, "Old and new passwords are required", http.StatusBadRequest)
+		return
+	}
+	if req.OldPassword == req.NewPassword {
+		http.Error(w, "New password must differ from the old one", http.StatusBadRequest)
+		return
+	}
+	claims := claimsFromContext(r.Context())
+	if !h.passwordCheckAllowed(w, claims.Username) {
+		return
+	}
+
+	user, err := h.UserRepo.GetUserByID(claims.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to get user: %v", err)
+		http.Error(w, "Failed to get user", http.StatusInternalServerError)
+		return
+	}
+	// 403, а не 401: токен действителен, и клиент не должен пытаться обновить сессию.
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)); err != nil {
+		h.passwordCheckFailed(w, claims.Username, "Invalid old password")
+		return
+	}
+	h.LoginLockout.Reset(claims.Username)
+	if err := h.Policy.ValidatePassword(req.NewPassword, user.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		http.Error(w, "Failed to hash password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.UserRepo.UpdatePassword(user.ID, string(hashedPassword)); err != nil {
+		logger.ErrorLogger.Printf("Failed to update password: %v", err)
+		http.Error(w, "Failed to update password", http.StatusInternalServerError)
+		return
+	}
+	if err := h.Authenticator.RevokeAllTokens(user.ID); err != nil {
+		// Пароль уже изменён, но старые сессии остались бы действительными: сообщаем об ошибке.
+		logger.ErrorLogger.Printf("Failed to revoke user tokens after password change: %v", err)
+		http.Error(w, "Password changed, but failed to revoke sessions", http.StatusInternalServerError)
+		return
+	}
+
+	token, err := h.Authenticator.GenerateToken(user.ID, user.Username)
+	if err != nil {
+		http.Error(w, "Failed to generate token", http.StatusInternalServerError)
+		return
+	}
+	refreshToken, err := h.Authenticator.IssueRefreshToken(user.ID, user.Username)
+	if err != nil {
+		http.Error(w, "Failed to generate refresh token", http.StatusInternalServerError)
+		return
+	}
+	logger.InfoLogger.Printf("Password changed: %s", user.Username)
+
+	resp := map[string]string{
+		"token":   token,
+		"user_id": user.ID,
+	}
+	if refreshToken != "" {
+		resp["refresh_token"] = refreshToken
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// passwordCheckAllowed отвечает 429, если проверка пароля для username заблокирована после серии ошибок.
+// Блокировка общая со входом: украденный токен не должен позволять перебирать пароль в обход Login.
+func (h *Handler) passwordCheckAllowed(w http.ResponseWriter, username string) bool {
+	if wait := h.LoginLockout.Locked(username); wait > 0 {
+		ratelimit.TooManyRequests(w, wait, "Too many failed password attempts")
+		return false
+	}
+	return true
+}
+
+// passwordCheckFailed учитывает неверный пароль авторизованного пользователя и отвечает 403.
+func (h *Handler) passwordCheckFailed(w http.ResponseWriter, username, message string) {
+	if lock := h.LoginLockout.Fail(username); lock > 0 {
+		logger.InfoLogger.Printf("Password checks for %q locked for %s after failed attempts", username, lock)
+	}
+	http.Error(w, message, http.StatusForbidden)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// RefreshToken обменивает токен обновления на новый JWT-токен и новый токен обновления.
+// Предъявленный токен становится недействительным.
+func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.RefreshToken == "" {
+		http.Error(w, "Refresh token is required", http.StatusBadRequest)
+		return
+	}
+
+	tokens, err := h.Authenticator.RefreshTokens(req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidRefreshToken) {
+		http.Error(w, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to refresh token: %v", err)
+		http.Error(w, "Failed to refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"user_id":       tokens.UserID,
+	})
+}
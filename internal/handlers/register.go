@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"net/http"
 	"time"
 
@@ -11,10 +11,11 @@ import (
 
 	"github.com/andranikuz/gophkeeper/pkg/entity"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 )
 
 // Register реализует регистрацию пользователя.
-// Здесь необходимо реализовать логику создания нового пользователя.
+// Имя и пароль проверяются по h.Policy (400 с описанием нарушенного правила), занятое имя даёт 409.
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Username string `json:"username"`
@@ -28,6 +29,14 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Username and password are required", http.StatusBadRequest)
 		return
 	}
+	if err := h.Policy.ValidateUsername(req.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.Policy.ValidatePassword(req.Password, req.Username); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	// Хешируем пароль.
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -51,8 +60,15 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Сохраняем пользователя в базе.
-	if err := h.UserRepo.SaveUser(user); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to save user: %v", err), http.StatusInternalServerError)
+	// Текст ошибки базы клиенту не передаётся: он раскрывает устройство хранилища.
+	err = h.UserRepo.SaveUser(user)
+	if errors.Is(err, repository.ErrUsernameTaken) {
+		http.Error(w, "Username already taken", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to save user: %v", err)
+		http.Error(w, "Failed to save user", http.StatusInternalServerError)
 		return
 	}
 
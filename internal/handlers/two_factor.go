@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/services"
+)
+
+// EnrollTwoFactor начинает подключение двухфакторной аутентификации: возвращает секрет TOTP
+// и URI otpauth для приложения-аутентификатора. Второй фактор начинает действовать после VerifyTwoFactor.
+func (h *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if h.TwoFactor == nil {
+		http.Error(w, "Two-factor authentication is not available", http.StatusNotImplemented)
+		return
+	}
+	claims := claimsFromContext(r.Context())
+	enrollment, err := h.TwoFactor.Enroll(claims.UserID, claims.Username)
+	if errors.Is(err, services.ErrTwoFactorEnabled) {
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to enroll two-factor authentication: %v", err)
+		http.Error(w, "Failed to enroll two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{
+		"secret":      enrollment.Secret,
+		"otpauth_uri": enrollment.URI,
+	})
+}
+
+// VerifyTwoFactor подтверждает подключение кодом из приложения-аутентификатора, включает
+// двухфакторную аутентификацию и возвращает одноразовые коды восстановления.
+func (h *Handler) VerifyTwoFactor(w http.ResponseWriter, r *http.Request) {
+	if h.TwoFactor == nil {
+		http.Error(w, "Two-factor authentication is not available", http.StatusNotImplemented)
+		return
+	}
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if req.Code == "" {
+		http.Error(w, "Code is required", http.StatusBadRequest)
+		return
+	}
+
+	claims := claimsFromContext(r.Context())
+	recoveryCodes, err := h.TwoFactor.Confirm(claims.UserID, req.Code)
+	switch {
+	case errors.Is(err, services.ErrInvalidOTP):
+		http.Error(w, "Invalid one-time password", http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrTwoFactorNotEnrolled):
+		http.Error(w, "Two-factor authentication is not enrolled", http.StatusBadRequest)
+		return
+	case errors.Is(err, services.ErrTwoFactorEnabled):
+		http.Error(w, "Two-factor authentication is already enabled", http.StatusConflict)
+		return
+	case err != nil:
+		logger.ErrorLogger.Printf("Failed to confirm two-factor authentication: %v", err)
+		http.Error(w, "Failed to confirm two-factor authentication", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoLogger.Printf("Two-factor authentication enabled: %s", claims.Username)
+	json.NewEncoder(w).Encode(map[string][]string{
+		"recovery_codes": recoveryCodes,
+	})
+}
@@ -0,0 +1,201 @@
+// Package healthcheck проверяет готовность зависимостей сервера — базы данных и хранилища файлов —
+// для HTTP-маршрута /readyz и стандартного сервиса grpc.health.v1.
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// Check проверяет одну зависимость и возвращает ошибку, если она недоступна.
+type Check func(ctx context.Context) error
+
+// Checker выполняет набор именованных проверок и запоминает результат последнего запуска.
+type Checker struct {
+	timeout time.Duration
+	names   []string
+	checks  map[string]Check
+
+	runMu  sync.Mutex // Не даёт одновременным запросам Latest запускать проверки параллельно.
+	mu     sync.Mutex
+	last   map[string]error
+	lastAt time.Time
+}
+
+// NewChecker создаёт набор проверок; каждая проверка должна уложиться в timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Add добавляет проверку name.
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Run выполняет все проверки параллельно и возвращает ошибку каждой из них (nil — проверка пройдена).
+func (c *Checker) Run(ctx context.Context) map[string]error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make(map[string]error, len(c.names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, name := range c.names {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			err := check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}(name, c.checks[name])
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	c.last = results
+	c.lastAt = time.Now()
+	c.mu.Unlock()
+	return copyResults(results)
+}
+
+// Latest возвращает результат последнего запуска, если он не старше maxAge, и выполняет проверки
+// только в противном случае. Запросы, пришедшие во время запуска, получают его результат,
+// поэтому частые обращения к /readyz не нагружают базу данных и хранилище.
+func (c *Checker) Latest(ctx context.Context, maxAge time.Duration) map[string]error {
+	requested := time.Now()
+	if results, ok := c.cached(requested.Add(-maxAge)); ok {
+		return results
+	}
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+	// Пока ждали, проверки мог выполнить другой запрос или Watch.
+	if results, ok := c.cached(requested); ok {
+		return results
+	}
+	return c.Run(ctx)
+}
+
+// cached возвращает копию результата последнего запуска, если он завершился не раньше since.
+func (c *Checker) cached(since time.Time) (map[string]error, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.last == nil || c.lastAt.Before(since) {
+		return nil, false
+	}
+	return copyResults(c.last), true
+}
+
+// copyResults копирует результаты проверок, чтобы вызывающий код не делил карту с кэшем.
+func copyResults(results map[string]error) map[string]error {
+	copied := make(map[string]error, len(results))
+	for name, err := range results {
+		copied[name] = err
+	}
+	return copied
+}
+
+// Healthy выполняет проверки и сообщает, пройдены ли все. Ошибки пишутся в лог.
+func (c *Checker) Healthy(ctx context.Context) bool {
+	healthy := true
+	for name, err := range c.Run(ctx) {
+		if err != nil {
+			logger.ErrorLogger.Printf("Health check %s failed: %v", name, err)
+			healthy = false
+		}
+	}
+	return healthy
+}
+
+// Watch каждые interval обновляет статус сервисов services (пустое имя — сервер целиком) в health
+// по результатам проверок, пока не отменён ctx. Результаты запусков попадают в кэш Latest.
+func (c *Checker) Watch(ctx context.Context, hs *health.Server, interval time.Duration, services ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !c.Healthy(ctx) {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		// После отмены контекста статус выставляет остановка сервера.
+		if ctx.Err() != nil {
+			return
+		}
+		for _, service := range services {
+			hs.SetServingStatus(service, status)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Database проверяет соединение с базой данных.
+func Database(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// Storage проверяет, что в хранилище файлов можно записать данные и прочитать их обратно.
+// Проверочный ключ случайный, чтобы параллельные проверки разных экземпляров сервера не мешали друг другу,
+// и удаляется сразу после проверки.
+func Storage(storage repository.BlobStorage) Check {
+	return func(ctx context.Context) error {
+		token := make([]byte, 16)
+		if _, err := rand.Read(token); err != nil {
+			return err
+		}
+		key := "healthcheck-" + hex.EncodeToString(token)
+		done := make(chan error, 1)
+		// Интерфейс хранилища не принимает контекст, поэтому таймаут соблюдается здесь.
+		go func() {
+			defer storage.Delete(key)
+			done <- probe(storage, key, token)
+		}()
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// probe записывает data под ключом key и сверяет прочитанное.
+func probe(storage repository.BlobStorage, key string, data []byte) error {
+	if err := storage.Put(key, data); err != nil {
+		return fmt.Errorf("write failed: %w", err)
+	}
+	r, err := storage.Get(key)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("read failed: %w", err)
+	}
+	if !bytes.Equal(got, data) {
+		return fmt.Errorf("read back data does not match")
+	}
+	return nil
+}
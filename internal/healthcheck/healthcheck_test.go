@@ -0,0 +1,133 @@
+package healthcheck
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
+)
+
+// brokenStorage — хранилище, в которое нельзя записать данные.
+type brokenStorage struct{}
+
+func (brokenStorage) Put(key string, data []byte) error     { return errors.New("read-only file system") }
+func (brokenStorage) Get(key string) (io.ReadCloser, error) { return nil, errors.New("not found") }
+func (brokenStorage) Delete(key string) error               { return nil }
+
+func TestChecker_Run(t *testing.T) {
+	c := NewChecker(50 * time.Millisecond)
+	c.Add("ok", func(ctx context.Context) error { return nil })
+	c.Add("fail", func(ctx context.Context) error { return errors.New("down") })
+	c.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	results := c.Run(context.Background())
+	assert.Len(t, results, 3)
+	assert.NoError(t, results["ok"])
+	assert.EqualError(t, results["fail"], "down")
+	assert.ErrorIs(t, results["slow"], context.DeadlineExceeded)
+	assert.False(t, c.Healthy(context.Background()))
+}
+
+func TestChecker_Latest(t *testing.T) {
+	var runs atomic.Int32
+	release := make(chan struct{})
+	c := NewChecker(time.Second)
+	c.Add("dependency", func(ctx context.Context) error {
+		runs.Add(1)
+		<-release
+		return nil
+	})
+
+	// Одновременные запросы получают результат одного запуска.
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Latest(context.Background(), time.Hour)["dependency"])
+		}()
+	}
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), runs.Load())
+
+	// Свежий результат берётся из кэша, устаревший — обновляется.
+	c.Latest(context.Background(), time.Hour)
+	assert.Equal(t, int32(1), runs.Load())
+	c.Latest(context.Background(), 0)
+	assert.Equal(t, int32(2), runs.Load())
+}
+
+func TestDatabase(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	check := Database(db)
+	assert.NoError(t, check(context.Background()))
+	require.NoError(t, db.Close())
+	assert.Error(t, check(context.Background()))
+}
+
+func TestStorage(t *testing.T) {
+	dir := t.TempDir()
+	storage, err := blobstore.NewFSStorage(dir)
+	require.NoError(t, err)
+	assert.NoError(t, Storage(storage)(context.Background()))
+	// Проверочные данные удаляются.
+	matches, err := filepath.Glob(filepath.Join(dir, "*", "healthcheck-*"))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+
+	assert.ErrorContains(t, Storage(brokenStorage{})(context.Background()), "write failed")
+}
+
+func TestChecker_Watch(t *testing.T) {
+	healthy := true
+	c := NewChecker(time.Second)
+	c.Add("dependency", func(ctx context.Context) error {
+		if !healthy {
+			return errors.New("down")
+		}
+		return nil
+	})
+	hs := health.NewServer()
+	status := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := hs.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "filesync"})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.Watch(ctx, hs, 10*time.Millisecond, "", "filesync")
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_SERVING }, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+
+	healthy = false
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go c.Watch(ctx, hs, 10*time.Millisecond, "", "filesync")
+	assert.Eventually(t, func() bool { return status() == healthpb.HealthCheckResponse_NOT_SERVING }, time.Second, 5*time.Millisecond)
+	// Результат, полученный Watch, доступен без нового запуска проверок.
+	assert.Error(t, c.Latest(context.Background(), time.Hour)["dependency"])
+}
@@ -0,0 +1,140 @@
+// Package metrics собирает метрики сервера в формате Prometheus: число и длительность HTTP- и gRPC-запросов,
+// объём переданных файлов, размеры списков синхронизации, попытки входа и время запросов к базе данных.
+// Все методы *Metrics допускают нулевой получатель: без метрик сервер работает как прежде.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace — общий префикс имён метрик сервера.
+const namespace = "gophkeeper"
+
+// Результаты попыток входа для LoginAttempt.
+const (
+	LoginSuccess = "success" // Пароль и второй фактор верны
+	LoginFailure = "failure" // Неверное имя, пароль или одноразовый код
+	LoginLocked  = "locked"  // Вход заблокирован после серии неудачных попыток
+)
+
+// syncListBuckets — границы гистограммы числа записей в списках ответа SyncRecords.
+var syncListBuckets = []float64{0, 1, 5, 10, 50, 100, 500, 1000, 5000}
+
+// Metrics хранит метрики одного экземпляра сервера в собственном реестре,
+// поэтому несколько серверов в одном процессе (например, в тестах) не мешают друг другу.
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	fileBytes    *prometheus.CounterVec
+	syncItems    *prometheus.HistogramVec
+	logins       *prometheus.CounterVec
+	dbDuration   *prometheus.HistogramVec
+}
+
+// New создаёт и регистрирует метрики сервера, а также стандартные метрики среды Go и процесса.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC calls by service, method and status code.",
+		}, []string{"service", "method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "gRPC call latency by service and method; for streams, the time the stream was open.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"service", "method"}),
+		fileBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "file_transfer_bytes_total",
+			Help:      "File bytes received from clients (upload) and sent to clients (download).",
+		}, []string{"direction"}),
+		syncItems: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "sync_list_items",
+			Help:      "Number of records in the merged, upload and download lists of a SyncRecords response.",
+			Buckets:   syncListBuckets,
+		}, []string{"list"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_attempts_total",
+			Help:      "Number of login attempts by result: success, failure or locked.",
+		}, []string{"result"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by statement type.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.grpcRequests, m.grpcDuration,
+		m.fileBytes, m.syncItems, m.logins, m.dbDuration,
+	)
+	return m
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus.
+func (m *Metrics) Handler() http.Handler {
+	if m == nil {
+		return http.NotFoundHandler()
+	}
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// UploadedBytes учитывает n байт файла, принятых от клиента.
+func (m *Metrics) UploadedBytes(n int) {
+	if m == nil {
+		return
+	}
+	m.fileBytes.WithLabelValues("upload").Add(float64(n))
+}
+
+// DownloadedBytes учитывает n байт файла, отправленных клиенту.
+func (m *Metrics) DownloadedBytes(n int) {
+	if m == nil {
+		return
+	}
+	m.fileBytes.WithLabelValues("download").Add(float64(n))
+}
+
+// ObserveSync записывает размеры списков одного ответа SyncRecords.
+func (m *Metrics) ObserveSync(merged, upload, download int) {
+	if m == nil {
+		return
+	}
+	m.syncItems.WithLabelValues("merged").Observe(float64(merged))
+	m.syncItems.WithLabelValues("upload").Observe(float64(upload))
+	m.syncItems.WithLabelValues("download").Observe(float64(download))
+}
+
+// LoginAttempt учитывает попытку входа с результатом LoginSuccess, LoginFailure или LoginLocked.
+func (m *Metrics) LoginAttempt(result string) {
+	if m == nil {
+		return
+	}
+	m.logins.WithLabelValues(result).Inc()
+}
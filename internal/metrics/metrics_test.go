@@ -0,0 +1,145 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// scrape возвращает метрики в текстовом формате, как их видит Prometheus.
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	m := New()
+	r := chi.NewRouter()
+	r.Use(m.Middleware)
+	r.Get("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	r.Post("/login", func(w http.ResponseWriter, r *http.Request) {})
+
+	for _, path := range []string{"/items/1", "/items/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/login", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/wp-admin/setup.php", nil))
+
+	// Запросы группируются по шаблону маршрута, а не по пути.
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/items/{id}", "404")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("POST", "/login", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", unmatchedRoute, "404")))
+	assert.Contains(t, scrape(t, m), `gophkeeper_http_request_duration_seconds_count{method="GET",route="/items/{id}"} 2`)
+}
+
+func TestInterceptors(t *testing.T) {
+	m := New()
+	unary := m.UnaryInterceptor()
+	_, err := unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/filesync.FileSyncService/SyncRecords"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	require.NoError(t, err)
+	_, err = unary(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/filesync.FileSyncService/SyncRecords"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.Unauthenticated, "missing token")
+		})
+	require.Error(t, err)
+
+	stream := m.StreamInterceptor()
+	err = stream(nil, nil, &grpc.StreamServerInfo{FullMethod: "/filesync.FileSyncService/UploadFile"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			return status.Error(codes.DataLoss, "checksum mismatch")
+		})
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues("filesync.FileSyncService", "SyncRecords", "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues("filesync.FileSyncService", "SyncRecords", "Unauthenticated")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.grpcRequests.WithLabelValues("filesync.FileSyncService", "UploadFile", "DataLoss")))
+}
+
+func TestDomainMetrics(t *testing.T) {
+	m := New()
+	m.UploadedBytes(1024)
+	m.UploadedBytes(512)
+	m.DownloadedBytes(100)
+	m.ObserveSync(3, 1, 0)
+	m.LoginAttempt(LoginFailure)
+	m.LoginAttempt(LoginFailure)
+	m.LoginAttempt(LoginSuccess)
+
+	assert.Equal(t, 1536.0, testutil.ToFloat64(m.fileBytes.WithLabelValues("upload")))
+	assert.Equal(t, 100.0, testutil.ToFloat64(m.fileBytes.WithLabelValues("download")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.logins.WithLabelValues(LoginFailure)))
+	body := scrape(t, m)
+	assert.Contains(t, body, `gophkeeper_sync_list_items_sum{list="merged"} 3`)
+	assert.Contains(t, body, `gophkeeper_login_attempts_total{result="success"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestOpenDB(t *testing.T) {
+	m := New()
+	db, err := m.OpenDB("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.Exec(`CREATE TABLE items (id TEXT PRIMARY KEY, value TEXT);`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO items (id, value) VALUES (?, ?);`, "1", "a")
+	require.NoError(t, err)
+	// Подготовленные запросы и транзакции тоже измеряются.
+	tx, err := db.Begin()
+	require.NoError(t, err)
+	stmt, err := tx.Prepare(`UPDATE items SET value = ? WHERE id = ?;`)
+	require.NoError(t, err)
+	_, err = stmt.Exec("b", "1")
+	require.NoError(t, err)
+	stmt.Close()
+	require.NoError(t, tx.Commit())
+	var value string
+	require.NoError(t, db.QueryRow(`SELECT value FROM items WHERE id = ?;`, "1").Scan(&value))
+	assert.Equal(t, "b", value)
+
+	// Ошибки драйвера не оборачиваются.
+	_, err = db.Exec(`INSERT INTO items (id, value) VALUES (?, ?);`, "1", "c")
+	assert.ErrorContains(t, err, "UNIQUE constraint failed")
+
+	body := scrape(t, m)
+	assert.Contains(t, body, `gophkeeper_db_query_duration_seconds_count{operation="insert"} 2`)
+	assert.Contains(t, body, `gophkeeper_db_query_duration_seconds_count{operation="update"} 1`)
+	assert.Contains(t, body, `gophkeeper_db_query_duration_seconds_count{operation="select"} 1`)
+	assert.Contains(t, body, `gophkeeper_db_query_duration_seconds_count{operation="other"} 1`)
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	called := false
+	m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.True(t, called)
+	m.UploadedBytes(1)
+	m.ObserveSync(1, 1, 1)
+	m.LoginAttempt(LoginSuccess)
+
+	db, err := m.OpenDB("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	assert.NoError(t, db.Ping())
+	db.Close()
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// unmatchedRoute — метка запросов, не попавших ни в один маршрут. Сам путь в метку не пишется:
+// перебор произвольных адресов иначе создаст неограниченное число временных рядов.
+const unmatchedRoute = "unmatched"
+
+// Middleware считает HTTP-запросы и их длительность. Маршрут берётся из шаблона chi
+// (например, /2fa/verify), поэтому middleware подключается к роутеру chi.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		// Шаблон маршрута известен только после того, как chi выбрал обработчик.
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+		m.httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(code)).Inc()
+		m.httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// UnaryInterceptor считает унарные gRPC-вызовы и их длительность.
+func (m *Metrics) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if m == nil {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.observeCall(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor считает gRPC-стримы и время, в течение которого они были открыты.
+func (m *Metrics) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if m == nil {
+			return handler(srv, stream)
+		}
+		start := time.Now()
+		err := handler(srv, stream)
+		m.observeCall(info.FullMethod, start, err)
+		return err
+	}
+}
+
+// observeCall записывает завершённый вызов fullMethod вида /package.Service/Method.
+func (m *Metrics) observeCall(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	m.grpcRequests.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	m.grpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+}
+
+// splitMethod разделяет полное имя gRPC-метода на сервис и метод.
+func splitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
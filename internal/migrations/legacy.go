@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// upgradeLegacySQLite приводит базу SQLite, созданную до появления миграций, к схеме первой миграции:
+// такие версии сервера создавали таблицы при запуске и добавляли недостающие колонки по одной.
+// Базы с таблицей schema_migrations и новые базы не изменяются.
+func upgradeLegacySQLite(db *sql.DB) error {
+	exists, err := tableExists(db, "schema_migrations")
+	if err != nil || exists {
+		return err
+	}
+	exists, err = tableExists(db, "data_items")
+	if err != nil || !exists {
+		return err
+	}
+	columns := []struct{ name, definition string }{
+		{"deleted", "INTEGER NOT NULL DEFAULT 0"},
+		{"deleted_at", "DATETIME"},
+		{"revision", "INTEGER NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if err := addColumnIfNotExists(db, "data_items", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableExists проверяет, есть ли в базе SQLite таблица с заданным именем.
+func tableExists(db *sql.DB, table string) (bool, error) {
+	var name string
+	err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?;`, table).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// addColumnIfNotExists добавляет колонку в таблицу, созданную предыдущей версией схемы.
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s);", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			dfltValue  sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", table, column, definition))
+	return err
+}
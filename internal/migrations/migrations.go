@@ -0,0 +1,281 @@
+// Package migrations применяет версионированные миграции схемы базы данных сервера.
+// Миграции встроены в бинарный файл: для каждого диалекта в директории с его именем лежат пары файлов
+// <версия>_<название>.up.sql и <версия>_<название>.down.sql. Применённые версии записываются в таблицу
+// schema_migrations, каждая миграция выполняется в отдельной транзакции вместе с этой записью.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Поддерживаемые диалекты SQL.
+const (
+	SQLite   = "sqlite"
+	Postgres = "postgres"
+)
+
+// migrationLockID — ключ advisory-блокировки PostgreSQL, под которой применяются миграции,
+// чтобы несколько экземпляров сервера не применяли их одновременно.
+const migrationLockID = 7036150501
+
+//go:embed sqlite/*.sql postgres/*.sql
+var files embed.FS
+
+// fileName — формат имени файла миграции.
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration — одна версия схемы.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string // SQL применения миграции
+	Down    string // SQL отката миграции
+}
+
+// Status описывает состояние миграции в базе.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator применяет и откатывает миграции одного диалекта.
+type Migrator struct {
+	db         *sql.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New создаёт Migrator со встроенными миграциями диалекта dialect.
+func New(db *sql.DB, dialect string) (*Migrator, error) {
+	sub, err := fs.Sub(files, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(db, dialect, sub)
+}
+
+// newMigrator создаёт Migrator с миграциями из fsys.
+func newMigrator(db *sql.DB, dialect string, fsys fs.FS) (*Migrator, error) {
+	if dialect != SQLite && dialect != Postgres {
+		return nil, fmt.Errorf("unknown sql dialect %q", dialect)
+	}
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// load читает миграции из fsys и упорядочивает их по версии.
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		m := fileName.FindStringSubmatch(entry.Name())
+		if m == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != m[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, m[2])
+		}
+		if m[3] == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up применяет все ещё не применённые миграции по порядку и возвращает их.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	var applied []Migration
+	for _, migration := range m.migrations {
+		ok, err := m.apply(migration)
+		if err != nil {
+			return applied, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		if ok {
+			applied = append(applied, migration)
+		}
+	}
+	return applied, nil
+}
+
+// Down откатывает последнюю применённую миграцию и возвращает её. Если применённых миграций нет,
+// возвращается nil.
+func (m *Migrator) Down() (*Migration, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(statuses) - 1; i >= 0; i-- {
+		if !statuses[i].Applied {
+			continue
+		}
+		migration := statuses[i].Migration
+		if err := m.revert(migration); err != nil {
+			return nil, fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return &migration, nil
+	}
+	return nil, nil
+}
+
+// Status возвращает состояние всех известных миграций.
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	appliedAt := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at string
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version], _ = time.Parse(time.RFC3339, at)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		at, ok := appliedAt[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: at})
+	}
+	return statuses, nil
+}
+
+// init создаёт таблицу schema_migrations. Базы SQLite, созданные до появления миграций,
+// предварительно приводятся к схеме первой миграции.
+func (m *Migrator) init() error {
+	if m.dialect == SQLite {
+		if err := upgradeLegacySQLite(m.db); err != nil {
+			return fmt.Errorf("failed to upgrade legacy schema: %w", err)
+		}
+	}
+	_, err := m.db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	);
+	`)
+	return err
+}
+
+// apply применяет миграцию, если она ещё не применена, и сообщает, была ли она применена.
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	tx, err := m.begin()
+	if err != nil {
+		return false, err
+	}
+	var one int
+	err = tx.QueryRow(`SELECT 1 FROM schema_migrations WHERE version = `+m.placeholder(1)+`;`, migration.Version).Scan(&one)
+	if err == nil {
+		// Миграция уже применена, например другим экземпляром сервера.
+		tx.Rollback()
+		return false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		tx.Rollback()
+		return false, err
+	}
+	if _, err := tx.Exec(migration.Up); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (`+
+		m.placeholder(1)+`, `+m.placeholder(2)+`, `+m.placeholder(3)+`);`,
+		migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// revert откатывает миграцию и удаляет запись о ней.
+func (m *Migrator) revert(migration Migration) error {
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(migration.Down); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = `+m.placeholder(1)+`;`, migration.Version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// begin начинает транзакцию миграции. В PostgreSQL транзакция берёт advisory-блокировку,
+// которая снимается при её завершении.
+func (m *Migrator) begin() (*sql.Tx, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	if m.dialect == Postgres {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1);`, migrationLockID); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return tx, nil
+}
+
+// placeholder возвращает n-й параметр запроса в синтаксисе диалекта.
+func (m *Migrator) placeholder(n int) string {
+	if m.dialect == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
@@ -0,0 +1,127 @@
+package migrations
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := openSQLite(t)
+	migrator, err := New(db, SQLite)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	require.Len(t, applied, 6)
+	assert.Equal(t, 1, applied[0].Version)
+	assert.Equal(t, "blobs", applied[1].Name)
+	assert.Equal(t, "refresh_tokens", applied[2].Name)
+	assert.Equal(t, "revoked_tokens", applied[3].Name)
+	assert.Equal(t, "two_factor", applied[4].Name)
+	assert.Equal(t, "user_revisions", applied[5].Name)
+	_, err = db.Exec(`INSERT INTO blobs (hash, size, updated_at) VALUES ('a', 1, '2025-01-01T00:00:00Z');`)
+	require.NoError(t, err)
+
+	// Повторный запуск ничего не применяет.
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	// Откат убирает только последнюю миграцию.
+	reverted, err := migrator.Down()
+	require.NoError(t, err)
+	require.NotNil(t, reverted)
+	assert.Equal(t, 6, reverted.Version)
+	_, err = db.Exec(`SELECT 1 FROM user_revisions;`)
+	assert.Error(t, err, "Таблица откаченной миграции должна быть удалена")
+	_, err = db.Exec(`SELECT 1 FROM two_factor;`)
+	assert.NoError(t, err)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 6)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[0].AppliedAt.IsZero())
+	assert.True(t, statuses[4].Applied)
+	assert.False(t, statuses[5].Applied)
+
+	for i := 0; i < 5; i++ {
+		_, err = migrator.Down()
+		require.NoError(t, err)
+	}
+	reverted, err = migrator.Down()
+	require.NoError(t, err)
+	assert.Nil(t, reverted, "Откатывать больше нечего")
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, 6)
+}
+
+func TestMigrator_UpgradesLegacySQLite(t *testing.T) {
+	db := openSQLite(t)
+	// Схема, которую создавали версии сервера без миграций.
+	_, err := db.Exec(`
+	CREATE TABLE users (id TEXT PRIMARY KEY, username TEXT UNIQUE, password TEXT, created_at DATETIME);
+	CREATE TABLE data_items (id TEXT PRIMARY KEY, type INTEGER, content text, meta text, user_id text, updated_at DATETIME);
+	INSERT INTO data_items (id, type, content, meta, user_id, updated_at) VALUES ('1', 0, 'c', 'm', 'u', '2025-01-01T00:00:00Z');
+	`)
+	require.NoError(t, err)
+
+	migrator, err := New(db, SQLite)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	// Данные сохранены, недостающие колонки добавлены.
+	var deleted bool
+	var revision int64
+	require.NoError(t, db.QueryRow(`SELECT deleted, revision FROM data_items WHERE id = '1';`).Scan(&deleted, &revision))
+	assert.False(t, deleted)
+	assert.Equal(t, int64(0), revision)
+	// Счётчик ревизий пользователя продолжает уже выданные ревизии.
+	require.NoError(t, db.QueryRow(`SELECT revision FROM user_revisions WHERE user_id = 'u';`).Scan(&revision))
+	assert.Equal(t, int64(0), revision)
+}
+
+func TestLoad_Validation(t *testing.T) {
+	_, err := load(fstest.MapFS{
+		"0001_init.up.sql": {Data: []byte("CREATE TABLE a (id TEXT);")},
+	})
+	assert.ErrorContains(t, err, "both up and down")
+
+	_, err = load(fstest.MapFS{
+		"init.sql": {Data: []byte("CREATE TABLE a (id TEXT);")},
+	})
+	assert.ErrorContains(t, err, "invalid migration file name")
+
+	// Миграции упорядочиваются по номеру версии, а не по имени файла.
+	migrations, err := load(fstest.MapFS{
+		"10_second.up.sql":   {Data: []byte("up 10")},
+		"10_second.down.sql": {Data: []byte("down 10")},
+		"9_first.up.sql":     {Data: []byte("up 9")},
+		"9_first.down.sql":   {Data: []byte("down 9")},
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, 9, migrations[0].Version)
+	assert.Equal(t, "up 9", migrations[0].Up)
+	assert.Equal(t, "down 10", migrations[1].Down)
+
+	_, err = New(nil, "mysql")
+	assert.Error(t, err)
+}
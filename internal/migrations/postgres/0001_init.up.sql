@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS data_items (
+	id TEXT PRIMARY KEY,
+	type INTEGER NOT NULL,
+	content TEXT NOT NULL DEFAULT '',
+	meta TEXT NOT NULL DEFAULT '',
+	user_id TEXT NOT NULL,
+	updated_at TIMESTAMPTZ NOT NULL,
+	deleted BOOLEAN NOT NULL DEFAULT FALSE,
+	deleted_at TIMESTAMPTZ,
+	revision BIGINT NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS data_items_user_id_idx ON data_items (user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS file_manifests;
+DROP TABLE IF EXISTS blobs;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS refresh_tokens_user_id_idx;
+DROP TABLE IF EXISTS user_token_revocations;
+DROP TABLE IF EXISTS revoked_tokens;
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS two_factor (
+	user_id TEXT PRIMARY KEY,
+	secret TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT FALSE,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	user_id TEXT NOT NULL,
+	code_hash TEXT NOT NULL,
+	PRIMARY KEY (user_id, code_hash)
+);
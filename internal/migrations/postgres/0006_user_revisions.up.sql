@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS user_revisions (
+	user_id TEXT PRIMARY KEY,
+	revision BIGINT NOT NULL DEFAULT 0
+);
+
+INSERT INTO user_revisions (user_id, revision)
+SELECT user_id, MAX(revision) FROM data_items GROUP BY user_id;
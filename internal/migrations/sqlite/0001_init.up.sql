@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username TEXT UNIQUE,
+	password TEXT,
+	created_at DATETIME
+);
+
+CREATE TABLE IF NOT EXISTS data_items (
+	id TEXT PRIMARY KEY,
+	type INTEGER,
+	content text,
+	meta text,
+	user_id text,
+	updated_at DATETIME,
+	deleted INTEGER NOT NULL DEFAULT 0,
+	deleted_at DATETIME,
+	revision INTEGER NOT NULL DEFAULT 0
+);
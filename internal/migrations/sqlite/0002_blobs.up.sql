@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS blobs (
+	hash TEXT PRIMARY KEY,
+	size INTEGER NOT NULL,
+	refcount INTEGER NOT NULL DEFAULT 0,
+	updated_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS file_manifests (
+	user_id TEXT NOT NULL,
+	file_id TEXT NOT NULL,
+	sha256 TEXT NOT NULL,
+	size INTEGER NOT NULL,
+	chunks TEXT NOT NULL,
+	PRIMARY KEY (user_id, file_id)
+);
@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"database/sql"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// AccountRepository удаляет учётные записи пользователей из базы PostgreSQL.
+type AccountRepository struct {
+	db *sql.DB
+}
+
+// NewAccountRepository возвращает репозиторий учётных записей.
+func NewAccountRepository(db *sql.DB) (*AccountRepository, error) {
+	return &AccountRepository{db: db}, nil
+}
+
+// DeleteAccount удаляет пользователя и все его данные в одной транзакции. Чанки файлов остаются
+// в хранилище без ссылок до сборки мусора. Записи об отзыве токенов остаются, чтобы выданные
+// пользователю токены отклонялись до истечения срока.
+func (r *AccountRepository) DeleteAccount(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := releaseUserManifests(tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, query := range []string{
+		`DELETE FROM data_items WHERE user_id = $1;`,
+		`DELETE FROM user_revisions WHERE user_id = $1;`,
+		`DELETE FROM recovery_codes WHERE user_id = $1;`,
+		`DELETE FROM two_factor WHERE user_id = $1;`,
+		`DELETE FROM refresh_tokens WHERE user_id = $1;`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM users WHERE id = $1;`, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+		return repository.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// releaseUserManifests удаляет манифесты всех файлов пользователя и снимает ссылки с их чанков.
+func releaseUserManifests(tx *sql.Tx, userID string) error {
+	rows, err := tx.Query(`DELETE FROM file_manifests WHERE user_id = $1 RETURNING chunks;`, userID)
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	for rows.Next() {
+		var chunks string
+		if err := rows.Scan(&chunks); err != nil {
+			rows.Close()
+			return err
+		}
+		hashes = append(hashes, splitChunks(chunks)...)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return addRefs(tx, hashes, -1)
+}
@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// BlobRepository реализует хранение манифестов файлов и счётчиков ссылок на чанки в PostgreSQL.
+type BlobRepository struct {
+	db *sql.DB
+}
+
+// NewBlobRepository возвращает репозиторий блобов и манифестов.
+func NewBlobRepository(db *sql.DB) (*BlobRepository, error) {
+	return &BlobRepository{db: db}, nil
+}
+
+// AddBlob регистрирует сохранённый чанк. Повторная регистрация продлевает жизнь чанка без ссылок.
+func (r *BlobRepository) AddBlob(hash string, size int64) error {
+	_, err := r.db.Exec(`
+	INSERT INTO blobs (hash, size, refcount, updated_at) VALUES ($1, $2, 0, now())
+	ON CONFLICT (hash) DO UPDATE SET updated_at = excluded.updated_at;
+	`, hash, size)
+	return err
+}
+
+// MissingBlobs возвращает хэши из списка, чанков для которых нет в хранилище, и продлевает жизнь
+// найденных чанков: до сохранения манифеста на них нет ссылок.
+func (r *BlobRepository) MissingBlobs(hashes []string) ([]string, error) {
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.Query(`UPDATE blobs SET updated_at = now() WHERE hash = ANY($1) RETURNING hash;`, hashes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	found := make(map[string]bool)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		found[hash] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, hash := range hashes {
+		if !found[hash] {
+			missing = append(missing, hash)
+			found[hash] = true
+		}
+	}
+	return missing, nil
+}
+
+// GetManifest возвращает манифест файла или repository.ErrNotFound.
+func (r *BlobRepository) GetManifest(userID, fileID string) (*entity.FileManifest, error) {
+	manifest := entity.FileManifest{UserID: userID, FileID: fileID}
+	var chunks string
+	err := r.db.QueryRow(`SELECT sha256, size, chunks FROM file_manifests WHERE user_id = $1 AND file_id = $2;`, userID, fileID).
+		Scan(&manifest.SHA256, &manifest.Size, &chunks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	manifest.Chunks = splitChunks(chunks)
+	return &manifest, nil
+}
+
+// SaveManifest сохраняет манифест файла, увеличивая счётчики ссылок его чанков
+// и уменьшая счётчики чанков заменённого манифеста.
+func (r *BlobRepository) SaveManifest(manifest entity.FileManifest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := releaseManifest(tx, manifest.UserID, manifest.FileID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(`
+	INSERT INTO file_manifests (user_id, file_id, sha256, size, chunks) VALUES ($1, $2, $3, $4, $5);
+	`, manifest.UserID, manifest.FileID, manifest.SHA256, manifest.Size, strings.Join(manifest.Chunks, ","))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := addRefs(tx, manifest.Chunks, 1); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteManifest удаляет манифест файла и уменьшает счётчики ссылок его чанков.
+func (r *BlobRepository) DeleteManifest(userID, fileID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := releaseManifest(tx, userID, fileID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// PurgeUnreferencedBlobs удаляет чанки без ссылок, не использовавшиеся с момента before, и возвращает их хэши.
+// Каждый чанк удаляется в своей транзакции: удалённая строка остаётся заблокированной, и AddBlob
+// того же чанка ждёт, пока remove удалит данные и транзакция зафиксируется.
+func (r *BlobRepository) PurgeUnreferencedBlobs(before time.Time, remove func(hash string) error) ([]string, error) {
+	cutoff := before.UTC()
+	rows, err := r.db.Query(`SELECT hash FROM blobs WHERE refcount <= 0 AND updated_at < $1;`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, hash)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var purged []string
+	for _, hash := range candidates {
+		ok, err := r.purgeBlob(hash, cutoff, remove)
+		if err != nil {
+			return purged, err
+		}
+		if ok {
+			purged = append(purged, hash)
+		}
+	}
+	return purged, nil
+}
+
+// purgeBlob удаляет чанк, если он всё ещё не используется. Условие проверяется повторно:
+// после выборки кандидатов на чанк могли сослаться или загрузить его заново.
+func (r *BlobRepository) purgeBlob(hash string, cutoff time.Time, remove func(hash string) error) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	res, err := tx.Exec(`DELETE FROM blobs WHERE hash = $1 AND refcount <= 0 AND updated_at < $2;`, hash, cutoff)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n == 0 {
+		tx.Rollback()
+		return false, err
+	}
+	if err := remove(hash); err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to remove blob %s: %w", hash, err)
+	}
+	return true, tx.Commit()
+}
+
+// releaseManifest удаляет манифест файла, если он есть, и снимает ссылки с его чанков.
+func releaseManifest(tx *sql.Tx, userID, fileID string) error {
+	var chunks string
+	err := tx.QueryRow(`DELETE FROM file_manifests WHERE user_id = $1 AND file_id = $2 RETURNING chunks;`, userID, fileID).Scan(&chunks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return addRefs(tx, splitChunks(chunks), -1)
+}
+
+// addRefs изменяет счётчики ссылок чанков на delta для каждого вхождения чанка.
+// Если чанка нет в хранилище, возвращается repository.ErrNotFound, и транзакцию нужно откатить.
+func addRefs(tx *sql.Tx, hashes []string, delta int) error {
+	stmt, err := tx.Prepare(`UPDATE blobs SET refcount = refcount + $1, updated_at = now() WHERE hash = $2;`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, hash := range hashes {
+		res, err := stmt.Exec(delta, hash)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return fmt.Errorf("chunk %s: %w", hash, repository.ErrNotFound)
+		}
+	}
+	return nil
+}
+
+// splitChunks разбирает список хэшей чанков, сохранённый через запятую.
+func splitChunks(chunks string) []string {
+	if chunks == "" {
+		return nil
+	}
+	return strings.Split(chunks, ",")
+}
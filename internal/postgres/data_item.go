@@ -0,0 +1,159 @@
+// Package postgres реализует репозитории сервера поверх PostgreSQL (драйвер pgx через database/sql).
+package postgres
+
+import (
+	"database/sql"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// DriverName — имя драйвера PostgreSQL для sql.Open.
+const DriverName = "pgx"
+
+// DataItemRepository реализует хранилище данных с использованием PostgreSQL.
+type DataItemRepository struct {
+	db *sql.DB
+}
+
+// NewDataItemRepository создаёт репозиторий записей поверх базы PostgreSQL, схема которой подготовлена миграциями.
+func NewDataItemRepository(db *sql.DB) (*DataItemRepository, error) {
+	return &DataItemRepository{db: db}, nil
+}
+
+// ApplyChanges применяет изменения записей пользователя userID в одной транзакции, см. repository.DataItemRepository.
+func (s *DataItemRepository) ApplyChanges(userID string, changes []entity.DataItem) ([]entity.DataItem, []string, error) {
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Upsert счётчика ревизий блокирует его строку до конца транзакции, как SELECT ... FOR UPDATE:
+	// параллельная синхронизация того же пользователя ждёт здесь и затем видит уже записанные ревизии.
+	// Счётчик создаётся с максимальной ревизией записей, чтобы новые ревизии не повторяли уже выданные.
+	var revision int64
+	err = tx.QueryRow(`
+	INSERT INTO user_revisions (user_id, revision)
+	SELECT $1::text, COALESCE(MAX(revision), 0) FROM data_items WHERE user_id = $1
+	ON CONFLICT (user_id) DO UPDATE SET revision = user_revisions.revision
+	RETURNING revision;
+	`, userID).Scan(&revision)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	// Запись обновляется, только если она принадлежит пользователю и её ревизия не изменилась с BaseRevision.
+	stmt, err := tx.Prepare(`
+	INSERT INTO data_items (id, type, content, meta, user_id, updated_at, deleted, deleted_at, revision)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (id) DO UPDATE SET
+		type = excluded.type,
+		content = excluded.content,
+		meta = excluded.meta,
+		updated_at = excluded.updated_at,
+		deleted = excluded.deleted,
+		deleted_at = excluded.deleted_at,
+		revision = excluded.revision
+	WHERE data_items.user_id = excluded.user_id AND data_items.revision = $10;
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	var accepted []entity.DataItem
+	var rejected []string
+	for _, item := range changes {
+		res, err := stmt.Exec(item.ID, int(item.Type), item.Content, item.Meta, userID, item.UpdatedAt.UTC(),
+			item.Deleted, nullTime(item.DeletedAt), revision+1, item.BaseRevision)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		if n == 0 {
+			rejected = append(rejected, item.ID)
+			continue
+		}
+		revision++
+		item.UserID = userID
+		item.Revision = revision
+		item.BaseRevision = 0
+		accepted = append(accepted, item)
+	}
+	if _, err := tx.Exec(`UPDATE user_revisions SET revision = $1 WHERE user_id = $2;`, revision, userID); err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return accepted, rejected, nil
+}
+
+// GetUserItems извлекает все объекты пользователя DataItem из базы.
+func (s *DataItemRepository) GetUserItems(userID string) ([]entity.DataItem, error) {
+	rows, err := s.db.Query(`
+	SELECT id, type, content, meta, user_id, updated_at, deleted, deleted_at, revision
+	FROM data_items
+	WHERE user_id = $1;
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []entity.DataItem
+	for rows.Next() {
+		var item entity.DataItem
+		var deletedAt sql.NullTime
+		var typeInt int
+		err := rows.Scan(&item.ID, &typeInt, &item.Content, &item.Meta, &item.UserID, &item.UpdatedAt,
+			&item.Deleted, &deletedAt, &item.Revision)
+		if err != nil {
+			return nil, err
+		}
+		item.Type = entity.DataType(typeInt)
+		item.UpdatedAt = item.UpdatedAt.UTC()
+		if deletedAt.Valid {
+			item.DeletedAt = deletedAt.Time.UTC()
+		}
+		items = append(items, item)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// PurgeDeletedItems окончательно удаляет надгробия, удалённые раньше указанного момента.
+// Запись с максимальной ревизией пользователя сохраняется, чтобы новые ревизии не повторяли
+// уже выданные курсоры синхронизации. Возвращает количество удалённых записей.
+func (s *DataItemRepository) PurgeDeletedItems(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+	DELETE FROM data_items d
+	WHERE d.deleted AND d.deleted_at < $1
+	  AND d.revision < (SELECT MAX(m.revision) FROM data_items m WHERE m.user_id = d.user_id);
+	`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// nullTime возвращает NULL для нулевого времени.
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
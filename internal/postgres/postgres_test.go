@@ -0,0 +1,395 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/internal/migrations"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// openTestDB подключается к PostgreSQL из GOPHKEEPER_TEST_POSTGRES_DSN и создаёт для теста
+// отдельную схему с применёнными миграциями, удаляемую по завершении. Без переменной окружения тест пропускается.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("GOPHKEEPER_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("GOPHKEEPER_TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open(DriverName, dsn)
+	require.NoError(t, err)
+	// Одно соединение, чтобы search_path действовал на все запросы теста.
+	db.SetMaxOpenConns(1)
+	schema := fmt.Sprintf("gophkeeper_test_%d", time.Now().UnixNano())
+	_, err = db.Exec("CREATE SCHEMA " + schema)
+	require.NoError(t, err)
+	_, err = db.Exec("SET search_path TO " + schema)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Exec("DROP SCHEMA " + schema + " CASCADE")
+		db.Close()
+	})
+	migrator, err := migrations.New(db, migrations.Postgres)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db
+}
+
+// noopRemove подменяет удаление данных чанка: в тестах репозитория данных нет.
+func noopRemove(hash string) error { return nil }
+
+func TestDataItemRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	items, rejected, err := repo.ApplyChanges("user1", []entity.DataItem{
+		{ID: "1", Type: entity.DataTypeText, Content: "text", Meta: "meta", UpdatedAt: now},
+		{ID: "2", Type: entity.DataTypeBinary, Content: "file", UpdatedAt: now},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	require.Len(t, items, 2)
+	assert.Equal(t, int64(1), items[0].Revision)
+	assert.Equal(t, int64(2), items[1].Revision)
+	other, _, err := repo.ApplyChanges("user2", []entity.DataItem{{ID: "3", Content: "other", UpdatedAt: now}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), other[0].Revision, "Ревизии у каждого пользователя свои")
+
+	got, err := repo.GetUserItems("user1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	byID := map[string]entity.DataItem{got[0].ID: got[0], got[1].ID: got[1]}
+	assert.Equal(t, items[0], byID["1"])
+	assert.Equal(t, items[1], byID["2"])
+
+	// Изменение на основе текущей ревизии обновляет запись.
+	deleted := items[0]
+	deleted.Deleted = true
+	deleted.DeletedAt = now.Add(-time.Hour)
+	deleted.BaseRevision = deleted.Revision
+	accepted, _, err := repo.ApplyChanges("user1", []entity.DataItem{deleted})
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, int64(3), accepted[0].Revision)
+
+	// Надгробие с максимальной ревизией пользователя не удаляется.
+	n, err := repo.PurgeDeletedItems(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// После появления более новой ревизии надгробие удаляется.
+	newer := items[1]
+	newer.BaseRevision = newer.Revision
+	_, _, err = repo.ApplyChanges("user1", []entity.DataItem{newer})
+	require.NoError(t, err)
+	n, err = repo.PurgeDeletedItems(now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	got, err = repo.GetUserItems("user1")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "2", got[0].ID)
+	assert.Equal(t, int64(4), got[0].Revision)
+}
+
+func TestDataItemRepository_ApplyChangesIsAtomic(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+
+	// Вторая запись нарушает ограничение CHECK, поэтому не сохраняется и первая.
+	_, err = db.Exec("ALTER TABLE data_items ADD CONSTRAINT meta_not_bad CHECK (meta <> 'bad')")
+	require.NoError(t, err)
+	_, _, err = repo.ApplyChanges("user1", []entity.DataItem{
+		{ID: "1", UpdatedAt: time.Now()},
+		{ID: "2", Meta: "bad", UpdatedAt: time.Now()},
+	})
+	require.Error(t, err)
+	got, err := repo.GetUserItems("user1")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestDataItemRepository_ApplyChangesChecksRevision(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	_, _, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "secret", UpdatedAt: now}})
+	require.NoError(t, err)
+
+	// Чужой клиент, приславший ID записи user1, не может её перезаписать или присвоить.
+	accepted, rejected, err := repo.ApplyChanges("user2", []entity.DataItem{
+		{ID: "2", Content: "own", UpdatedAt: now},
+		{ID: "1", Content: "stolen", UpdatedAt: now, BaseRevision: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, "2", accepted[0].ID)
+	assert.Equal(t, []string{"1"}, rejected)
+
+	// Изменения на основе устаревшей ревизии или без неё отклоняются.
+	_, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "new", UpdatedAt: now}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, rejected)
+	accepted, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "edit", UpdatedAt: now, BaseRevision: 1}})
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, int64(2), accepted[0].Revision)
+	_, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "late", UpdatedAt: now, BaseRevision: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, rejected)
+
+	got, err := repo.GetUserItems("user1")
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "edit", got[0].Content)
+}
+
+func TestUserRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewUserRepository(db)
+	require.NoError(t, err)
+
+	user := entity.User{ID: "id1", Username: "alice", Password: "hash", CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	require.NoError(t, repo.SaveUser(user))
+	got, err := repo.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, user, *got)
+
+	// Имя пользователя уникально.
+	err = repo.SaveUser(entity.User{ID: "id2", Username: "alice", Password: "other", CreatedAt: time.Now()})
+	assert.ErrorIs(t, err, repository.ErrUsernameTaken)
+	got, err = repo.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", got.Password, "Существующая учётная запись не должна перезаписываться")
+
+	_, err = repo.GetUserByUsername("bob")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	require.NoError(t, repo.UpdatePassword("id1", "new-hash"))
+	got, err = repo.GetUserByID("id1")
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", got.Password)
+	_, err = repo.GetUserByID("id2")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.ErrorIs(t, repo.UpdatePassword("id2", "hash"), repository.ErrNotFound)
+}
+
+func TestBlobRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewBlobRepository(db)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AddBlob("a", 1))
+	require.NoError(t, repo.AddBlob("b", 1))
+	missing, err := repo.MissingBlobs([]string{"a", "c", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, missing)
+
+	manifest := entity.FileManifest{UserID: "user1", FileID: "file1", SHA256: "sum", Size: 2, Chunks: []string{"a", "b"}}
+	require.NoError(t, repo.SaveManifest(manifest))
+	got, err := repo.GetManifest("user1", "file1")
+	require.NoError(t, err)
+	assert.Equal(t, manifest, *got)
+
+	// Чанки с ссылками не удаляются.
+	future := time.Now().Add(time.Hour)
+	purged, err := repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	// Новая версия файла освобождает чанк b.
+	manifest.Chunks = []string{"a"}
+	require.NoError(t, repo.SaveManifest(manifest))
+	purged, err = repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, purged)
+
+	require.NoError(t, repo.DeleteManifest("user1", "file1"))
+	_, err = repo.GetManifest("user1", "file1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	purged, err = repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+
+	// Манифест с незарегистрированным чанком не сохраняется.
+	err = repo.SaveManifest(entity.FileManifest{UserID: "user1", FileID: "file2", Chunks: []string{"b", "unknown"}})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.GetManifest("user1", "file2")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Проверка наличия чанка откладывает его удаление.
+	require.NoError(t, repo.AddBlob("b", 1))
+	_, err = db.Exec(`UPDATE blobs SET updated_at = now() - interval '2 days';`)
+	require.NoError(t, err)
+	missing, err = repo.MissingBlobs([]string{"b"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	purged, err = repo.PurgeUnreferencedBlobs(time.Now().Add(-time.Hour), noopRemove)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	// Если данные не удалось удалить, запись о чанке остаётся.
+	purged, err = repo.PurgeUnreferencedBlobs(future, func(hash string) error { return errors.New("storage unavailable") })
+	assert.Error(t, err)
+	assert.Empty(t, purged)
+	missing, err = repo.MissingBlobs([]string{"b"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestRefreshTokenRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewRefreshTokenRepository(db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	token := entity.RefreshToken{TokenHash: "h1", UserID: "user1", Username: "alice", FamilyID: "f1", ExpiresAt: now.Add(time.Hour), CreatedAt: now}
+	require.NoError(t, repo.SaveRefreshToken(token))
+	got, err := repo.GetRefreshToken("h1")
+	require.NoError(t, err)
+	assert.Equal(t, token, *got)
+
+	// Ротация отзывает старый токен и выполняется только один раз.
+	next := token
+	next.TokenHash = "h2"
+	require.NoError(t, repo.RotateRefreshToken("h1", next))
+	assert.ErrorIs(t, repo.RotateRefreshToken("h1", entity.RefreshToken{TokenHash: "h3", FamilyID: "f1", ExpiresAt: now, CreatedAt: now}), repository.ErrNotFound)
+	got, err = repo.GetRefreshToken("h1")
+	require.NoError(t, err)
+	assert.True(t, got.Revoked)
+
+	require.NoError(t, repo.RevokeUserRefreshTokens("user1"))
+	got, err = repo.GetRefreshToken("h2")
+	require.NoError(t, err)
+	assert.True(t, got.Revoked)
+
+	n, err := repo.PurgeExpiredRefreshTokens(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	_, err = repo.GetRefreshToken("h2")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestRevokedTokenRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewRevokedTokenRepository(db)
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repo.RevokeToken("jti1", "user1", now.Add(time.Hour)))
+	// Повторный отзыв не считается ошибкой.
+	require.NoError(t, repo.RevokeToken("jti1", "user1", now.Add(time.Hour)))
+	revoked, err := repo.IsTokenRevoked("jti1", "user1", now)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked("jti2", "user1", now)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	// Отзываются токены, выпущенные раньше границы.
+	require.NoError(t, repo.RevokeUserTokens("user1", now))
+	revoked, err = repo.IsTokenRevoked("jti2", "user1", now.Add(-time.Second))
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = repo.IsTokenRevoked("jti2", "user1", now)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	n, err := repo.PurgeExpiredRevokedTokens(now.Add(2 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestTwoFactorRepository(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewTwoFactorRepository(db)
+	require.NoError(t, err)
+
+	_, err = repo.GetTwoFactor("user1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.ErrorIs(t, repo.EnableTwoFactor("user1", nil), repository.ErrNotFound)
+
+	tf := entity.TwoFactor{UserID: "user1", Secret: "SECRET", CreatedAt: time.Now().UTC().Truncate(time.Microsecond)}
+	require.NoError(t, repo.SaveTwoFactor(tf))
+	require.NoError(t, repo.EnableTwoFactor("user1", []string{"h1", "h2"}))
+	got, err := repo.GetTwoFactor("user1")
+	require.NoError(t, err)
+	tf.Enabled = true
+	assert.Equal(t, tf, *got)
+
+	// Шаг TOTP и код восстановления принимаются только один раз.
+	ok, err := repo.UseTOTPStep("user1", 10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseTOTPStep("user1", 10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = repo.UseRecoveryCode("user1", "h1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseRecoveryCode("user1", "h1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestAccountRepository(t *testing.T) {
+	db := openTestDB(t)
+	users, err := NewUserRepository(db)
+	require.NoError(t, err)
+	items, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+	blobs, err := NewBlobRepository(db)
+	require.NoError(t, err)
+	repo, err := NewAccountRepository(db)
+	require.NoError(t, err)
+
+	for _, user := range []entity.User{
+		{ID: "user1", Username: "alice", Password: "hash", CreatedAt: time.Now()},
+		{ID: "user2", Username: "bob", Password: "hash", CreatedAt: time.Now()},
+	} {
+		require.NoError(t, users.SaveUser(user))
+		_, _, err := items.ApplyChanges(user.ID, []entity.DataItem{{ID: "item-" + user.ID, UpdatedAt: time.Now()}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, blobs.AddBlob("a", 1))
+	require.NoError(t, blobs.AddBlob("shared", 1))
+	require.NoError(t, blobs.SaveManifest(entity.FileManifest{UserID: "user1", FileID: "f1", Chunks: []string{"a", "shared"}}))
+	require.NoError(t, blobs.SaveManifest(entity.FileManifest{UserID: "user2", FileID: "f2", Chunks: []string{"shared"}}))
+
+	require.NoError(t, repo.DeleteAccount("user1"))
+	_, err = users.GetUserByID("user1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	got, err := items.GetUserItems("user1")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	_, err = blobs.GetManifest("user1", "f1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	// Чанки остаются до сборки мусора; удаляются только те, на которые не ссылаются файлы других пользователей.
+	missing, err := blobs.MissingBlobs([]string{"a", "shared"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	purged, err := blobs.PurgeUnreferencedBlobs(time.Now().Add(time.Minute), noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+
+	// Данные других пользователей не затронуты.
+	got, err = items.GetUserItems("user2")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+
+	assert.ErrorIs(t, repo.DeleteAccount("user1"), repository.ErrNotFound)
+}
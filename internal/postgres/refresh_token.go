@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// RefreshTokenRepository хранит токены обновления сессий в PostgreSQL.
+type RefreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository возвращает репозиторий токенов обновления.
+func NewRefreshTokenRepository(db *sql.DB) (*RefreshTokenRepository, error) {
+	return &RefreshTokenRepository{db: db}, nil
+}
+
+// SaveRefreshToken сохраняет новый токен.
+func (r *RefreshTokenRepository) SaveRefreshToken(token entity.RefreshToken) error {
+	return insertRefreshToken(r.db, token)
+}
+
+// GetRefreshToken возвращает токен по его хэшу или repository.ErrNotFound.
+func (r *RefreshTokenRepository) GetRefreshToken(tokenHash string) (*entity.RefreshToken, error) {
+	token := entity.RefreshToken{TokenHash: tokenHash}
+	err := r.db.QueryRow(`
+	SELECT user_id, username, family_id, expires_at, created_at, revoked FROM refresh_tokens WHERE token_hash = $1;
+	`, tokenHash).Scan(&token.UserID, &token.Username, &token.FamilyID, &token.ExpiresAt, &token.CreatedAt, &token.Revoked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	token.CreatedAt = token.CreatedAt.UTC()
+	return &token, nil
+}
+
+// RotateRefreshToken атомарно отзывает токен oldHash и сохраняет next.
+func (r *RefreshTokenRepository) RotateRefreshToken(oldHash string, next entity.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`UPDATE refresh_tokens SET revoked = TRUE WHERE token_hash = $1 AND NOT revoked;`, oldHash)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+		return repository.ErrNotFound
+	}
+	if err := insertRefreshToken(tx, next); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevokeRefreshTokenFamily отзывает все токены семейства.
+func (r *RefreshTokenRepository) RevokeRefreshTokenFamily(familyID string) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked = TRUE WHERE family_id = $1;`, familyID)
+	return err
+}
+
+// RevokeUserRefreshTokens отзывает все токены пользователя.
+func (r *RefreshTokenRepository) RevokeUserRefreshTokens(userID string) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revoked = TRUE WHERE user_id = $1;`, userID)
+	return err
+}
+
+// PurgeExpiredRefreshTokens удаляет токены, срок действия которых истёк раньше before.
+func (r *RefreshTokenRepository) PurgeExpiredRefreshTokens(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expires_at < $1;`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// execer — общий интерфейс *sql.DB и *sql.Tx для выполнения запросов.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertRefreshToken добавляет токен в таблицу.
+func insertRefreshToken(db execer, token entity.RefreshToken) error {
+	_, err := db.Exec(`
+	INSERT INTO refresh_tokens (token_hash, user_id, username, family_id, expires_at, created_at, revoked)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`, token.TokenHash, token.UserID, token.Username, token.FamilyID, token.ExpiresAt.UTC(), token.CreatedAt.UTC(), token.Revoked)
+	return err
+}
@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// uniqueViolation — код ошибки PostgreSQL при нарушении ограничения уникальности.
+const uniqueViolation = "23505"
+
+// UserRepository реализует операции для работы с пользователями в базе PostgreSQL.
+type UserRepository struct {
+	db *sql.DB
+}
+
+// NewUserRepository возвращает репозиторий пользователей.
+func NewUserRepository(db *sql.DB) (*UserRepository, error) {
+	return &UserRepository{db: db}, nil
+}
+
+// SaveUser добавляет нового пользователя. Если имя занято, возвращается repository.ErrUsernameTaken.
+func (r *UserRepository) SaveUser(user entity.User) error {
+	_, err := r.db.Exec(`
+	INSERT INTO users (id, username, password, created_at)
+	VALUES ($1, $2, $3, $4);
+	`, user.ID, user.Username, user.Password, user.CreatedAt.UTC())
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation && pgErr.ConstraintName == "users_username_key" {
+		return repository.ErrUsernameTaken
+	}
+	return err
+}
+
+// GetUserByUsername возвращает пользователя по имени.
+func (r *UserRepository) GetUserByUsername(username string) (*entity.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT id, username, password, created_at FROM users WHERE username = $1;`, username))
+}
+
+// GetUserByID возвращает пользователя по идентификатору.
+func (r *UserRepository) GetUserByID(id string) (*entity.User, error) {
+	return scanUser(r.db.QueryRow(`SELECT id, username, password, created_at FROM users WHERE id = $1;`, id))
+}
+
+// UpdatePassword заменяет хэш пароля пользователя.
+func (r *UserRepository) UpdatePassword(userID, passwordHash string) error {
+	res, err := r.db.Exec(`UPDATE users SET password = $1 WHERE id = $2;`, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// scanUser читает пользователя из строки результата запроса. Если строки нет, возвращается repository.ErrNotFound.
+func scanUser(row *sql.Row) (*entity.User, error) {
+	var user entity.User
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	user.CreatedAt = user.CreatedAt.UTC()
+	return &user, nil
+}
@@ -0,0 +1,101 @@
+// Package profile читает именованные профили клиента: адреса серверов, настройки TLS, путь к локальной базе
+// и файлу сессии. Профили позволяют одному пользователю держать, например, личное и командное хранилища.
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EnvConfigFile — переменная окружения с путём к файлу профилей.
+const EnvConfigFile = "GOPHKEEPER_CONFIG"
+
+// Profile — настройки подключения клиента к одному серверу. Пустые поля заменяются значениями по умолчанию.
+type Profile struct {
+	Server     string `json:"server,omitempty"`      // Адрес HTTP-сервера, https:// включает TLS
+	GrpcServer string `json:"grpc_server,omitempty"` // Адрес gRPC-сервера host:port
+	TLSCA      string `json:"tls_ca,omitempty"`      // PEM-файл с сертификатами доверенных УЦ
+	TLSPin     string `json:"tls_pin,omitempty"`     // SHA-256-отпечаток закреплённого сертификата сервера
+	DB         string `json:"db,omitempty"`          // Путь к локальной базе BoltDB
+	Session    string `json:"session,omitempty"`     // Путь к файлу сессии
+}
+
+// File — содержимое файла профилей.
+type File struct {
+	// Default — профиль, который используется без флага -profile.
+	Default  string             `json:"default,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// DefaultPath возвращает путь к файлу профилей: из переменной GOPHKEEPER_CONFIG
+// или gophkeeper/profiles.json в пользовательской директории настроек.
+func DefaultPath() string {
+	if path := os.Getenv(EnvConfigFile); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "gophkeeper", "profiles.json")
+}
+
+// Load читает файл профилей. Отсутствующий файл не ошибка: возвращается пустой набор профилей.
+func Load(path string) (*File, error) {
+	f := &File{Profiles: map[string]Profile{}}
+	if path == "" {
+		return f, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles %s: %w", path, err)
+	}
+	if f.Profiles == nil {
+		f.Profiles = map[string]Profile{}
+	}
+	return f, nil
+}
+
+// Get возвращает профиль name; пустое имя означает профиль по умолчанию, если он задан.
+// Локальная база и файл сессии, не указанные в профиле, по умолчанию лежат в data/<name>,
+// чтобы хранилища разных профилей не смешивались.
+func (f *File) Get(name string) (Profile, string, error) {
+	if name == "" {
+		name = f.Default
+	}
+	if name == "" {
+		return Profile{}, "", nil
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return Profile{}, "", fmt.Errorf("profile %q not found", name)
+	}
+	if p.DB == "" {
+		p.DB = filepath.Join("data", name, "client.db")
+	}
+	if p.Session == "" {
+		p.Session = filepath.Join("data", name, "session.json")
+	}
+	return p, name, nil
+}
+
+// Names возвращает имена профилей в алфавитном порядке.
+func (f *File) Names() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
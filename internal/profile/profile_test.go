@@ -0,0 +1,61 @@
+package profile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"default": "personal",
+		"profiles": {
+			"personal": {"server": "http://127.0.0.1:8080"},
+			"work": {
+				"server": "https://staging.example.com:8443",
+				"grpc_server": "staging.example.com:50051",
+				"tls_pin": "ab:cd",
+				"db": "/var/lib/gophkeeper/work.db",
+				"session": "/var/lib/gophkeeper/work-session.json"
+			}
+		}
+	}`), 0600))
+
+	f, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"personal", "work"}, f.Names())
+
+	p, name, err := f.Get("work")
+	require.NoError(t, err)
+	assert.Equal(t, "work", name)
+	assert.Equal(t, "staging.example.com:50051", p.GrpcServer)
+	assert.Equal(t, "/var/lib/gophkeeper/work.db", p.DB)
+
+	// Без имени выбирается профиль по умолчанию, база и сессия по умолчанию отдельные для профиля.
+	p, name, err = f.Get("")
+	require.NoError(t, err)
+	assert.Equal(t, "personal", name)
+	assert.Equal(t, filepath.Join("data", "personal", "client.db"), p.DB)
+	assert.Equal(t, filepath.Join("data", "personal", "session.json"), p.Session)
+
+	_, _, err = f.Get("missing")
+	assert.Error(t, err)
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "profiles.json"))
+	require.NoError(t, err)
+	p, name, err := f.Get("")
+	require.NoError(t, err)
+	assert.Empty(t, name)
+	assert.Equal(t, Profile{}, p)
+
+	path := filepath.Join(t.TempDir(), "broken.json")
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0600))
+	_, err = Load(path)
+	assert.Error(t, err)
+}
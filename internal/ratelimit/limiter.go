@@ -0,0 +1,86 @@
+// Package ratelimit ограничивает частоту запросов с одного адреса и блокирует вход в учётную запись
+// после серии неудачных попыток. Состояние хранится в памяти процесса, поэтому при запуске нескольких
+// экземпляров сервера лимиты действуют для каждого экземпляра отдельно.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто из памяти удаляются состояния неактивных ключей.
+const sweepInterval = time.Minute
+
+// Limiter ограничивает частоту запросов по ключу (как правило, IP-адресу) алгоритмом token bucket:
+// ключ может сделать до burst запросов подряд, после чего запросы разрешаются с частотой perMinute в минуту.
+// Нулевой *Limiter ничего не ограничивает.
+type Limiter struct {
+	mu        sync.Mutex
+	rate      float64 // Пополнение корзины в токенах за секунду
+	burst     float64
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// bucket — корзина токенов одного ключа.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter создаёт ограничитель на perMinute запросов в минуту с допустимым всплеском burst.
+// Если perMinute не положителен, ограничение отключено и возвращается nil.
+func NewLimiter(perMinute, burst int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow расходует токен ключа. Если токенов нет, возвращает false и время до появления следующего.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep удаляет корзины, которые успели заполниться полностью: их состояние не отличается от нового ключа.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
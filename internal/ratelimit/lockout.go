@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout блокирует вход в учётную запись после maxFailures неудачных попыток подряд.
+// Каждая следующая блокировка вдвое длиннее предыдущей, но не длиннее maxDuration. Счётчик блокировок
+// сбрасывается успешным входом или через maxDuration без неудачных попыток. Нулевой *Lockout ничего не блокирует.
+type Lockout struct {
+	mu          sync.Mutex
+	maxFailures int
+	base        time.Duration
+	maxDuration time.Duration
+	entries     map[string]*lockEntry
+	lastSweep   time.Time
+	now         func() time.Time
+}
+
+// lockEntry — неудачные попытки входа в одну учётную запись.
+type lockEntry struct {
+	failures    int       // Неудачные попытки после последней блокировки
+	lockouts    int       // Блокировки подряд, определяют длительность следующей
+	lockedUntil time.Time // Окончание текущей блокировки
+	lastFailure time.Time
+}
+
+// NewLockout создаёт блокировку после maxFailures неудачных попыток на время от base до maxDuration.
+// Если maxFailures или base не положительны, блокировка отключена и возвращается nil.
+func NewLockout(maxFailures int, base, maxDuration time.Duration) *Lockout {
+	if maxFailures <= 0 || base <= 0 {
+		return nil
+	}
+	if maxDuration < base {
+		maxDuration = base
+	}
+	return &Lockout{
+		maxFailures: maxFailures,
+		base:        base,
+		maxDuration: maxDuration,
+		entries:     make(map[string]*lockEntry),
+		now:         time.Now,
+	}
+}
+
+// Locked возвращает оставшееся время блокировки ключа или ноль, если вход разрешён.
+func (l *Lockout) Locked(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.entries[key]
+	if !ok {
+		return 0
+	}
+	if remaining := e.lockedUntil.Sub(l.now()); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// Fail учитывает неудачную попытку входа. Если она исчерпала лимит, ключ блокируется,
+// и возвращается длительность блокировки.
+func (l *Lockout) Fail(key string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+	e, ok := l.entries[key]
+	if !ok || (now.After(e.lockedUntil) && now.Sub(e.lastFailure) > l.maxDuration) {
+		e = &lockEntry{}
+		l.entries[key] = e
+	}
+	e.lastFailure = now
+	e.failures++
+	if e.failures < l.maxFailures {
+		return 0
+	}
+	duration := l.base << e.lockouts
+	if duration > l.maxDuration || duration <= 0 {
+		duration = l.maxDuration
+	} else {
+		e.lockouts++
+	}
+	e.failures = 0
+	e.lockedUntil = now.Add(duration)
+	return duration
+}
+
+// Reset забывает неудачные попытки ключа после успешного входа.
+func (l *Lockout) Reset(key string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.entries, key)
+}
+
+// sweep удаляет ключи без блокировки, неудачные попытки которых старше maxDuration.
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, e := range l.entries {
+		if now.After(e.lockedUntil) && now.Sub(e.lastFailure) > l.maxDuration {
+			delete(l.entries, key)
+		}
+	}
+}
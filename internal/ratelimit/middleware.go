@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware ограничивает частоту HTTP-запросов с одного IP-адреса и отвечает 429 с заголовком
+// Retry-After при превышении. Адрес берётся из соединения: заголовкам X-Forwarded-For без доверенного
+// прокси верить нельзя, иначе клиент обойдёт ограничение, подставляя произвольные адреса.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := l.Allow(hostOf(r.RemoteAddr)); !ok {
+			TooManyRequests(w, wait, "Too many requests")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryInterceptor ограничивает частоту унарных gRPC-вызовов с одного IP-адреса.
+func (l *Limiter) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{},
+		info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.allowPeer(ctx); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor ограничивает частоту открытия gRPC-стримов с одного IP-адреса.
+func (l *Limiter) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream,
+		info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.allowPeer(stream.Context()); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// allowPeer расходует токен адреса gRPC-клиента и при превышении возвращает ошибку ResourceExhausted.
+func (l *Limiter) allowPeer(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	key := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		key = hostOf(p.Addr.String())
+	}
+	if ok, wait := l.Allow(key); !ok {
+		return status.Errorf(codes.ResourceExhausted, "too many requests, retry in %d s", retryAfterSeconds(wait))
+	}
+	return nil
+}
+
+// TooManyRequests отвечает 429 с заголовком Retry-After.
+func TooManyRequests(w http.ResponseWriter, wait time.Duration, message string) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+	http.Error(w, message, http.StatusTooManyRequests)
+}
+
+// retryAfterSeconds округляет время ожидания вверх до целых секунд, но не меньше одной.
+func retryAfterSeconds(wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// hostOf возвращает IP-адрес из адреса вида host:port.
+func hostOf(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock — управляемые тестом часы.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestLimiter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	l := NewLimiter(60, 2)
+	l.now = clock.Now
+
+	// Всплеск в пределах burst разрешён, дальше — не чаще раза в секунду.
+	ok, _ := l.Allow("1.1.1.1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("1.1.1.1")
+	assert.True(t, ok)
+	ok, wait := l.Allow("1.1.1.1")
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	// Другие адреса ограничиваются независимо.
+	ok, _ = l.Allow("2.2.2.2")
+	assert.True(t, ok)
+
+	clock.now = clock.now.Add(time.Second)
+	ok, _ = l.Allow("1.1.1.1")
+	assert.True(t, ok)
+
+	// Заполнившиеся корзины удаляются из памяти.
+	clock.now = clock.now.Add(time.Hour)
+	l.Allow("3.3.3.3")
+	assert.Len(t, l.buckets, 1)
+
+	// Отключённый ограничитель пропускает всё.
+	var disabled *Limiter = NewLimiter(0, 10)
+	assert.Nil(t, disabled)
+	ok, _ = disabled.Allow("1.1.1.1")
+	assert.True(t, ok)
+}
+
+func TestLockout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	l := NewLockout(3, time.Minute, 3*time.Minute)
+	l.now = clock.Now
+
+	assert.Zero(t, l.Fail("alice"))
+	assert.Zero(t, l.Fail("alice"))
+	assert.Equal(t, time.Minute, l.Fail("alice"))
+	assert.Equal(t, time.Minute, l.Locked("alice"))
+	assert.Zero(t, l.Locked("bob"))
+
+	// Каждая следующая блокировка вдвое длиннее, но не длиннее максимума.
+	clock.now = clock.now.Add(time.Minute)
+	assert.Zero(t, l.Locked("alice"))
+	l.Fail("alice")
+	l.Fail("alice")
+	assert.Equal(t, 2*time.Minute, l.Fail("alice"))
+	clock.now = clock.now.Add(2 * time.Minute)
+	l.Fail("alice")
+	l.Fail("alice")
+	assert.Equal(t, 3*time.Minute, l.Fail("alice"))
+
+	// Успешный вход сбрасывает счётчики.
+	clock.now = clock.now.Add(3 * time.Minute)
+	l.Reset("alice")
+	l.Fail("alice")
+	l.Fail("alice")
+	assert.Equal(t, time.Minute, l.Fail("alice"))
+
+	// Без неудачных попыток дольше максимума блокировки начинаются заново.
+	clock.now = clock.now.Add(10 * time.Minute)
+	l.Fail("alice")
+	l.Fail("alice")
+	assert.Equal(t, time.Minute, l.Fail("alice"))
+}
+
+func TestMiddleware(t *testing.T) {
+	l := NewLimiter(1, 1)
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	request := func() *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "/login", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+	res := request()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	// Другой порт того же адреса ограничение не обходит.
+	res = request()
+	defer res.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal(t, "60", res.Header.Get("Retry-After"))
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	interceptor := NewLimiter(1, 1).UnaryInterceptor()
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 5000}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	resp, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
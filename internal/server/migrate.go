@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/internal/config"
+	"github.com/andranikuz/gophkeeper/internal/migrations"
+)
+
+// Migrate выполняет команду управления миграциями базы данных:
+// up — применить все новые миграции, down — откатить последнюю, status — показать состояние.
+func Migrate(cfg *config.Config, command string, out io.Writer) error {
+	db, dialect, err := OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			fmt.Fprintf(out, "Applied %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "No new migrations")
+		}
+	case "down":
+		migration, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		if migration == nil {
+			fmt.Fprintln(out, "No applied migrations")
+			return nil
+		}
+		fmt.Fprintf(out, "Reverted %d_%s\n", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Version\tName\tApplied At")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %q: expected up, down or status", command)
+	}
+	return nil
+}
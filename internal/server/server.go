@@ -2,98 +2,546 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
 	"net"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/andranikuz/gophkeeper/internal/account"
 	"github.com/andranikuz/gophkeeper/internal/auth"
+	"github.com/andranikuz/gophkeeper/internal/blobstore"
 	"github.com/andranikuz/gophkeeper/internal/config"
 	pb "github.com/andranikuz/gophkeeper/internal/filesync"
 	"github.com/andranikuz/gophkeeper/internal/grpcserver"
 	"github.com/andranikuz/gophkeeper/internal/handlers"
+	"github.com/andranikuz/gophkeeper/internal/healthcheck"
+	"github.com/andranikuz/gophkeeper/internal/metrics"
+	"github.com/andranikuz/gophkeeper/internal/migrations"
+	"github.com/andranikuz/gophkeeper/internal/postgres"
+	"github.com/andranikuz/gophkeeper/internal/ratelimit"
 	"github.com/andranikuz/gophkeeper/internal/sqlite"
+	"github.com/andranikuz/gophkeeper/internal/tlsutil"
 	"github.com/andranikuz/gophkeeper/pkg/logger"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// tombstoneGCInterval — период сборки устаревших надгробий и чанков без ссылок.
+const tombstoneGCInterval = time.Hour
+
+// blobGracePeriod — сколько хранится чанк без ссылок: за это время незавершённую загрузку можно продолжить.
+const blobGracePeriod = 24 * time.Hour
+
+// legacyFilesDir — директория файлов, загруженных до появления хранилища блобов.
+const legacyFilesDir = "./data/server_files"
+
+// healthCheckInterval — период обновления статуса gRPC-сервиса здоровья,
+// healthCheckTimeout — сколько ждать ответа базы данных и хранилища при проверке.
+const (
+	healthCheckInterval = 10 * time.Second
+	healthCheckTimeout  = 5 * time.Second
+)
+
+// totpIssuer — название сервиса, под которым аккаунт отображается в приложении-аутентификаторе.
+const totpIssuer = "GophKeeper"
+
+// selfSignedCertFile и selfSignedKeyFile — куда сохраняется самоподписанный сертификат, если пути не заданы.
+const (
+	selfSignedCertFile = "./data/tls/server.crt"
+	selfSignedKeyFile  = "./data/tls/server.key"
 )
 
 // Server реализует сервер.
 type Server struct {
-	handler    *handlers.Handler
-	grpcServer *grpc.Server
-	cfg        *config.Config
-	ctx        context.Context
+	handler      *handlers.Handler
+	grpcServer   *grpc.Server
+	tlsConfig    *tls.Config
+	dataItemRepo repository.DataItemRepository
+	blobRepo     repository.BlobRepository
+	refreshRepo  repository.RefreshTokenRepository
+	revokedRepo  repository.RevokedTokenRepository
+	blobStore    *blobstore.Store
+	readiness    *healthcheck.Checker
+	healthServer *health.Server
+	db           *sql.DB
+	cfg          *config.Config
+	ctx          context.Context
 }
 
-// NewServer создаёт новый экземпляр Server.
-func NewServer(ctx context.Context) (*Server, error) {
-	// Инициализируем конфиг.
-	cfg := config.LoadConfig()
-	// Инициализируем базу данных sqlite.
-	db, err := InitDB(cfg.DBPath)
+// NewServer создаёт новый экземпляр Server. Отмена ctx останавливает запущенный сервер, см. Run.
+func NewServer(ctx context.Context, cfg *config.Config) (*Server, error) {
+	credentialPolicy, err := cfg.CredentialPolicy()
 	if err != nil {
 		return nil, err
 	}
-	// Инициализируем репозитории.
-	dataItemRepo, err := sqlite.NewDataItemRepository(db)
+	tlsConfig, err := newTLSConfig(cfg)
 	if err != nil {
 		return nil, err
 	}
-	userRepo, err := sqlite.NewUserRepository(db)
+	// Метрики собираются, только если их можно забрать с /metrics.
+	var serverMetrics *metrics.Metrics
+	if cfg.Metrics {
+		serverMetrics = metrics.New()
+	}
+	// Инициализируем базу данных и репозитории.
+	repos, err := newRepositories(cfg, serverMetrics)
 	if err != nil {
 		return nil, err
 	}
+	blobStorage, err := newBlobStorage(cfg)
+	if err != nil {
+		repos.db.Close()
+		return nil, err
+	}
+	blobStore := blobstore.New(blobStorage)
 	// Инициализируем модуль аутентификации.
-	authManager := auth.NewAuthenticator(cfg.TokenSecret, cfg.TokenExpiration)
+	authManager := auth.NewAuthenticator(cfg.TokenSecret, cfg.TokenExpiration).
+		WithRefreshTokens(repos.refreshTokens, time.Duration(cfg.RefreshTokenExp)*time.Hour).
+		WithDenylist(repos.revokedTokens)
 	// Инициализируем http хендлеры.
-	handler := handlers.NewHandler(dataItemRepo, userRepo, authManager)
-	// Создаем gRPC сервер с интерсепторами авторизации.
-	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(grpcserver.JwtUnaryInterceptor(authManager)),
-		grpc.StreamInterceptor(grpcserver.JwtStreamInterceptor(authManager)),
-	)
-	fileSyncSvc := grpcserver.NewFileSyncServiceServer("./data/server_files", dataItemRepo, authManager)
+	handler := handlers.NewHandler(repos.dataItems, repos.users, authManager)
+	handler.Policy = credentialPolicy
+	handler.Metrics = serverMetrics
+	handler.TwoFactor = auth.NewTwoFactor(repos.twoFactor, totpIssuer)
+	handler.Account = account.NewService(repos.accounts, repos.users, repos.dataItems, repos.blobs, blobStore, legacyFilesDir)
+	handler.RateLimiter = ratelimit.NewLimiter(cfg.AuthRateLimit, cfg.AuthRateBurst)
+	handler.LoginLockout = ratelimit.NewLockout(cfg.LoginMaxFailures,
+		time.Duration(cfg.LoginLockout)*time.Second, time.Duration(cfg.LoginLockoutMax)*time.Second)
+	// Создаем gRPC сервер с интерсепторами авторизации. Ограничение частоты проверяется
+	// до авторизации, чтобы перебор токенов тоже упирался в лимит. Метрики учитывают все вызовы,
+	// включая отклонённые лимитом и авторизацией.
+	grpcLimiter := ratelimit.NewLimiter(cfg.GrpcRateLimit, cfg.GrpcRateBurst)
+	grpcOptions := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(serverMetrics.UnaryInterceptor(),
+			grpcLimiter.UnaryInterceptor(), grpcserver.JwtUnaryInterceptor(authManager)),
+		grpc.ChainStreamInterceptor(serverMetrics.StreamInterceptor(),
+			grpcLimiter.StreamInterceptor(), grpcserver.JwtStreamInterceptor(authManager)),
+	}
+	if tlsConfig != nil {
+		grpcOptions = append(grpcOptions, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcServer := grpc.NewServer(grpcOptions...)
+	fileSyncSvc := grpcserver.NewFileSyncServiceServer(legacyFilesDir, repos.dataItems, repos.blobs, blobStore, authManager, serverMetrics)
 	pb.RegisterFileSyncServiceServer(grpcServer, fileSyncSvc)
+	// Готовность сервера определяется доступностью базы данных и хранилища файлов.
+	readiness := healthcheck.NewChecker(healthCheckTimeout)
+	readiness.Add("database", healthcheck.Database(repos.db))
+	readiness.Add("storage", healthcheck.Storage(blobStorage))
+	handler.Readiness = readiness
+	// Watch обновляет результат каждые healthCheckInterval; запас покрывает время самого запуска.
+	handler.ReadinessMaxAge = 2 * healthCheckInterval
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
 
 	return &Server{
-		ctx:        ctx,
-		cfg:        cfg,
-		handler:    handler,
-		grpcServer: grpcServer,
+		ctx:          ctx,
+		cfg:          cfg,
+		handler:      handler,
+		grpcServer:   grpcServer,
+		tlsConfig:    tlsConfig,
+		dataItemRepo: repos.dataItems,
+		blobRepo:     repos.blobs,
+		refreshRepo:  repos.refreshTokens,
+		revokedRepo:  repos.revokedTokens,
+		blobStore:    blobStore,
+		readiness:    readiness,
+		healthServer: healthServer,
+		db:           repos.db,
 	}, nil
 }
 
+// newTLSConfig загружает сертификат сервера. Если сертификат не задан и самоподписанный не запрошен,
+// возвращается nil: серверы работают без TLS.
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certFile, keyFile := cfg.TLSCert, cfg.TLSKey
+	if cfg.TLSSelfSigned {
+		if certFile == "" && keyFile == "" {
+			certFile, keyFile = selfSignedCertFile, selfSignedKeyFile
+		}
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("both -tls-cert and -tls-key must be set")
+		}
+		if err := tlsutil.EnsureSelfSigned(certFile, keyFile, []string{cfg.Host}); err != nil {
+			return nil, fmt.Errorf("failed to create self-signed certificate: %w", err)
+		}
+	}
+	if certFile == "" && keyFile == "" {
+		logger.InfoLogger.Printf("TLS is disabled: passwords and tokens are sent in cleartext")
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("both -tls-cert and -tls-key must be set")
+	}
+	tlsConfig, err := tlsutil.ServerConfig(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	// Отпечаток нужен клиентам, закрепляющим сертификат флагом -tls-pin.
+	leaf, err := x509.ParseCertificate(tlsConfig.Certificates[0].Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %w", err)
+	}
+	logger.InfoLogger.Printf("TLS certificate %s, SHA-256 fingerprint %s", certFile, tlsutil.Fingerprint(leaf))
+	return tlsConfig, nil
+}
+
+// Run запускает HTTP- и gRPC-серверы и блокируется, пока не будет отменён контекст сервера
+// или один из серверов не завершится с ошибкой. Затем серверы перестают принимать соединения,
+// текущие запросы и передачи файлов завершаются в пределах -shutdown-timeout, после чего
+// закрывается база данных. Возвращается ошибка сервера, из-за которой пришлось остановиться.
 func (s Server) Run() error {
-	// Формируем адрес для HTTP-сервера.
-	httpAddr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.Port)
-	// Запускаем HTTP-сервер в горутине.
-	go func() {
-		logger.InfoLogger.Printf("HTTP server started on %s", httpAddr)
-		if err := http.ListenAndServe(httpAddr, s.handler.RegisterRoutes()); err != nil {
-			log.Fatalf("HTTP server error: %v", err)
+	defer func() {
+		if err := s.db.Close(); err != nil {
+			logger.ErrorLogger.Printf("Failed to close database: %v", err)
 		}
 	}()
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
 
-	// Формируем адрес для gRPC-сервера.
+	// Формируем адрес для gRPC-сервера. Порт занимается до запуска горутин, чтобы ошибка вернулась сразу.
 	grpcAddr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.GrpcPort)
 	lis, err := net.Listen("tcp", grpcAddr)
 	if err != nil {
 		return fmt.Errorf("listener error: %w", err)
 	}
-	logger.InfoLogger.Printf("gRPC server started on %s", grpcAddr)
-	if err := s.grpcServer.Serve(lis); err != nil {
-		return fmt.Errorf("gRPC server error: %w", err)
+	// Формируем адрес для HTTP-сервера.
+	httpAddr := s.cfg.Host + ":" + strconv.Itoa(s.cfg.Port)
+	httpServer := &http.Server{
+		Addr:      httpAddr,
+		Handler:   s.handler.RegisterRoutes(),
+		TLSConfig: s.tlsConfig,
+	}
+
+	// Запускаем сборку устаревших надгробий.
+	var background sync.WaitGroup
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		s.collectTombstones(ctx)
+	}()
+	// Статус grpc.health.v1 обновляется по тем же проверкам, что и /readyz.
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		s.readiness.Watch(ctx, s.healthServer, healthCheckInterval, "", pb.FileSyncService_ServiceDesc.ServiceName)
+	}()
+
+	errs := make(chan error, 2)
+	go func() {
+		var err error
+		if s.tlsConfig != nil {
+			logger.InfoLogger.Printf("HTTPS server started on %s", httpAddr)
+			// Сертификат уже загружен в TLSConfig, поэтому пути к файлам не передаются.
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			logger.InfoLogger.Printf("HTTP server started on %s without TLS", httpAddr)
+			err = httpServer.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs <- fmt.Errorf("HTTP server error: %w", err)
+		}
+	}()
+	go func() {
+		logger.InfoLogger.Printf("gRPC server started on %s", grpcAddr)
+		if err := s.grpcServer.Serve(lis); err != nil {
+			errs <- fmt.Errorf("gRPC server error: %w", err)
+		}
+	}()
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		logger.InfoLogger.Printf("Shutting down")
+	case runErr = <-errs:
+		logger.ErrorLogger.Printf("Shutting down: %v", runErr)
+	}
+	cancel()
+	s.shutdown(httpServer)
+	background.Wait()
+	logger.InfoLogger.Printf("Server stopped")
+	return runErr
+}
+
+// shutdown останавливает HTTP- и gRPC-серверы, дожидаясь завершения текущих запросов и потоков
+// не дольше -shutdown-timeout. Оставшиеся после этого соединения закрываются принудительно.
+func (s Server) shutdown(httpServer *http.Server) {
+	// Клиенты grpc.health.v1 сразу узнают, что сервер останавливается.
+	s.healthServer.Shutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.cfg.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	httpDone := make(chan struct{})
+	go func() {
+		defer close(httpDone)
+		if err := httpServer.Shutdown(ctx); err != nil {
+			logger.ErrorLogger.Printf("HTTP requests did not finish in time, closing connections: %v", err)
+			httpServer.Close()
+		}
+	}()
+	grpcDone := make(chan struct{})
+	go func() {
+		defer close(grpcDone)
+		s.grpcServer.GracefulStop()
+	}()
+	select {
+	case <-grpcDone:
+	case <-ctx.Done():
+		logger.ErrorLogger.Printf("gRPC streams did not finish in time, closing them")
+		s.grpcServer.Stop()
+		<-grpcDone
+	}
+	<-httpDone
+}
+
+// collectTombstones периодически удаляет надгробия, срок хранения которых истёк, чанки без ссылок
+// и истёкшие токены.
+// К этому моменту удаление должно было распространиться на все устройства пользователя.
+func (s Server) collectTombstones(ctx context.Context) {
+	retention := time.Duration(s.cfg.TombstoneRetention) * time.Hour
+	ticker := time.NewTicker(tombstoneGCInterval)
+	defer ticker.Stop()
+	for {
+		n, err := s.dataItemRepo.PurgeDeletedItems(time.Now().Add(-retention))
+		if err != nil {
+			logger.ErrorLogger.Printf("Failed to purge tombstones: %v", err)
+		} else if n > 0 {
+			logger.InfoLogger.Printf("Purged %d tombstones", n)
+		}
+		s.collectBlobs()
+		s.collectExpiredTokens()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collectBlobs удаляет чанки, на которые не ссылается ни один файл дольше blobGracePeriod.
+// Данные чанка удаляются до того, как освобождается запись о нём, поэтому одновременная загрузка
+// того же чанка не может остаться без данных.
+func (s Server) collectBlobs() {
+	hashes, err := s.blobRepo.PurgeUnreferencedBlobs(time.Now().Add(-blobGracePeriod), s.blobStore.Delete)
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to purge unreferenced blobs: %v", err)
+	}
+	if len(hashes) > 0 {
+		logger.InfoLogger.Printf("Purged %d unreferenced blobs", len(hashes))
+	}
+}
+
+// collectExpiredTokens удаляет истёкшие токены обновления и истёкшие токены из списка отозванных.
+func (s Server) collectExpiredTokens() {
+	n, err := s.refreshRepo.PurgeExpiredRefreshTokens(time.Now())
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to purge expired refresh tokens: %v", err)
+	} else if n > 0 {
+		logger.InfoLogger.Printf("Purged %d expired refresh tokens", n)
+	}
+	n, err = s.revokedRepo.PurgeExpiredRevokedTokens(time.Now())
+	if err != nil {
+		logger.ErrorLogger.Printf("Failed to purge expired revoked tokens: %v", err)
+	} else if n > 0 {
+		logger.InfoLogger.Printf("Purged %d expired revoked tokens", n)
+	}
+}
+
+// newBlobStorage создаёт хранилище чанков файлов, выбранное в конфигурации.
+func newBlobStorage(cfg *config.Config) (repository.BlobStorage, error) {
+	switch cfg.Storage {
+	case "fs":
+		return blobstore.NewFSStorage(cfg.StorageDir)
+	case "s3":
+		return blobstore.NewS3Storage(blobstore.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage %q: expected fs or s3", cfg.Storage)
+	}
+}
+
+// repositories объединяет репозитории выбранной базы данных.
+type repositories struct {
+	db            *sql.DB
+	dataItems     repository.DataItemRepository
+	users         repository.UserRepository
+	blobs         repository.BlobRepository
+	refreshTokens repository.RefreshTokenRepository
+	revokedTokens repository.RevokedTokenRepository
+	twoFactor     repository.TwoFactorRepository
+	accounts      repository.AccountRepository
+}
+
+// newRepositories открывает базу данных, выбранную в конфигурации, применяет к ней миграции
+// и создаёт репозитории. Если m задан, в нём измеряется время запросов к базе.
+func newRepositories(cfg *config.Config, m *metrics.Metrics) (*repositories, error) {
+	db, dialect, err := openDB(cfg, m)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	applied, err := migrator.Up()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	for _, migration := range applied {
+		logger.InfoLogger.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+	var repos *repositories
+	if dialect == migrations.Postgres {
+		repos, err = newPostgresRepositories(db)
+	} else {
+		repos, err = newSQLiteRepositories(db)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	repos.db = db
+	return repos, nil
+}
+
+// OpenDB открывает базу данных, выбранную в конфигурации, и возвращает её вместе с диалектом SQL.
+func OpenDB(cfg *config.Config) (*sql.DB, string, error) {
+	return openDB(cfg, nil)
+}
+
+// openDB открывает базу данных, как OpenDB, и измеряет время запросов к ней в m, если он задан.
+func openDB(cfg *config.Config, m *metrics.Metrics) (*sql.DB, string, error) {
+	switch cfg.DBDriver {
+	case "sqlite":
+		db, err := initSQLite(cfg.DBPath, m)
+		if err != nil {
+			return nil, "", err
+		}
+		return db, migrations.SQLite, nil
+	case "postgres":
+		if cfg.DBDSN == "" {
+			return nil, "", fmt.Errorf("-db-dsn must be set for postgres")
+		}
+		db, err := m.OpenDB(postgres.DriverName, cfg.DBDSN)
+		if err != nil {
+			return nil, "", err
+		}
+		if err := db.Ping(); err != nil {
+			db.Close()
+			return nil, "", fmt.Errorf("failed to connect to postgres: %w", err)
+		}
+		return db, migrations.Postgres, nil
+	default:
+		return nil, "", fmt.Errorf("unknown db driver %q: expected sqlite or postgres", cfg.DBDriver)
+	}
+}
+
+// newSQLiteRepositories создаёт репозитории поверх базы SQLite.
+func newSQLiteRepositories(db *sql.DB) (*repositories, error) {
+	dataItemRepo, err := sqlite.NewDataItemRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	userRepo, err := sqlite.NewUserRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	blobRepo, err := sqlite.NewBlobRepository(db)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	refreshTokenRepo, err := sqlite.NewRefreshTokenRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	revokedTokenRepo, err := sqlite.NewRevokedTokenRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	twoFactorRepo, err := sqlite.NewTwoFactorRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	accountRepo, err := sqlite.NewAccountRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories{
+		dataItems:     dataItemRepo,
+		users:         userRepo,
+		blobs:         blobRepo,
+		refreshTokens: refreshTokenRepo,
+		revokedTokens: revokedTokenRepo,
+		twoFactor:     twoFactorRepo,
+		accounts:      accountRepo,
+	}, nil
+}
+
+// newPostgresRepositories создаёт репозитории поверх базы PostgreSQL.
+func newPostgresRepositories(db *sql.DB) (*repositories, error) {
+	dataItemRepo, err := postgres.NewDataItemRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	userRepo, err := postgres.NewUserRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	blobRepo, err := postgres.NewBlobRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	refreshTokenRepo, err := postgres.NewRefreshTokenRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	revokedTokenRepo, err := postgres.NewRevokedTokenRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	twoFactorRepo, err := postgres.NewTwoFactorRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	accountRepo, err := postgres.NewAccountRepository(db)
+	if err != nil {
+		return nil, err
+	}
+	return &repositories{
+		dataItems:     dataItemRepo,
+		users:         userRepo,
+		blobs:         blobRepo,
+		refreshTokens: refreshTokenRepo,
+		revokedTokens: revokedTokenRepo,
+		twoFactor:     twoFactorRepo,
+		accounts:      accountRepo,
+	}, nil
 }
 
 // InitDB открывает базу SQLite по заданному пути.
 func InitDB(path string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	return initSQLite(path, nil)
+}
+
+// initSQLite открывает базу SQLite и измеряет время запросов к ней в m, если он задан.
+func initSQLite(path string, m *metrics.Metrics) (*sql.DB, error) {
+	db, err := m.OpenDB("sqlite3", path)
 	if err != nil {
 		return nil, err
 	}
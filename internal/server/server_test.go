@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/internal/config"
+)
+
+// newTestServer создаёт сервер на SQLite во временной директории, которая становится рабочей:
+// сервер создаёт служебные директории относительно неё.
+func newTestServer(t *testing.T, ctx context.Context, port int) *Server {
+	t.Helper()
+	dir := t.TempDir()
+	oldWD, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	t.Cleanup(func() { os.Chdir(oldWD) })
+
+	s, err := NewServer(ctx, &config.Config{
+		Host:            "127.0.0.1",
+		Port:            port,
+		ShutdownTimeout: 5,
+		DBDriver:        "sqlite",
+		DBPath:          filepath.Join(dir, "gophkeeper.db"),
+		Storage:         "fs",
+		StorageDir:      filepath.Join(dir, "blobs"),
+		TokenSecret:     "test-secret",
+		TokenExpiration: 3600,
+		RefreshTokenExp: 720,
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestRun_GracefulShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := newTestServer(t, ctx, 0)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run не завершился после отмены контекста")
+	}
+	assert.Error(t, s.db.Ping(), "База данных должна быть закрыта")
+}
+
+func TestRun_ListenError(t *testing.T) {
+	// HTTP-порт уже занят: сервер останавливается и возвращает ошибку, а не завершает процесс.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	s := newTestServer(t, context.Background(), lis.Addr().(*net.TCPAddr).Port)
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "HTTP server error")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Run не вернул ошибку занятого порта")
+	}
+	assert.Error(t, s.db.Ping())
+}
@@ -2,7 +2,10 @@ package session
 
 import (
 	"encoding/json"
+	"errors"
+	"io/fs"
 	"os"
+	"path/filepath"
 
 	"github.com/andranikuz/gophkeeper/internal/client"
 )
@@ -10,27 +13,46 @@ import (
 // BboltStorage must implements LocalStorage interface
 var _ client.SessionService = &Session{}
 
-const sessionFile = "data/session.json"
+// DefaultFile — файл сессии по умолчанию.
+const DefaultFile = "data/session.json"
 
 // Session управляет сессией пользователя.
 type Session struct {
 	Token client.Token
+	path  string
 }
 
-// NewSession создаёт новую сессию, пытаясь прочитать токен из файла.
+// NewSession создаёт новую сессию, пытаясь прочитать токен из файла по умолчанию.
 // Если файла нет, возвращается сессия с пустым токеном.
-func NewSession() Session {
-	return Session{Token: readToken()}
+func NewSession() *Session {
+	return NewSessionFile(DefaultFile)
+}
+
+// NewSessionFile создаёт сессию, хранящуюся в файле path, например отдельную для каждого профиля клиента.
+func NewSessionFile(path string) *Session {
+	return &Session{Token: readToken(path), path: path}
 }
 
 // Save сохраняет переданный токен в сессию и записывает его в файл.
-func (s Session) Save(token client.Token) error {
+func (s *Session) Save(token client.Token) error {
 	s.Token = token
 	data, err := json.Marshal(token)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(sessionFile, data, 0644)
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
+
+// Clear удаляет сессию вместе с файлом.
+func (s *Session) Clear() error {
+	s.Token = client.Token{}
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
 }
 
 // GetSessionToken возвращает строку токена сессии.
@@ -38,15 +60,20 @@ func (s Session) GetSessionToken() string {
 	return s.Token.Token
 }
 
+// GetRefreshToken возвращает токен обновления сессии.
+func (s Session) GetRefreshToken() string {
+	return s.Token.RefreshToken
+}
+
 // GetUserID возвращает userID из сессии.
 func (s Session) GetUserID() string {
 	return s.Token.UserID
 }
 
-// readToken считывает токен из файла и возвращает его.
+// readToken считывает токен из файла path и возвращает его.
 // Если чтение или парсинг не удался, возвращается пустой Token.
-func readToken() client.Token {
-	data, err := os.ReadFile(sessionFile)
+func readToken(path string) client.Token {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return client.Token{}
 	}
@@ -63,3 +63,37 @@ func TestSaveAndReadToken(t *testing.T) {
 		t.Errorf("Ожидался токен %+v, получена сессия %+v", token, newSession.Token)
 	}
 }
+
+// TestClear проверяет удаление сессии вместе с файлом.
+func TestClear(t *testing.T) {
+	dir := t.TempDir()
+	oldWD, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Не удалось получить текущую директорию: %v", err)
+	}
+	defer os.Chdir(oldWD)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Не удалось перейти в временную директорию: %v", err)
+	}
+	if err := os.Mkdir("data", 0755); err != nil {
+		t.Fatalf("Не удалось создать директорию data: %v", err)
+	}
+
+	session := NewSession()
+	if err := session.Save(client.Token{Token: "testToken", RefreshToken: "refresh", UserID: "userID123"}); err != nil {
+		t.Fatalf("Ошибка сохранения токена: %v", err)
+	}
+	if err := session.Clear(); err != nil {
+		t.Fatalf("Ошибка удаления сессии: %v", err)
+	}
+	if session.GetSessionToken() != "" || session.GetRefreshToken() != "" || session.GetUserID() != "" {
+		t.Errorf("Сессия не очищена: %+v", session.Token)
+	}
+	if _, err := os.Stat("data/session.json"); !os.IsNotExist(err) {
+		t.Errorf("Файл сессии не удалён: %v", err)
+	}
+	// Повторное удаление не считается ошибкой.
+	if err := session.Clear(); err != nil {
+		t.Fatalf("Ошибка повторного удаления сессии: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// AccountRepository удаляет учётные записи пользователей из базы SQLite.
+type AccountRepository struct {
+	db *sql.DB
+}
+
+// NewAccountRepository возвращает репозиторий учётных записей.
+func NewAccountRepository(db *sql.DB) (*AccountRepository, error) {
+	return &AccountRepository{db: db}, nil
+}
+
+// DeleteAccount удаляет пользователя и все его данные в одной транзакции. С чанков файлов только
+// снимаются ссылки, удалит их сборщик мусора. Записи об отзыве токенов сохраняются: выданные
+// пользователю токены должны отклоняться до истечения срока.
+func (r *AccountRepository) DeleteAccount(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := releaseUserManifests(tx, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, query := range []string{
+		`DELETE FROM data_items WHERE user_id = ?;`,
+		`DELETE FROM user_revisions WHERE user_id = ?;`,
+		`DELETE FROM recovery_codes WHERE user_id = ?;`,
+		`DELETE FROM two_factor WHERE user_id = ?;`,
+		`DELETE FROM refresh_tokens WHERE user_id = ?;`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM users WHERE id = ?;`, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+		return repository.ErrNotFound
+	}
+	return tx.Commit()
+}
+
+// releaseUserManifests удаляет манифесты всех файлов пользователя и снимает ссылки с их чанков.
+func releaseUserManifests(tx *sql.Tx, userID string) error {
+	rows, err := tx.Query(`SELECT file_id FROM file_manifests WHERE user_id = ?;`, userID)
+	if err != nil {
+		return err
+	}
+	var fileIDs []string
+	for rows.Next() {
+		var fileID string
+		if err := rows.Scan(&fileID); err != nil {
+			rows.Close()
+			return err
+		}
+		fileIDs = append(fileIDs, fileID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, fileID := range fileIDs {
+		if err := releaseManifest(tx, userID, fileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -14,57 +14,92 @@ type DataItemRepository struct {
 	db *sql.DB
 }
 
-// NewDataItemRepository открывает (или создаёт) базу SQLite по заданному пути и инициализирует схему.
+// NewDataItemRepository создаёт репозиторий записей поверх базы SQLite, схема которой подготовлена миграциями.
 func NewDataItemRepository(db *sql.DB) (*DataItemRepository, error) {
-	// Создаем таблицу, если ее еще нет.
-	schema := `
-	CREATE TABLE IF NOT EXISTS data_items (
-		id TEXT PRIMARY KEY,
-		type INTEGER,
-		content text,
-		meta text,
-		user_id text,
-		updated_at DATETIME
-	);
-	`
-	_, err := db.Exec(schema)
-	if err != nil {
-		return nil, err
-	}
-
 	return &DataItemRepository{db: db}, nil
 }
 
-// SaveItems сохраняет срез DataItem атомарно (в транзакции).
-func (s *DataItemRepository) SaveItems(items []entity.DataItem) error {
+// ApplyChanges применяет изменения записей пользователя userID в одной транзакции, см. repository.DataItemRepository.
+func (s *DataItemRepository) ApplyChanges(userID string, changes []entity.DataItem) ([]entity.DataItem, []string, error) {
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
 	tx, err := s.db.Begin()
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
+	// Первый запрос транзакции пишет в счётчик ревизий пользователя и тем самым захватывает блокировку
+	// записи в базу: параллельная синхронизация ждёт завершения этой транзакции, а не читает устаревшие ревизии.
+	// Счётчик создаётся с максимальной ревизией записей, чтобы новые ревизии не повторяли уже выданные.
+	var revision int64
+	err = tx.QueryRow(`
+	INSERT INTO user_revisions (user_id, revision)
+	SELECT ?, COALESCE(MAX(revision), 0) FROM data_items WHERE user_id = ?
+	ON CONFLICT (user_id) DO UPDATE SET revision = revision
+	RETURNING revision;
+	`, userID, userID).Scan(&revision)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, err
+	}
+	// Запись обновляется, только если она принадлежит пользователю и её ревизия не изменилась с BaseRevision.
 	stmt, err := tx.Prepare(`
-	INSERT OR REPLACE INTO data_items (id, type, content, meta, user_id, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?);
+	INSERT INTO data_items (id, type, content, meta, user_id, updated_at, deleted, deleted_at, revision)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (id) DO UPDATE SET
+		type = excluded.type,
+		content = excluded.content,
+		meta = excluded.meta,
+		updated_at = excluded.updated_at,
+		deleted = excluded.deleted,
+		deleted_at = excluded.deleted_at,
+		revision = excluded.revision
+	WHERE data_items.user_id = excluded.user_id AND data_items.revision = ?;
 	`)
 	if err != nil {
 		tx.Rollback()
-		return err
+		return nil, nil, err
 	}
 	defer stmt.Close()
 
-	for _, item := range items {
-		_, err = stmt.Exec(item.ID, int(item.Type), item.Content, item.Meta, item.UserID, item.UpdatedAt.Format(time.RFC3339))
+	var accepted []entity.DataItem
+	var rejected []string
+	for _, item := range changes {
+		res, err := stmt.Exec(item.ID, int(item.Type), item.Content, item.Meta, userID, item.UpdatedAt.Format(time.RFC3339Nano),
+			item.Deleted, formatNullTime(item.DeletedAt), revision+1, item.BaseRevision)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, err
+		}
+		n, err := res.RowsAffected()
 		if err != nil {
 			tx.Rollback()
-			return err
+			return nil, nil, err
 		}
+		if n == 0 {
+			rejected = append(rejected, item.ID)
+			continue
+		}
+		revision++
+		item.UserID = userID
+		item.Revision = revision
+		item.BaseRevision = 0
+		accepted = append(accepted, item)
+	}
+	if _, err := tx.Exec(`UPDATE user_revisions SET revision = ? WHERE user_id = ?;`, revision, userID); err != nil {
+		tx.Rollback()
+		return nil, nil, err
 	}
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return accepted, rejected, nil
 }
 
 // GetUserItems извлекает все объекты пользователя DataItem из базы.
 func (s *DataItemRepository) GetUserItems(userID string) ([]entity.DataItem, error) {
 	query := `
-	SELECT id, type, content, meta, user_id, updated_at 
+	SELECT id, type, content, meta, user_id, updated_at, deleted, deleted_at, revision
 	FROM data_items
 	WHERE user_id = ?;
 	`
@@ -78,8 +113,10 @@ func (s *DataItemRepository) GetUserItems(userID string) ([]entity.DataItem, err
 	for rows.Next() {
 		var item entity.DataItem
 		var updatedAtStr string
+		var deletedAtStr sql.NullString
 		var typeInt int
-		err := rows.Scan(&item.ID, &typeInt, &item.Content, &item.Meta, &item.UserID, &updatedAtStr)
+		err := rows.Scan(&item.ID, &typeInt, &item.Content, &item.Meta, &item.UserID, &updatedAtStr,
+			&item.Deleted, &deletedAtStr, &item.Revision)
 		if err != nil {
 			return nil, err
 		}
@@ -90,6 +127,9 @@ func (s *DataItemRepository) GetUserItems(userID string) ([]entity.DataItem, err
 		} else {
 			item.UpdatedAt = t
 		}
+		if deletedAtStr.Valid {
+			item.DeletedAt, _ = time.Parse(time.RFC3339, deletedAtStr.String)
+		}
 		items = append(items, item)
 	}
 	if err = rows.Err(); err != nil {
@@ -97,3 +137,27 @@ func (s *DataItemRepository) GetUserItems(userID string) ([]entity.DataItem, err
 	}
 	return items, nil
 }
+
+// PurgeDeletedItems окончательно удаляет надгробия, удалённые раньше указанного момента.
+// Запись с максимальной ревизией пользователя сохраняется, чтобы новые ревизии не повторяли
+// уже выданные курсоры синхронизации. Возвращает количество удалённых записей.
+func (s *DataItemRepository) PurgeDeletedItems(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`
+	DELETE FROM data_items
+	WHERE deleted = 1 AND deleted_at < ?
+	  AND revision < (SELECT MAX(d.revision) FROM data_items d WHERE d.user_id = data_items.user_id);
+	`, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// formatNullTime форматирует время в RFC3339 (UTC, чтобы значения сравнивались как строки)
+// или возвращает NULL для нулевого значения.
+func formatNullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}
+}
@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RevokedTokenRepository хранит список отозванных JWT-токенов в SQLite.
+// Время хранится строками RFC 3339 в UTC, поэтому сравнивается лексикографически.
+type RevokedTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRevokedTokenRepository возвращает репозиторий отозванных токенов.
+func NewRevokedTokenRepository(db *sql.DB) (*RevokedTokenRepository, error) {
+	return &RevokedTokenRepository{db: db}, nil
+}
+
+// RevokeToken добавляет токен в список отозванных.
+func (r *RevokedTokenRepository) RevokeToken(jti, userID string, expiresAt time.Time) error {
+	_, err := r.db.Exec(`
+	INSERT INTO revoked_tokens (jti, user_id, expires_at) VALUES (?, ?, ?)
+	ON CONFLICT(jti) DO NOTHING;
+	`, jti, userID, expiresAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// RevokeUserTokens отзывает все токены пользователя, выпущенные раньше before.
+func (r *RevokedTokenRepository) RevokeUserTokens(userID string, before time.Time) error {
+	_, err := r.db.Exec(`
+	INSERT INTO user_token_revocations (user_id, revoked_before) VALUES (?, ?)
+	ON CONFLICT(user_id) DO UPDATE SET revoked_before = excluded.revoked_before;
+	`, userID, before.UTC().Format(time.RFC3339))
+	return err
+}
+
+// IsTokenRevoked сообщает, отозван ли токен по jti или вместе со всеми токенами пользователя.
+func (r *RevokedTokenRepository) IsTokenRevoked(jti, userID string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db.QueryRow(`
+	SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)
+		OR EXISTS (SELECT 1 FROM user_token_revocations WHERE user_id = ? AND revoked_before > ?);
+	`, jti, userID, issuedAt.UTC().Format(time.RFC3339)).Scan(&revoked)
+	return revoked, err
+}
+
+// PurgeExpiredRevokedTokens удаляет из списка токены, истёкшие раньше before:
+// они и так не пройдут проверку срока действия.
+func (r *RevokedTokenRepository) PurgeExpiredRevokedTokens(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < ?;`, before.UTC().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
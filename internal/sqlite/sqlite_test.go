@@ -0,0 +1,320 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andranikuz/gophkeeper/internal/migrations"
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// openTestDB создаёт базу SQLite во временной директории и применяет к ней миграции.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := migrations.New(db, migrations.SQLite)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db
+}
+
+// noopRemove подменяет удаление данных чанка: в тестах репозитория данных нет.
+func noopRemove(hash string) error { return nil }
+
+func TestDataItemRepository_ApplyChangesChecksRevision(t *testing.T) {
+	repo, err := NewDataItemRepository(openTestDB(t))
+	require.NoError(t, err)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	accepted, rejected, err := repo.ApplyChanges("user1", []entity.DataItem{
+		{ID: "1", Content: "secret", UpdatedAt: now},
+		{ID: "2", Content: "note", UpdatedAt: now},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	require.Len(t, accepted, 2)
+	assert.Equal(t, "user1", accepted[0].UserID)
+	assert.Equal(t, int64(1), accepted[0].Revision)
+	assert.Equal(t, int64(2), accepted[1].Revision)
+
+	// Чужой клиент, приславший ID записи user1, не может её перезаписать или присвоить.
+	accepted, rejected, err = repo.ApplyChanges("user2", []entity.DataItem{
+		{ID: "3", Content: "own", UpdatedAt: now},
+		{ID: "1", Content: "stolen", UpdatedAt: now, BaseRevision: 1},
+	})
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, int64(1), accepted[0].Revision, "Ревизии у каждого пользователя свои")
+	assert.Equal(t, []string{"1"}, rejected)
+
+	// Изменения на основе устаревшей ревизии или без неё отклоняются и не расходуют ревизии.
+	_, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "new", UpdatedAt: now}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, rejected)
+	accepted, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "edit", UpdatedAt: now, BaseRevision: 1}})
+	require.NoError(t, err)
+	assert.Empty(t, rejected)
+	assert.Equal(t, int64(3), accepted[0].Revision)
+	_, rejected, err = repo.ApplyChanges("user1", []entity.DataItem{{ID: "1", Content: "late", UpdatedAt: now, BaseRevision: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, rejected)
+
+	got, err := repo.GetUserItems("user1")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	byID := map[string]entity.DataItem{got[0].ID: got[0], got[1].ID: got[1]}
+	assert.Equal(t, "edit", byID["1"].Content)
+	assert.Equal(t, int64(3), byID["1"].Revision)
+	got, err = repo.GetUserItems("user2")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestDataItemRepository_ApplyChangesContinuesLegacyRevisions(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+
+	// Записи, сохранённые до появления счётчика, уже имеют ревизии: новые ревизии их продолжают.
+	_, err = db.Exec(`INSERT INTO data_items (id, type, content, meta, user_id, updated_at, deleted, revision)
+		VALUES ('old', 0, '', '', 'user1', ?, 0, 7);`, time.Now().UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+	accepted, _, err := repo.ApplyChanges("user1", []entity.DataItem{{ID: "new", UpdatedAt: time.Now()}})
+	require.NoError(t, err)
+	require.Len(t, accepted, 1)
+	assert.Equal(t, int64(8), accepted[0].Revision)
+}
+
+func TestBlobRepository_SaveManifestRequiresChunks(t *testing.T) {
+	repo, err := NewBlobRepository(openTestDB(t))
+	require.NoError(t, err)
+	require.NoError(t, repo.AddBlob("a", 1))
+
+	// Чанк b не зарегистрирован: манифест не сохраняется, ссылка на a не остаётся.
+	err = repo.SaveManifest(entity.FileManifest{UserID: "user1", FileID: "file1", Chunks: []string{"a", "b"}})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = repo.GetManifest("user1", "file1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	purged, err := repo.PurgeUnreferencedBlobs(time.Now().Add(time.Hour), noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+}
+
+func TestBlobRepository_PurgeKeepsChunkIfRemoveFails(t *testing.T) {
+	repo, err := NewBlobRepository(openTestDB(t))
+	require.NoError(t, err)
+	require.NoError(t, repo.AddBlob("a", 1))
+	future := time.Now().Add(time.Hour)
+
+	// Данные не удалось удалить: запись о чанке остаётся до следующей сборки мусора.
+	purged, err := repo.PurgeUnreferencedBlobs(future, func(hash string) error { return errors.New("storage unavailable") })
+	assert.Error(t, err)
+	assert.Empty(t, purged)
+	missing, err := repo.MissingBlobs([]string{"a"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+
+	var removed []string
+	purged, err = repo.PurgeUnreferencedBlobs(future, func(hash string) error {
+		removed = append(removed, hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+	assert.Equal(t, []string{"a"}, removed)
+}
+
+func TestBlobRepository_MissingBlobsKeepsChunks(t *testing.T) {
+	db := openTestDB(t)
+	repo, err := NewBlobRepository(db)
+	require.NoError(t, err)
+	require.NoError(t, repo.AddBlob("a", 1))
+	old := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	_, err = db.Exec(`UPDATE blobs SET updated_at = ?;`, old)
+	require.NoError(t, err)
+
+	// Проверка наличия чанка перед загрузкой файла откладывает его удаление.
+	missing, err := repo.MissingBlobs([]string{"a"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	purged, err := repo.PurgeUnreferencedBlobs(time.Now().Add(-time.Hour), noopRemove)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+}
+
+func TestBlobRepository(t *testing.T) {
+	repo, err := NewBlobRepository(openTestDB(t))
+	require.NoError(t, err)
+
+	require.NoError(t, repo.AddBlob("a", 1))
+	require.NoError(t, repo.AddBlob("b", 1))
+	missing, err := repo.MissingBlobs([]string{"a", "c", "b", "c"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c"}, missing)
+
+	manifest := entity.FileManifest{UserID: "user1", FileID: "file1", SHA256: "sum", Size: 2, Chunks: []string{"a", "b", "a"}}
+	require.NoError(t, repo.SaveManifest(manifest))
+	got, err := repo.GetManifest("user1", "file1")
+	require.NoError(t, err)
+	assert.Equal(t, manifest, *got)
+
+	// Пока на чанки ссылается файл, сборщик мусора их не трогает.
+	future := time.Now().Add(time.Hour)
+	purged, err := repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+
+	// Новая версия файла освобождает чанк b; a упоминался дважды и остаётся.
+	manifest.Chunks = []string{"a"}
+	require.NoError(t, repo.SaveManifest(manifest))
+	purged, err = repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, purged)
+
+	// Чанки без ссылок удаляются только после отсечки.
+	require.NoError(t, repo.DeleteManifest("user1", "file1"))
+	_, err = repo.GetManifest("user1", "file1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	purged, err = repo.PurgeUnreferencedBlobs(time.Now().Add(-time.Hour), noopRemove)
+	require.NoError(t, err)
+	assert.Empty(t, purged)
+	purged, err = repo.PurgeUnreferencedBlobs(future, noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+	missing, err = repo.MissingBlobs([]string{"a", "b"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, missing)
+
+	// Удаление отсутствующего манифеста не считается ошибкой.
+	require.NoError(t, repo.DeleteManifest("user1", "file1"))
+}
+
+func TestTwoFactorRepository(t *testing.T) {
+	repo, err := NewTwoFactorRepository(openTestDB(t))
+	require.NoError(t, err)
+
+	_, err = repo.GetTwoFactor("user1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.ErrorIs(t, repo.EnableTwoFactor("user1", nil), repository.ErrNotFound)
+
+	tf := entity.TwoFactor{UserID: "user1", Secret: "SECRET", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	require.NoError(t, repo.SaveTwoFactor(tf))
+	require.NoError(t, repo.EnableTwoFactor("user1", []string{"h1", "h2"}))
+	got, err := repo.GetTwoFactor("user1")
+	require.NoError(t, err)
+	tf.Enabled = true
+	assert.Equal(t, tf, *got)
+
+	// Шаг TOTP принимается один раз, более ранние шаги — никогда.
+	ok, err := repo.UseTOTPStep("user1", 10)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseTOTPStep("user1", 10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = repo.UseTOTPStep("user1", 9)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = repo.UseTOTPStep("user1", 11)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Код восстановления одноразовый, остальные коды не сгорают.
+	ok, err = repo.UseRecoveryCode("user1", "h1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = repo.UseRecoveryCode("user1", "h1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = repo.UseRecoveryCode("user1", "h2")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestAccountRepository(t *testing.T) {
+	db := openTestDB(t)
+	users, err := NewUserRepository(db)
+	require.NoError(t, err)
+	items, err := NewDataItemRepository(db)
+	require.NoError(t, err)
+	blobs, err := NewBlobRepository(db)
+	require.NoError(t, err)
+	repo, err := NewAccountRepository(db)
+	require.NoError(t, err)
+
+	for _, user := range []entity.User{
+		{ID: "user1", Username: "alice", Password: "hash", CreatedAt: time.Now()},
+		{ID: "user2", Username: "bob", Password: "hash", CreatedAt: time.Now()},
+	} {
+		require.NoError(t, users.SaveUser(user))
+		_, _, err := items.ApplyChanges(user.ID, []entity.DataItem{{ID: "item-" + user.ID, UpdatedAt: time.Now()}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, blobs.AddBlob("a", 1))
+	require.NoError(t, blobs.AddBlob("shared", 1))
+	require.NoError(t, blobs.SaveManifest(entity.FileManifest{UserID: "user1", FileID: "f1", Chunks: []string{"a", "shared"}}))
+	require.NoError(t, blobs.SaveManifest(entity.FileManifest{UserID: "user2", FileID: "f2", Chunks: []string{"shared"}}))
+
+	require.NoError(t, repo.DeleteAccount("user1"))
+	_, err = users.GetUserByID("user1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	got, err := items.GetUserItems("user1")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	_, err = blobs.GetManifest("user1", "f1")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Чанки удалённого аккаунта остаются в хранилище до сборки мусора,
+	// а чанк, нужный другому пользователю, не освобождается.
+	missing, err := blobs.MissingBlobs([]string{"a", "shared"})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+	purged, err := blobs.PurgeUnreferencedBlobs(time.Now().Add(time.Minute), noopRemove)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, purged)
+
+	got, err = items.GetUserItems("user2")
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+	_, err = blobs.GetManifest("user2", "f2")
+	require.NoError(t, err)
+
+	// Имя удалённого пользователя снова свободно.
+	require.NoError(t, users.SaveUser(entity.User{ID: "user3", Username: "alice", Password: "hash", CreatedAt: time.Now()}))
+	assert.ErrorIs(t, repo.DeleteAccount("user1"), repository.ErrNotFound)
+}
+
+func TestUserRepository(t *testing.T) {
+	repo, err := NewUserRepository(openTestDB(t))
+	require.NoError(t, err)
+
+	user := entity.User{ID: "id1", Username: "alice", Password: "hash", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	require.NoError(t, repo.SaveUser(user))
+	got, err := repo.GetUserByUsername("alice")
+	require.NoError(t, err)
+	assert.Equal(t, user, *got)
+
+	// Повторная регистрация того же имени не перезаписывает учётную запись.
+	err = repo.SaveUser(entity.User{ID: "id2", Username: "alice", Password: "other", CreatedAt: time.Now()})
+	assert.ErrorIs(t, err, repository.ErrUsernameTaken)
+	got, err = repo.GetUserByID("id1")
+	require.NoError(t, err)
+	assert.Equal(t, "hash", got.Password)
+	_, err = repo.GetUserByID("id2")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// Совпадение идентификатора не считается занятым именем.
+	err = repo.SaveUser(entity.User{ID: "id1", Username: "bob", Password: "hash", CreatedAt: time.Now()})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, repository.ErrUsernameTaken)
+}
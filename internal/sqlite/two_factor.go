@@ -0,0 +1,99 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
+)
+
+// TwoFactorRepository хранит настройки двухфакторной аутентификации в SQLite.
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+// NewTwoFactorRepository возвращает репозиторий настроек двухфакторной аутентификации.
+func NewTwoFactorRepository(db *sql.DB) (*TwoFactorRepository, error) {
+	return &TwoFactorRepository{db: db}, nil
+}
+
+// SaveTwoFactor сохраняет настройки пользователя, заменяя прежние.
+func (r *TwoFactorRepository) SaveTwoFactor(tf entity.TwoFactor) error {
+	_, err := r.db.Exec(`
+	INSERT OR REPLACE INTO two_factor (user_id, secret, enabled, last_used_step, created_at)
+	VALUES (?, ?, ?, ?, ?);
+	`, tf.UserID, tf.Secret, tf.Enabled, tf.LastUsedStep, tf.CreatedAt.UTC().Format(time.RFC3339))
+	return err
+}
+
+// GetTwoFactor возвращает настройки пользователя или repository.ErrNotFound.
+func (r *TwoFactorRepository) GetTwoFactor(userID string) (*entity.TwoFactor, error) {
+	tf := entity.TwoFactor{UserID: userID}
+	var createdAt string
+	err := r.db.QueryRow(`
+	SELECT secret, enabled, last_used_step, created_at FROM two_factor WHERE user_id = ?;
+	`, userID).Scan(&tf.Secret, &tf.Enabled, &tf.LastUsedStep, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	tf.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	return &tf, nil
+}
+
+// EnableTwoFactor включает двухфакторную аутентификацию и заменяет коды восстановления.
+func (r *TwoFactorRepository) EnableTwoFactor(userID string, recoveryCodeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	res, err := tx.Exec(`UPDATE two_factor SET enabled = 1 WHERE user_id = ?;`, userID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		if err != nil {
+			return err
+		}
+		return repository.ErrNotFound
+	}
+	if _, err := tx.Exec(`DELETE FROM recovery_codes WHERE user_id = ?;`, userID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES (?, ?);`, userID, hash); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep атомарно отмечает временной шаг TOTP использованным.
+func (r *TwoFactorRepository) UseTOTPStep(userID string, step int64) (bool, error) {
+	res, err := r.db.Exec(`
+	UPDATE two_factor SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?;
+	`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// UseRecoveryCode удаляет код восстановления.
+func (r *TwoFactorRepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?;`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
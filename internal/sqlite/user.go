@@ -2,9 +2,14 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/mattn/go-sqlite3"
+
 	"github.com/andranikuz/gophkeeper/pkg/entity"
+	"github.com/andranikuz/gophkeeper/pkg/repository"
 )
 
 // UserRepository реализует операции для работы с пользователями в базе SQLite.
@@ -12,44 +17,63 @@ type UserRepository struct {
 	db *sql.DB
 }
 
-// NewUserRepository открывает (или создаёт) таблицу пользователей и возвращает репозиторий.
+// NewUserRepository возвращает репозиторий пользователей.
 func NewUserRepository(db *sql.DB) (*UserRepository, error) {
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		username TEXT UNIQUE,
-		password TEXT,
-		created_at DATETIME
-	);
-	`
-	_, err := db.Exec(schema)
-	if err != nil {
-		return nil, err
-	}
 	return &UserRepository{db: db}, nil
 }
 
-// SaveUser сохраняет или обновляет пользователя в базе.
+// SaveUser добавляет нового пользователя. Занятое имя даёт repository.ErrUsernameTaken:
+// INSERT OR REPLACE молча перезаписал бы чужую учётную запись.
 func (r *UserRepository) SaveUser(user entity.User) error {
 	query := `
-	INSERT OR REPLACE INTO users (id, username, password, created_at)
+	INSERT INTO users (id, username, password, created_at)
 	VALUES (?, ?, ?, ?);
 	`
 	_, err := r.db.Exec(query, user.ID, user.Username, user.Password, user.CreatedAt.Format(time.RFC3339))
-	if err != nil {
-		return err
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique &&
+		strings.Contains(sqliteErr.Error(), "users.username") {
+		return repository.ErrUsernameTaken
 	}
-	return nil
+	return err
 }
 
 // GetUserByUsername возвращает пользователя по имени.
 func (r *UserRepository) GetUserByUsername(username string) (*entity.User, error) {
 	query := `SELECT id, username, password, created_at FROM users WHERE username = ?;`
-	row := r.db.QueryRow(query, username)
+	return scanUser(r.db.QueryRow(query, username))
+}
+
+// GetUserByID возвращает пользователя по идентификатору.
+func (r *UserRepository) GetUserByID(id string) (*entity.User, error) {
+	query := `SELECT id, username, password, created_at FROM users WHERE id = ?;`
+	return scanUser(r.db.QueryRow(query, id))
+}
 
+// UpdatePassword заменяет хэш пароля пользователя.
+func (r *UserRepository) UpdatePassword(userID, passwordHash string) error {
+	res, err := r.db.Exec(`UPDATE users SET password = ? WHERE id = ?;`, passwordHash, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// scanUser читает пользователя из строки результата запроса. Если строки нет, возвращается repository.ErrNotFound.
+func scanUser(row *sql.Row) (*entity.User, error) {
 	var user entity.User
 	var createdAtStr string
 	err := row.Scan(&user.ID, &user.Username, &user.Password, &createdAtStr)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, repository.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
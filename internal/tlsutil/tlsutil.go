@@ -0,0 +1,143 @@
+// Package tlsutil собирает TLS-настройки сервера и клиента: загрузку и генерацию сертификата сервера,
+// проверку сертификата по собственному списку УЦ и закрепление (pinning) сертификата по отпечатку.
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// selfSignedValidity — срок действия сгенерированного самоподписанного сертификата.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// ErrPinMismatch возвращается, если отпечаток сертификата сервера не совпал с закреплённым.
+var ErrPinMismatch = errors.New("server certificate does not match the pinned fingerprint")
+
+// ServerConfig загружает сертификат и ключ сервера.
+func ServerConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// EnsureSelfSigned создаёт самоподписанный сертификат для hosts, если файлов certFile и keyFile ещё нет.
+// Существующий сертификат не перевыпускается, чтобы закреплённый клиентами отпечаток оставался прежним.
+// Предназначен для разработки: в эксплуатации сертификат должен выпускать УЦ.
+func EnsureSelfSigned(certFile, keyFile string, hosts []string) error {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if certErr == nil && keyErr == nil {
+		return nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"GophKeeper"}, CommonName: "gophkeeper"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	for _, host := range append([]string{"localhost", "127.0.0.1", "::1"}, hosts...) {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else if host != "" {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	if err := writePEM(keyFile, "PRIVATE KEY", keyDER, 0600); err != nil {
+		return err
+	}
+	return writePEM(certFile, "CERTIFICATE", der, 0644)
+}
+
+// writePEM записывает блок PEM в файл, создавая недостающие директории.
+func writePEM(path, blockType string, der []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	return os.WriteFile(path, data, perm)
+}
+
+// Fingerprint возвращает отпечаток сертификата: SHA-256 от DER в шестнадцатеричном виде.
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ClientConfig собирает TLS-настройки клиента. caFile — PEM-файл с сертификатами УЦ, которым клиент
+// доверяет вместо системных. pin — отпечаток сертификата сервера (см. Fingerprint, двоеточия допускаются):
+// если он задан без caFile, цепочка не проверяется, сервер подтверждается только совпадением отпечатка;
+// вместе с caFile проверяется и цепочка, и отпечаток.
+func ClientConfig(caFile, pin string) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		conf.RootCAs = pool
+	}
+	if pin == "" {
+		return conf, nil
+	}
+
+	pin = strings.ToLower(strings.ReplaceAll(pin, ":", ""))
+	if decoded, err := hex.DecodeString(pin); err != nil || len(decoded) != sha256.Size {
+		return nil, fmt.Errorf("invalid certificate fingerprint: expected %d hex-encoded bytes of SHA-256", sha256.Size)
+	}
+	// Самоподписанный сертификат не проходит проверку цепочки, поэтому без УЦ её заменяет сверка отпечатка.
+	conf.InsecureSkipVerify = caFile == ""
+	conf.VerifyConnection = func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 || Fingerprint(state.PeerCertificates[0]) != pin {
+			return ErrPinMismatch
+		}
+		return nil
+	}
+	return conf, nil
+}
@@ -0,0 +1,106 @@
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTLSServer запускает HTTPS-сервер с самоподписанным сертификатом и возвращает его вместе с путём к сертификату.
+func newTLSServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls", "server.crt"), filepath.Join(dir, "tls", "server.key")
+	require.NoError(t, EnsureSelfSigned(certFile, keyFile, []string{"gophkeeper.local"}))
+
+	conf, err := ServerConfig(certFile, keyFile)
+	require.NoError(t, err)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.TLS = conf
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts, certFile
+}
+
+// readCert читает сертификат из PEM-файла.
+func readCert(t *testing.T, path string) *x509.Certificate {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
+
+func get(conf *tls.Config, url string) error {
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: conf}}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestEnsureSelfSigned(t *testing.T) {
+	ts, certFile := newTLSServer(t)
+	cert := readCert(t, certFile)
+	assert.Contains(t, cert.DNSNames, "gophkeeper.local")
+	info, err := os.Stat(strings.TrimSuffix(certFile, ".crt") + ".key")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Повторный вызов не перевыпускает сертификат.
+	require.NoError(t, EnsureSelfSigned(certFile, strings.TrimSuffix(certFile, ".crt")+".key", nil))
+	assert.Equal(t, Fingerprint(cert), Fingerprint(readCert(t, certFile)))
+
+	// Без доверия к сертификату соединение не устанавливается.
+	conf, err := ClientConfig("", "")
+	require.NoError(t, err)
+	assert.Error(t, get(conf, ts.URL))
+}
+
+func TestClientConfig_CA(t *testing.T) {
+	ts, certFile := newTLSServer(t)
+	conf, err := ClientConfig(certFile, "")
+	require.NoError(t, err)
+	assert.NoError(t, get(conf, ts.URL))
+
+	_, err = ClientConfig(filepath.Join(t.TempDir(), "missing.pem"), "")
+	assert.Error(t, err)
+}
+
+func TestClientConfig_Pin(t *testing.T) {
+	ts, certFile := newTLSServer(t)
+	fingerprint := Fingerprint(readCert(t, certFile))
+
+	conf, err := ClientConfig("", fingerprint)
+	require.NoError(t, err)
+	assert.NoError(t, get(conf, ts.URL))
+
+	// Отпечаток можно указать в верхнем регистре с двоеточиями, как его показывает openssl.
+	var pairs []string
+	for i := 0; i < len(fingerprint); i += 2 {
+		pairs = append(pairs, strings.ToUpper(fingerprint[i:i+2]))
+	}
+	conf, err = ClientConfig(certFile, strings.Join(pairs, ":"))
+	require.NoError(t, err)
+	assert.NoError(t, get(conf, ts.URL))
+
+	conf, err = ClientConfig("", strings.Repeat("00", 32))
+	require.NoError(t, err)
+	assert.ErrorIs(t, get(conf, ts.URL), ErrPinMismatch)
+
+	_, err = ClientConfig("", "not-a-fingerprint")
+	assert.Error(t, err)
+}
@@ -0,0 +1,151 @@
+package vault
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// segmentSize — размер открытого текста в одном сегменте потока.
+// Поток шифруется посегментно, поэтому файл никогда не загружается в память целиком.
+const segmentSize = 64 * 1024
+
+// noncePrefixSize — размер случайного префикса nonce; оставшиеся 8 байт — номер сегмента.
+const noncePrefixSize = 16
+
+// EncryptStream шифрует поток src и пишет результат в dst.
+// Формат: версия формата, случайный префикс nonce, затем сегменты шифротекста.
+// Nonce каждого сегмента состоит из префикса и номера сегмента, а признак последнего
+// сегмента входит в дополнительные данные, поэтому перестановка, удаление
+// и обрезка сегментов обнаруживаются при расшифровке.
+func (c *Cipher) EncryptStream(dst io.Writer, src io.Reader) error {
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.encryptStream(dst, src, prefix)
+}
+
+// EncryptStreamConvergent шифрует поток так же, как EncryptStream, но префикс nonce выводится
+// из HMAC открытого текста. Одинаковые файлы одного пользователя дают одинаковый шифротекст,
+// что позволяет серверу хранить их один раз; ценой этого сервер узнаёт о совпадении файлов.
+// Поток читается дважды, поэтому src должен поддерживать Seek.
+func (c *Cipher) EncryptStreamConvergent(dst io.Writer, src io.ReadSeeker) error {
+	mac := hmac.New(sha256.New, c.nonceKey)
+	if _, err := io.Copy(mac, src); err != nil {
+		return fmt.Errorf("failed to read data: %w", err)
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return c.encryptStream(dst, src, mac.Sum(nil)[:noncePrefixSize])
+}
+
+// encryptStream шифрует поток с заданным префиксом nonce.
+func (c *Cipher) encryptStream(dst io.Writer, src io.Reader, prefix []byte) error {
+	header := make([]byte, 1+noncePrefixSize)
+	header[0] = formatVersion
+	copy(header[1:], prefix)
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+
+	cur := make([]byte, segmentSize)
+	next := make([]byte, segmentSize)
+	n, err := readSegment(src, cur)
+	if err != nil {
+		return err
+	}
+	out := make([]byte, 0, segmentSize+c.aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		// Читаем следующий сегмент заранее, чтобы знать, является ли текущий последним.
+		m := 0
+		if n == segmentSize {
+			if m, err = readSegment(src, next); err != nil {
+				return err
+			}
+		}
+		last := m == 0
+		out = c.aead.Seal(out[:0], segmentNonce(header[1:], counter), cur[:n], segmentAD(last))
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		cur, next = next, cur
+		n = m
+	}
+}
+
+// DecryptStream расшифровывает поток, созданный EncryptStream, и пишет открытый текст в dst.
+// Сегменты записываются в dst по мере проверки, поэтому при ошибке в dst может остаться
+// частично расшифрованный результат — вызывающий код должен его отбросить.
+func (c *Cipher) DecryptStream(dst io.Writer, src io.Reader) error {
+	header := make([]byte, 1+noncePrefixSize)
+	if _, err := io.ReadFull(src, header); err != nil || header[0] != formatVersion {
+		return ErrDecrypt
+	}
+
+	r := bufio.NewReader(src)
+	buf := make([]byte, segmentSize+c.aead.Overhead())
+	out := make([]byte, 0, segmentSize)
+	for counter := uint64(0); ; counter++ {
+		n, err := io.ReadFull(r, buf)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			if errors.Is(err, io.EOF) {
+				// Поток оборвался на границе сегментов до последнего сегмента.
+				return ErrDecrypt
+			}
+			return err
+		}
+		last := n < len(buf)
+		if !last {
+			if _, err := r.Peek(1); errors.Is(err, io.EOF) {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		out, err = c.aead.Open(out[:0], segmentNonce(header[1:], counter), buf[:n], segmentAD(last))
+		if err != nil {
+			return ErrDecrypt
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// readSegment читает из r до len(buf) байт и возвращает их количество.
+func readSegment(r io.Reader, buf []byte) (int, error) {
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, fmt.Errorf("failed to read data: %w", err)
+	}
+	return n, nil
+}
+
+// segmentNonce формирует nonce сегмента из префикса потока и номера сегмента.
+func segmentNonce(prefix []byte, counter uint64) []byte {
+	nonce := make([]byte, noncePrefixSize+8)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint64(nonce[noncePrefixSize:], counter)
+	return nonce
+}
+
+// segmentAD возвращает дополнительные данные сегмента с признаком последнего сегмента.
+func segmentAD(last bool) []byte {
+	if last {
+		return []byte{formatVersion, 1}
+	}
+	return []byte{formatVersion, 0}
+}
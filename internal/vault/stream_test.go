@@ -0,0 +1,101 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptStream(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+
+	sizes := []int{0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3*segmentSize + 17}
+	for _, size := range sizes {
+		plaintext := make([]byte, size)
+		_, err := rand.Read(plaintext)
+		require.NoError(t, err)
+
+		var encrypted bytes.Buffer
+		require.NoError(t, c.EncryptStream(&encrypted, bytes.NewReader(plaintext)), "size %d", size)
+		if size > 0 {
+			assert.NotContains(t, encrypted.String(), string(plaintext), "size %d", size)
+		}
+
+		var decrypted bytes.Buffer
+		require.NoError(t, c.DecryptStream(&decrypted, bytes.NewReader(encrypted.Bytes())), "size %d", size)
+		assert.Equal(t, string(plaintext), decrypted.String(), "size %d", size)
+	}
+}
+
+func TestDecryptStreamIntegrity(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+	plaintext := make([]byte, 2*segmentSize+100)
+	_, err = rand.Read(plaintext)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, c.EncryptStream(&buf, bytes.NewReader(plaintext)))
+	encrypted := buf.Bytes()
+	segment := segmentSize + c.aead.Overhead()
+	header := 1 + noncePrefixSize
+
+	// Изменённый байт.
+	tampered := bytes.Clone(encrypted)
+	tampered[header+10] ^= 0xff
+	assert.ErrorIs(t, c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(tampered)), ErrDecrypt)
+
+	// Обрезка на границе сегментов.
+	truncated := encrypted[:header+2*segment]
+	assert.ErrorIs(t, c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(truncated)), ErrDecrypt)
+
+	// Обрезка посреди сегмента.
+	assert.ErrorIs(t, c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(encrypted[:len(encrypted)-5])), ErrDecrypt)
+
+	// Перестановка сегментов.
+	swapped := bytes.Clone(encrypted)
+	copy(swapped[header:], encrypted[header+segment:header+2*segment])
+	copy(swapped[header+segment:], encrypted[header:header+segment])
+	assert.ErrorIs(t, c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(swapped)), ErrDecrypt)
+
+	// Только заголовок.
+	assert.ErrorIs(t, c.DecryptStream(&bytes.Buffer{}, bytes.NewReader(encrypted[:header])), ErrDecrypt)
+
+	// Другой ключ.
+	wrong, err := NewCipher("wrong", "user123")
+	require.NoError(t, err)
+	assert.ErrorIs(t, wrong.DecryptStream(&bytes.Buffer{}, bytes.NewReader(encrypted)), ErrDecrypt)
+}
+
+func TestEncryptStreamConvergent(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+	plaintext := make([]byte, segmentSize+100)
+	_, err = rand.Read(plaintext)
+	require.NoError(t, err)
+
+	encrypt := func(c *Cipher, data []byte) []byte {
+		var buf bytes.Buffer
+		require.NoError(t, c.EncryptStreamConvergent(&buf, bytes.NewReader(data)))
+		return buf.Bytes()
+	}
+
+	// Одинаковый файл даёт одинаковый шифротекст и расшифровывается обычным образом.
+	first := encrypt(c, plaintext)
+	assert.Equal(t, first, encrypt(c, plaintext))
+	var decrypted bytes.Buffer
+	require.NoError(t, c.DecryptStream(&decrypted, bytes.NewReader(first)))
+	assert.Equal(t, string(plaintext), decrypted.String())
+
+	// Другой файл или другой ключ дают другой шифротекст.
+	changed := bytes.Clone(plaintext)
+	changed[0] ^= 0xff
+	assert.NotEqual(t, first[:1+noncePrefixSize], encrypt(c, changed)[:1+noncePrefixSize])
+	other, err := NewCipher("other", "user123")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, encrypt(other, plaintext))
+}
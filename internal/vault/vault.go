@@ -0,0 +1,115 @@
+package vault
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Параметры Argon2id для выведения ключа из мастер-пароля.
+const (
+	argonTime    = 1
+	argonMemory  = 64 * 1024 // 64 MB
+	argonThreads = 4
+)
+
+// formatVersion — версия формата зашифрованных данных, записывается первым байтом.
+const formatVersion byte = 1
+
+// ErrDecrypt возвращается, если данные не удалось расшифровать:
+// неверный мастер-пароль или данные повреждены.
+var ErrDecrypt = errors.New("failed to decrypt data: wrong master password or corrupted data")
+
+// Cipher шифрует и расшифровывает данные ключом, выведенным из мастер-пароля.
+// Используется XChaCha20-Poly1305, поэтому каждый шифротекст аутентифицирован.
+type Cipher struct {
+	aead cipher.AEAD
+	// nonceKey — ключ для выведения детерминированных nonce при конвергентном шифровании файлов.
+	nonceKey []byte
+}
+
+// DeriveKey выводит симметричный ключ из мастер-пароля с помощью Argon2id.
+// salt должен быть уникален для пользователя (например, его идентификатор).
+func DeriveKey(masterPassword, salt string) []byte {
+	s := sha256.Sum256([]byte("gophkeeper:" + salt))
+	return argon2.IDKey([]byte(masterPassword), s[:], argonTime, argonMemory, argonThreads, chacha20poly1305.KeySize)
+}
+
+// NewCipher создаёт Cipher с ключом, выведенным из мастер-пароля и соли.
+func NewCipher(masterPassword, salt string) (*Cipher, error) {
+	return NewCipherFromKey(DeriveKey(masterPassword, salt))
+}
+
+// NewCipherFromKey создаёт Cipher из готового 32-байтного ключа.
+func NewCipherFromKey(key []byte) (*Cipher, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init cipher: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("gophkeeper:stream-nonce"))
+	return &Cipher{aead: aead, nonceKey: mac.Sum(nil)}, nil
+}
+
+// Encrypt шифрует данные. Результат: версия формата, случайный nonce и шифротекст с тегом.
+func (c *Cipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	out := make([]byte, 1+nonceSize, 1+nonceSize+len(plaintext)+c.aead.Overhead())
+	out[0] = formatVersion
+	if _, err := rand.Read(out[1:]); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(out, out[1:], plaintext, out[:1]), nil
+}
+
+// Decrypt расшифровывает данные, полученные методом Encrypt, и проверяет их целостность.
+func (c *Cipher) Decrypt(data []byte) ([]byte, error) {
+	nonceSize := c.aead.NonceSize()
+	if len(data) < 1+nonceSize+c.aead.Overhead() || data[0] != formatVersion {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := c.aead.Open(nil, data[1:1+nonceSize], data[1+nonceSize:], data[:1])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// IsEncryptedString сообщает, похожа ли строка на результат EncryptString: base64 с версией формата,
+// nonce и тегом. Проверка не требует ключа, поэтому не гарантирует, что строка расшифруется.
+func IsEncryptedString(s string) bool {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	return len(data) >= 1+chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead && data[0] == formatVersion
+}
+
+// EncryptString шифрует строку и возвращает шифротекст в base64.
+func (c *Cipher) EncryptString(plaintext string) (string, error) {
+	data, err := c.Encrypt([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// DecryptString расшифровывает строку, полученную методом EncryptString.
+func (c *Cipher) DecryptString(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", ErrDecrypt
+	}
+	plaintext, err := c.Decrypt(data)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
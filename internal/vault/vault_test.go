@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptDecryptString(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+
+	ciphertext, err := c.EncryptString("secret data")
+	require.NoError(t, err)
+	assert.NotContains(t, ciphertext, "secret data")
+
+	plaintext, err := c.DecryptString(ciphertext)
+	require.NoError(t, err)
+	assert.Equal(t, "secret data", plaintext)
+
+	// Одинаковые данные шифруются по-разному благодаря случайному nonce.
+	other, err := c.EncryptString("secret data")
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, other)
+}
+
+func TestDecryptWrongPassword(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+	ciphertext, err := c.EncryptString("secret data")
+	require.NoError(t, err)
+
+	wrong, err := NewCipher("wrong", "user123")
+	require.NoError(t, err)
+	_, err = wrong.DecryptString(ciphertext)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	// Та же фраза с другой солью даёт другой ключ.
+	otherSalt, err := NewCipher("master", "user456")
+	require.NoError(t, err)
+	_, err = otherSalt.DecryptString(ciphertext)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestDecryptTampered(t *testing.T) {
+	c, err := NewCipher("master", "user123")
+	require.NoError(t, err)
+	data, err := c.Encrypt([]byte("secret data"))
+	require.NoError(t, err)
+
+	data[len(data)-1] ^= 0xff
+	_, err = c.Decrypt(data)
+	assert.ErrorIs(t, err, ErrDecrypt)
+
+	_, err = c.DecryptString("not base64 !!!")
+	assert.ErrorIs(t, err, ErrDecrypt)
+	_, err = c.Decrypt([]byte{formatVersion})
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestIsEncryptedString(t *testing.T) {
+	c, err := NewCipherFromKey(make([]byte, 32))
+	require.NoError(t, err)
+	encrypted, err := c.EncryptString("")
+	require.NoError(t, err)
+
+	assert.True(t, IsEncryptedString(encrypted))
+	assert.False(t, IsEncryptedString("plain text"))
+	assert.False(t, IsEncryptedString("report.txt"))
+	assert.False(t, IsEncryptedString(""))
+	// Короткая строка в base64 не может быть шифротекстом.
+	assert.False(t, IsEncryptedString("AQID"))
+}
@@ -42,6 +42,13 @@ type DataItem struct {
 	Meta      string    `json:"meta"`       // Произвольная метаинформация
 	UserID    string    `json:"user_id"`    // Владелец записи
 	UpdatedAt time.Time `json:"updated_at"` // Время последнего обновления (используется для синхронизации)
+	Deleted   bool      `json:"deleted"`    // Признак удалённой записи (надгробие)
+	DeletedAt time.Time `json:"deleted_at"` // Время удаления записи
+	// Revision — ревизия записи, назначенная сервером. Ноль означает локальное изменение,
+	// ещё не принятое сервером.
+	Revision int64 `json:"revision"`
+	// BaseRevision — серверная ревизия, на основе которой сделано локальное изменение.
+	BaseRevision int64 `json:"base_revision"`
 }
 
 // NewDataItem создаёт новый экземпляр DataItem с заданными параметрами.
@@ -55,3 +62,28 @@ func NewDataItem(id string, dataType DataType, content string, meta string, user
 		UpdatedAt: time.Now(),
 	}
 }
+
+// MarkModified отмечает запись как изменённую локально: ревизия, на которой основано изменение,
+// запоминается в BaseRevision, а Revision сбрасывается до подтверждения изменения сервером.
+func (d *DataItem) MarkModified() {
+	if d.Revision > 0 {
+		d.BaseRevision = d.Revision
+		d.Revision = 0
+	}
+	d.UpdatedAt = time.Now()
+}
+
+// IsModified сообщает, содержит ли запись локальное изменение, ещё не принятое сервером.
+func (d *DataItem) IsModified() bool {
+	return d.Revision == 0
+}
+
+// MarkDeleted превращает запись в надгробие: очищает содержимое и отмечает время удаления.
+// Надгробие синхронизируется с сервером, чтобы удаление применилось на всех устройствах.
+func (d *DataItem) MarkDeleted() {
+	d.MarkModified()
+	d.Content = ""
+	d.Meta = ""
+	d.Deleted = true
+	d.DeletedAt = d.UpdatedAt
+}
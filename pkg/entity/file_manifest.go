@@ -0,0 +1,10 @@
+package entity
+
+// FileManifest описывает файл записи как упорядоченный список чанков в хранилище блобов.
+type FileManifest struct {
+	UserID string   `json:"user_id"`
+	FileID string   `json:"file_id"`
+	SHA256 string   `json:"sha256"` // SHA-256 всего файла в hex.
+	Size   int64    `json:"size"`
+	Chunks []string `json:"chunks"` // SHA-256 чанков в hex в порядке следования.
+}
@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// RefreshToken — долгоживущий токен обновления сессии. Сервер хранит только SHA-256 токена.
+// При каждом обновлении токен отзывается и заменяется новым из того же семейства; повторное
+// предъявление отозванного токена означает его кражу и отзывает всё семейство.
+type RefreshToken struct {
+	TokenHash string    // SHA-256 токена в hex
+	UserID    string    // Владелец токена
+	Username  string    // Имя пользователя для выпуска access-токена
+	FamilyID  string    // Идентификатор цепочки токенов, начатой одним логином
+	ExpiresAt time.Time // Момент истечения срока действия
+	CreatedAt time.Time // Момент выпуска
+	Revoked   bool      // Токен использован или отозван
+}
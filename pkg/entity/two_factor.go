@@ -0,0 +1,12 @@
+package entity
+
+import "time"
+
+// TwoFactor — настройки двухфакторной аутентификации пользователя по TOTP (RFC 6238).
+type TwoFactor struct {
+	UserID       string    // Владелец
+	Secret       string    // Секрет TOTP в base32
+	Enabled      bool      // Подключение подтверждено кодом из приложения
+	LastUsedStep int64     // Номер последнего принятого временного шага; коды не старше него повторно не принимаются
+	CreatedAt    time.Time // Момент начала подключения
+}
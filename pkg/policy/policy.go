@@ -0,0 +1,137 @@
+// Package policy описывает требования к имени пользователя и паролю учётной записи.
+// Одни и те же правила проверяет сервер при регистрации и клиент до отправки запроса.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxPasswordBytes — предельная длина пароля: bcrypt учитывает только первые 72 байта.
+const MaxPasswordBytes = 72
+
+var (
+	// ErrInvalidUsername возвращается, если имя пользователя не соответствует правилам.
+	ErrInvalidUsername = errors.New("invalid username")
+	// ErrWeakPassword возвращается, если пароль не соответствует требованиям к стойкости.
+	ErrWeakPassword = errors.New("weak password")
+)
+
+// Policy — правила для имени пользователя и пароля. Нулевые значения ограничений их отключают.
+type Policy struct {
+	MinUsernameLength  int            // Минимальная длина имени в символах
+	MaxUsernameLength  int            // Максимальная длина имени в символах
+	UsernamePattern    *regexp.Regexp // Допустимый формат имени
+	MinPasswordLength  int            // Минимальная длина пароля в символах
+	MinPasswordEntropy float64        // Минимальная оценка энтропии пароля в битах, см. Entropy
+}
+
+// DefaultUsernamePattern — формат имени по умолчанию: латиница, цифры, точка, дефис и подчёркивание,
+// первым символом — буква или цифра.
+const DefaultUsernamePattern = `^[A-Za-z0-9][A-Za-z0-9._-]*$`
+
+// Default возвращает правила, которые применяются, если сервер не настроен иначе.
+func Default() Policy {
+	return Policy{
+		MinUsernameLength:  3,
+		MaxUsernameLength:  32,
+		UsernamePattern:    regexp.MustCompile(DefaultUsernamePattern),
+		MinPasswordLength:  8,
+		MinPasswordEntropy: 40,
+	}
+}
+
+// ValidateUsername проверяет имя пользователя. Ошибка оборачивает ErrInvalidUsername
+// и объясняет, какое правило нарушено.
+func (p Policy) ValidateUsername(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length == 0 {
+		return fmt.Errorf("%w: username is required", ErrInvalidUsername)
+	}
+	if p.MinUsernameLength > 0 && length < p.MinUsernameLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrInvalidUsername, p.MinUsernameLength)
+	}
+	if p.MaxUsernameLength > 0 && length > p.MaxUsernameLength {
+		return fmt.Errorf("%w: must be at most %d characters long", ErrInvalidUsername, p.MaxUsernameLength)
+	}
+	if p.UsernamePattern != nil && !p.UsernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: must match %s", ErrInvalidUsername, p.UsernamePattern)
+	}
+	return nil
+}
+
+// ValidatePassword проверяет стойкость пароля учётной записи username. Ошибка оборачивает ErrWeakPassword.
+func (p Policy) ValidatePassword(password, username string) error {
+	if password == "" {
+		return fmt.Errorf("%w: password is required", ErrWeakPassword)
+	}
+	if len(password) > MaxPasswordBytes {
+		return fmt.Errorf("%w: must be at most %d bytes long", ErrWeakPassword, MaxPasswordBytes)
+	}
+	if p.MinPasswordLength > 0 && utf8.RuneCountInString(password) < p.MinPasswordLength {
+		return fmt.Errorf("%w: must be at least %d characters long", ErrWeakPassword, p.MinPasswordLength)
+	}
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		return fmt.Errorf("%w: must not contain the username", ErrWeakPassword)
+	}
+	if p.MinPasswordEntropy > 0 && Entropy(password) < p.MinPasswordEntropy {
+		return fmt.Errorf("%w: too predictable, use a longer password with mixed character types", ErrWeakPassword)
+	}
+	return nil
+}
+
+// Entropy грубо оценивает энтропию пароля в битах. Каждый символ даёт log2 размера алфавита,
+// составленного из встречающихся в пароле классов символов. Повтор предыдущего символа и шаг
+// последовательности вроде "abc" или "123" дают один бит, уже встречавшийся символ — половину.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+	pool := 0
+	for _, class := range []struct {
+		present bool
+		size    int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.present {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	perChar := math.Log2(float64(pool))
+
+	var bits float64
+	seen := make(map[rune]bool)
+	prev := rune(-1)
+	for _, r := range password {
+		switch {
+		case prev >= 0 && (r == prev || r == prev+1 || r == prev-1):
+			bits++
+		case seen[r]:
+			bits += perChar / 2
+		default:
+			bits += perChar
+		}
+		seen[r] = true
+		prev = r
+	}
+	return bits
+}
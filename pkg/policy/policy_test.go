@@ -0,0 +1,45 @@
+package policy
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUsername(t *testing.T) {
+	p := Default()
+	assert.NoError(t, p.ValidateUsername("alice.smith-1"))
+	assert.ErrorIs(t, p.ValidateUsername(""), ErrInvalidUsername)
+	assert.ErrorIs(t, p.ValidateUsername("al"), ErrInvalidUsername)
+	assert.ErrorIs(t, p.ValidateUsername(strings.Repeat("a", 33)), ErrInvalidUsername)
+	assert.ErrorIs(t, p.ValidateUsername("-alice"), ErrInvalidUsername)
+	assert.ErrorIs(t, p.ValidateUsername("алиса"), ErrInvalidUsername)
+
+	// Правила настраиваются, нулевые ограничения отключены.
+	p = Policy{UsernamePattern: regexp.MustCompile(`^\p{L}+$`)}
+	assert.NoError(t, p.ValidateUsername("алиса"))
+	assert.NoError(t, p.ValidateUsername("a"))
+}
+
+func TestValidatePassword(t *testing.T) {
+	p := Default()
+	assert.NoError(t, p.ValidatePassword("correct-horse-battery", "alice"))
+	assert.NoError(t, p.ValidatePassword("Tr0ub4dor&3", "alice"))
+	assert.ErrorIs(t, p.ValidatePassword("", "alice"), ErrWeakPassword)
+	assert.ErrorIs(t, p.ValidatePassword("Xy7!", "alice"), ErrWeakPassword)
+	assert.ErrorIs(t, p.ValidatePassword("12345678", "alice"), ErrWeakPassword)
+	assert.ErrorIs(t, p.ValidatePassword("aaaaaaaaaaaaaaaa", "alice"), ErrWeakPassword)
+	assert.ErrorIs(t, p.ValidatePassword("my-ALICE-password", "alice"), ErrWeakPassword)
+	assert.ErrorIs(t, p.ValidatePassword(strings.Repeat("correct-horse-", 6), "alice"), ErrWeakPassword)
+}
+
+func TestEntropy(t *testing.T) {
+	assert.Zero(t, Entropy(""))
+	// Повторы и последовательности почти не добавляют энтропии.
+	assert.Less(t, Entropy("abcdefgh"), Entropy("hcfaegbd"))
+	assert.Less(t, Entropy("aaaaaaaa"), 12.0)
+	// Больше классов символов — больше бит на символ.
+	assert.Less(t, Entropy("qmzrtwkp"), Entropy("qM3r!wKp"))
+}
@@ -0,0 +1,11 @@
+package repository
+
+// AccountRepository удаляет учётную запись пользователя вместе со всеми его данными.
+type AccountRepository interface {
+	// DeleteAccount в одной транзакции удаляет пользователя, его записи, манифесты файлов, настройки
+	// двухфакторной аутентификации и токены обновления, снимая ссылки с чанков файлов. Сами чанки
+	// не удаляются: оставшиеся без ссылок чанки удаляет сборщик мусора после льготного периода,
+	// так как их может повторно использовать загрузка, начатая другим пользователем.
+	// Если пользователя нет, возвращается ErrNotFound.
+	DeleteAccount(userID string) error
+}
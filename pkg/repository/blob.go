@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// ErrNotFound возвращается, если запрошенный объект отсутствует в хранилище.
+var ErrNotFound = errors.New("not found")
+
+// BlobRepository хранит манифесты файлов и счётчики ссылок на чанки в хранилище блобов.
+type BlobRepository interface {
+	// AddBlob регистрирует сохранённый чанк. Повторная регистрация продлевает жизнь чанка без ссылок.
+	AddBlob(hash string, size int64) error
+	// MissingBlobs возвращает хэши из списка, чанков для которых нет в хранилище. Найденные чанки
+	// считаются использованными сейчас, чтобы сборщик мусора не удалил их, пока клиент загружает
+	// файл, который на них ссылается.
+	MissingBlobs(hashes []string) ([]string, error)
+	// GetManifest возвращает манифест файла или ErrNotFound.
+	GetManifest(userID, fileID string) (*entity.FileManifest, error)
+	// SaveManifest сохраняет манифест файла, увеличивая счётчики ссылок его чанков
+	// и уменьшая счётчики чанков заменённого манифеста. Если какого-то чанка нет в хранилище
+	// (например, его успел удалить сборщик мусора), манифест не сохраняется и возвращается ErrNotFound.
+	SaveManifest(manifest entity.FileManifest) error
+	// DeleteManifest удаляет манифест файла и уменьшает счётчики ссылок его чанков.
+	DeleteManifest(userID, fileID string) error
+	// PurgeUnreferencedBlobs удаляет чанки без ссылок, не использовавшиеся с момента before, и возвращает
+	// их хэши. Данные чанка удаляет remove, пока запись о нём заблокирована: AddBlob того же чанка ждёт
+	// окончания удаления, поэтому загрузка, начатая во время сборки мусора, сохранит данные заново.
+	// Если remove вернул ошибку, запись о чанке остаётся, и сборка мусора прекращается.
+	PurgeUnreferencedBlobs(before time.Time, remove func(hash string) error) ([]string, error)
+}
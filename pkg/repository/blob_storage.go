@@ -0,0 +1,13 @@
+package repository
+
+import "io"
+
+// BlobStorage хранит данные чанков файлов по ключу. Реализации: файловая система и S3-совместимое хранилище.
+type BlobStorage interface {
+	// Put сохраняет данные под ключом, заменяя существующие.
+	Put(key string, data []byte) error
+	// Get открывает данные на чтение или возвращает ErrNotFound.
+	Get(key string) (io.ReadCloser, error)
+	// Delete удаляет данные. Отсутствие ключа ошибкой не считается.
+	Delete(key string) error
+}
@@ -1,11 +1,22 @@
 package repository
 
-import "github.com/andranikuz/gophkeeper/pkg/entity"
+import (
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
 
 // DataItemRepository описывает набор методов для работы с данными типа DataItem.
 type DataItemRepository interface {
-	// SaveItems сохраняет срез DataItem атомарно (в транзакции).
-	SaveItems(items []entity.DataItem) error
+	// ApplyChanges в одной транзакции применяет изменения записей пользователя userID. Изменение принимается,
+	// только если ревизия записи в базе всё ещё равна его BaseRevision, либо записи в базе нет; принятые
+	// изменения получают следующие ревизии из счётчика пользователя, а BaseRevision обнуляется.
+	// Параллельные вызовы для одного пользователя выполняются по очереди, поэтому изменение, основанное
+	// на уже заменённой ревизии, отклоняется. Записи других пользователей с теми же ID не изменяются.
+	// Возвращает принятые записи и ID отклонённых.
+	ApplyChanges(userID string, changes []entity.DataItem) (accepted []entity.DataItem, rejected []string, err error)
 	// GetUserItems извлекает все объекты DataItem для заданного пользователя.
 	GetUserItems(userID string) ([]entity.DataItem, error)
+	// PurgeDeletedItems окончательно удаляет надгробия, удалённые раньше указанного момента.
+	PurgeDeletedItems(before time.Time) (int64, error)
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// RefreshTokenRepository хранит токены обновления сессий.
+type RefreshTokenRepository interface {
+	// SaveRefreshToken сохраняет новый токен.
+	SaveRefreshToken(token entity.RefreshToken) error
+	// GetRefreshToken возвращает токен по его хэшу или ErrNotFound.
+	GetRefreshToken(tokenHash string) (*entity.RefreshToken, error)
+	// RotateRefreshToken атомарно отзывает токен oldHash и сохраняет next. Если токен уже отозван
+	// (например, параллельным обновлением), возвращается ErrNotFound.
+	RotateRefreshToken(oldHash string, next entity.RefreshToken) error
+	// RevokeRefreshTokenFamily отзывает все токены семейства.
+	RevokeRefreshTokenFamily(familyID string) error
+	// RevokeUserRefreshTokens отзывает все токены пользователя.
+	RevokeUserRefreshTokens(userID string) error
+	// PurgeExpiredRefreshTokens удаляет токены, срок действия которых истёк раньше before.
+	PurgeExpiredRefreshTokens(before time.Time) (int64, error)
+}
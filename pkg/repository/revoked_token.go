@@ -0,0 +1,16 @@
+package repository
+
+import "time"
+
+// RevokedTokenRepository хранит отозванные до истечения срока JWT-токены.
+type RevokedTokenRepository interface {
+	// RevokeToken добавляет токен с идентификатором jti в список отозванных до момента expiresAt.
+	// Повторный отзыв не считается ошибкой.
+	RevokeToken(jti, userID string, expiresAt time.Time) error
+	// RevokeUserTokens отзывает все токены пользователя, выпущенные раньше before.
+	RevokeUserTokens(userID string, before time.Time) error
+	// IsTokenRevoked сообщает, отозван ли токен jti пользователя userID, выпущенный в issuedAt.
+	IsTokenRevoked(jti, userID string, issuedAt time.Time) (bool, error)
+	// PurgeExpiredRevokedTokens удаляет из списка токены, истёкшие раньше before.
+	PurgeExpiredRevokedTokens(before time.Time) (int64, error)
+}
@@ -0,0 +1,19 @@
+package repository
+
+import "github.com/andranikuz/gophkeeper/pkg/entity"
+
+// TwoFactorRepository хранит настройки двухфакторной аутентификации и коды восстановления.
+type TwoFactorRepository interface {
+	// SaveTwoFactor сохраняет настройки пользователя, заменяя прежние.
+	SaveTwoFactor(tf entity.TwoFactor) error
+	// GetTwoFactor возвращает настройки пользователя или ErrNotFound.
+	GetTwoFactor(userID string) (*entity.TwoFactor, error)
+	// EnableTwoFactor включает двухфакторную аутентификацию и заменяет коды восстановления
+	// пользователя на переданные хэши.
+	EnableTwoFactor(userID string, recoveryCodeHashes []string) error
+	// UseTOTPStep атомарно отмечает временной шаг TOTP использованным. Возвращает false,
+	// если уже использован этот или более поздний шаг.
+	UseTOTPStep(userID string, step int64) (bool, error)
+	// UseRecoveryCode удаляет код восстановления. Возвращает false, если кода нет.
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+}
@@ -1,11 +1,23 @@
 package repository
 
-import "github.com/andranikuz/gophkeeper/pkg/entity"
+import (
+	"errors"
+
+	"github.com/andranikuz/gophkeeper/pkg/entity"
+)
+
+// ErrUsernameTaken возвращается при сохранении пользователя с уже занятым именем.
+var ErrUsernameTaken = errors.New("username already taken")
 
 // UserRepository определяет операции для работы с пользователями в базе SQLite.
 type UserRepository interface {
-	// SaveUser сохраняет или обновляет пользователя в базе.
+	// SaveUser добавляет нового пользователя. Если имя уже занято, возвращается ErrUsernameTaken,
+	// а существующая учётная запись не меняется.
 	SaveUser(user entity.User) error
-	// GetUserByUsername возвращает пользователя по имени.
+	// GetUserByUsername возвращает пользователя по имени или ErrNotFound.
 	GetUserByUsername(username string) (*entity.User, error)
+	// GetUserByID возвращает пользователя по идентификатору или ErrNotFound.
+	GetUserByID(id string) (*entity.User, error)
+	// UpdatePassword заменяет хэш пароля пользователя. Если пользователя нет, возвращается ErrNotFound.
+	UpdatePassword(userID, passwordHash string) error
 }
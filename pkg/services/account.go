@@ -0,0 +1,14 @@
+package services
+
+import (
+	"context"
+	"io"
+)
+
+// AccountInterface определяет операции над учётной записью пользователя целиком.
+type AccountInterface interface {
+	// DeleteAccount безвозвратно удаляет пользователя, его записи и файлы.
+	DeleteAccount(userID string) error
+	// ExportAccount записывает в w архив со всеми данными, которые сервер хранит о пользователе.
+	ExportAccount(ctx context.Context, userID string, w io.Writer) error
+}
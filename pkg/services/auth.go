@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 
 	"github.com/golang-jwt/jwt"
 )
@@ -13,6 +14,16 @@ type Claims struct {
 	jwt.StandardClaims
 }
 
+// ErrInvalidRefreshToken возвращается для неизвестного, истёкшего или отозванного токена обновления.
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+// TokenPair — результат обновления сессии.
+type TokenPair struct {
+	UserID       string
+	AccessToken  string
+	RefreshToken string
+}
+
 // AuthenticatorInterface определяет методы для работы с аутентификацией.
 type AuthenticatorInterface interface {
 	// HashPassword принимает пароль в виде строки и возвращает его bcrypt-хэш.
@@ -23,6 +34,18 @@ type AuthenticatorInterface interface {
 	GenerateToken(userID string, username string) (string, error)
 	// ValidateToken проверяет валидность переданного JWT-токена и возвращает данные из claims.
 	ValidateToken(tokenStr string) (*Claims, error)
+	// IssueRefreshToken выпускает токен обновления для новой сессии пользователя.
+	IssueRefreshToken(userID, username string) (string, error)
+	// RefreshTokens обменивает токен обновления на новую пару токенов, отзывая предъявленный.
+	RefreshTokens(refreshToken string) (*TokenPair, error)
+	// RevokeToken отзывает JWT-токен до истечения его срока.
+	RevokeToken(claims *Claims) error
+	// RevokeAllTokens отзывает все JWT-токены и токены обновления пользователя.
+	RevokeAllTokens(userID string) error
+	// RevokeRefreshToken отзывает токен обновления.
+	RevokeRefreshToken(refreshToken string) error
+	// IsTokenRevoked сообщает, отозван ли токен.
+	IsTokenRevoked(claims *Claims) (bool, error)
 	// GetUserIdFromCtx извлекает userID из контекста.
 	GetUserIdFromCtx(ctx context.Context) (string, error)
 }
@@ -0,0 +1,30 @@
+package services
+
+import "errors"
+
+var (
+	// ErrInvalidOTP возвращается для неверного, устаревшего или уже использованного одноразового кода.
+	ErrInvalidOTP = errors.New("invalid one-time password")
+	// ErrTwoFactorNotEnrolled возвращается при подтверждении, если подключение не начато.
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	// ErrTwoFactorEnabled возвращается при повторном подключении уже включённой двухфакторной аутентификации.
+	ErrTwoFactorEnabled = errors.New("two-factor authentication is already enabled")
+)
+
+// TOTPEnrollment — данные для добавления аккаунта в приложение-аутентификатор.
+type TOTPEnrollment struct {
+	Secret string // Секрет в base32 для ручного ввода
+	URI    string // URI otpauth://totp/... для QR-кода
+}
+
+// TwoFactorInterface определяет методы двухфакторной аутентификации по TOTP.
+type TwoFactorInterface interface {
+	// Enroll начинает подключение: генерирует новый секрет TOTP для пользователя.
+	Enroll(userID, username string) (*TOTPEnrollment, error)
+	// Confirm завершает подключение кодом из приложения и возвращает одноразовые коды восстановления.
+	Confirm(userID, code string) ([]string, error)
+	// Enabled сообщает, включена ли у пользователя двухфакторная аутентификация.
+	Enabled(userID string) (bool, error)
+	// Verify проверяет код TOTP или код восстановления при входе.
+	Verify(userID, code string) error
+}
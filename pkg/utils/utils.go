@@ -1,15 +1,34 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+
 	"github.com/andranikuz/gophkeeper/pkg/entity"
-	"path/filepath"
 )
 
 const ClientDestDir = "./data/client_files"
 
 // GetLocalFilePath определяется путь к локальному файлу.
+// Имя файла в записи зашифровано, поэтому путь строится только по ID записи.
 func GetLocalFilePath(item *entity.DataItem) string {
-	// Определяем расширение файла.
-	ext := filepath.Ext(item.Content)
-	return ClientDestDir + `/` + item.ID + ext
+	return ClientDestDir + `/` + item.ID
+}
+
+// FileChecksum возвращает размер и SHA-256 (в hex) файла.
+func FileChecksum(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
 }